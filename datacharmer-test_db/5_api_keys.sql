@@ -0,0 +1,18 @@
+--  API keys of the employee service. Only a SHA-256 hash of every key is stored. roles lists the comma separated
+--  roles of non admin keys, a key without roles may do nothing.
+
+USE employees;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id            BIGINT          NOT NULL AUTO_INCREMENT,
+    owner         VARCHAR(100)    NOT NULL,
+    prefix        CHAR(12)        NOT NULL,
+    key_hash      CHAR(64)        NOT NULL,
+    admin         BOOLEAN         NOT NULL DEFAULT FALSE,
+    roles         VARCHAR(255)    NOT NULL DEFAULT '',
+    created_at    DATETIME        NOT NULL,
+    last_used_at  DATETIME        NULL,
+    revoked_at    DATETIME        NULL,
+    PRIMARY KEY (id),
+    UNIQUE  KEY (key_hash)
+);
@@ -0,0 +1,26 @@
+--  Append-only audit log of every mutation made through the employee service.
+
+USE employees;
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id            BIGINT          NOT NULL AUTO_INCREMENT,
+    created_at    DATETIME(6)     NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    actor         VARCHAR(255)    NOT NULL,
+    request_id    VARCHAR(128)    NOT NULL DEFAULT '',
+    action        VARCHAR(64)     NOT NULL,
+    emp_no        INT             NULL,
+    before_state  JSON            NULL,
+    after_state   JSON            NULL,
+    PRIMARY KEY (id),
+    KEY (emp_no, created_at),
+    KEY (actor, created_at),
+    KEY (created_at)
+);
+
+DROP TRIGGER IF EXISTS audit_log_no_update;
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log FOR EACH ROW
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log FOR EACH ROW
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
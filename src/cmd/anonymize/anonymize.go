@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/anonymize"
+	"employee_exercise/src/pkg/libs/config"
+	"employee_exercise/src/pkg/libs/database"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// anonymize writes the anonymized dataset to stdout as JSON lines. The database and EXPORT_KEY are
+// configured like the server, see the README.
+func main() {
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.Export.Key == "" {
+		fmt.Fprintln(os.Stderr, "EXPORT_KEY is required to derive the pseudonyms")
+		os.Exit(2)
+	}
+
+	db := database.GetDbEngine(cfg.MySQL)
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	service := &anonymize.ExportService{
+		ExportManager: db,
+		Pseudonymizer: anonymize.NewPseudonymizer(cfg.Export.Key, cfg.Export.JitterDays),
+	}
+
+	if err = service.Export(ctx, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "could not export: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/apikey"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
+	"employee_exercise/src/pkg/libs/database"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage: apikeys [configuration flags] <command>
+
+commands:
+  issue -owner NAME [-admin] [-roles ROLE,...]   issue a key, it is printed once and cannot be recovered
+  list                                           list keys with owner and usage metadata
+  revoke ID                                      revoke a key
+
+The database is configured like the server, see the README.`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	command, configArgs, commandArgs := splitArgs(args)
+	if command == "" {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+
+	cfg, err := config.Load(configArgs, os.LookupEnv)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not load configuration: %v\n", err)
+		return 1
+	}
+
+	db := database.GetDbEngine(cfg.MySQL)
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: actor(), Method: "cli"})
+
+	service := &apikey.APIKeyService{APIKeyManager: db}
+
+	switch command {
+	case "issue":
+		flags := flag.NewFlagSet("issue", flag.ContinueOnError)
+		flags.SetOutput(stderr)
+		owner := flags.String("owner", "", "who the key belongs to")
+		admin := flags.Bool("admin", false, "allow the key to manage other keys")
+		roles := flags.String("roles", "", "comma separated roles of the key, such as hr or analyst, none by default")
+		if err = flags.Parse(commandArgs); err != nil || *owner == "" {
+			fmt.Fprintln(stderr, usage)
+			return 2
+		}
+
+		issued, issueError := service.Issue(ctx, *owner, *admin, splitRoles(*roles))
+		if issueError != nil {
+			fmt.Fprintf(stderr, "could not issue key: %v\n", issueError)
+			return 1
+		}
+		fmt.Fprintf(stdout, "id: %d\nowner: %s\nadmin: %t\nroles: %s\nkey: %s\n", issued.ID, issued.Owner, issued.Admin, formatRoles(issued.Roles), issued.Key)
+
+	case "list":
+		keys, listError := service.List(ctx)
+		if listError != nil {
+			fmt.Fprintf(stderr, "could not list keys: %v\n", listError)
+			return 1
+		}
+
+		table := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tPREFIX\tOWNER\tADMIN\tROLES\tCREATED\tLAST USED\tREVOKED")
+		for _, key := range keys {
+			fmt.Fprintf(table, "%d\t%s\t%s\t%t\t%s\t%s\t%s\t%s\n", key.ID, key.Prefix, key.Owner, key.Admin, formatRoles(key.Roles),
+				key.CreatedAt.Format(time.RFC3339), formatTime(key.LastUsedAt), formatTime(key.RevokedAt))
+		}
+		table.Flush()
+
+	case "revoke":
+		if len(commandArgs) != 1 {
+			fmt.Fprintln(stderr, usage)
+			return 2
+		}
+
+		id, parseError := strconv.ParseInt(commandArgs[0], 10, 64)
+		if parseError != nil {
+			fmt.Fprintf(stderr, "wrong key id: %s\n", commandArgs[0])
+			return 2
+		}
+
+		if revokeError := service.Revoke(ctx, id); revokeError != nil {
+			fmt.Fprintf(stderr, "could not revoke key %d: %v\n", id, revokeError)
+			return 1
+		}
+		fmt.Fprintf(stdout, "key %d revoked\n", id)
+
+	default:
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+
+	return 0
+}
+
+// splitArgs separates configuration flags, the command and the command's own arguments.
+func splitArgs(args []string) (string, []string, []string) {
+	for i, arg := range args {
+		switch arg {
+		case "issue", "list", "revoke":
+			return arg, args[:i], args[i+1:]
+		}
+	}
+	return "", args, nil
+}
+
+// actor names the operating system user in the audit log.
+func actor() string {
+	if current, err := user.Current(); err == nil {
+		return "cli:" + current.Username
+	}
+	return "cli"
+}
+
+func splitRoles(roles string) []string {
+	var split []string
+	for _, role := range strings.Split(roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			split = append(split, role)
+		}
+	}
+	return split
+}
+
+func formatRoles(roles []string) string {
+	if len(roles) == 0 {
+		return "-"
+	}
+	return strings.Join(roles, ",")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
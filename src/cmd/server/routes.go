@@ -0,0 +1,64 @@
+package main
+
+import (
+	"employee_exercise/src/pkg/controllers"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/openapi"
+	"employee_exercise/src/pkg/libs/ratelimit"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// publicPaths are served without credentials.
+var publicPaths = []string{"/healthz", "/readyz", "/metrics", "/openapi.json", "/docs", "/docs/{asset}"}
+
+// handlers are the controllers behind the routes. Export is nil when exports are disabled.
+type handlers struct {
+	Metrics      http.Handler
+	Health       controllers.HealthController
+	Employee     controllers.EmployeeController
+	Report       controllers.ReportController
+	Organization controllers.OrganizationController
+	APIKey       controllers.APIKeyController
+	Audit        controllers.AuditController
+	GraphQL      controllers.GraphQLController
+	Export       *controllers.ExportController
+}
+
+// protect authenticates and rate limits the routes. Failed authentications are charged to the client's IP
+// before credentials are checked, every other request to its principal once authentication resolved it.
+func protect(router *mux.Router, authenticator *auth.Authenticator, limiter *ratelimit.Limiter) {
+	router.Use(limiter.Failures, authenticator.Middleware, limiter.Middleware)
+}
+
+// registerRoutes adds every route of the API. Each one is described in the OpenAPI document, which the tests
+// check against this list.
+func registerRoutes(router *mux.Router, h handlers) {
+	readEmployees := []auth.Permission{auth.PermissionReadEmployees, auth.PermissionReadDepartmentEmployees}
+
+	router.Handle("/metrics", h.Metrics).Methods("GET")
+	router.HandleFunc("/healthz", h.Health.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", h.Health.Readiness).Methods("GET")
+	router.Handle("/openapi.json", openapi.Handler()).Methods("GET")
+	router.Handle("/docs", openapi.DocsHandler()).Methods("GET")
+	router.Handle("/docs/{asset}", openapi.DocsAssetHandler()).Methods("GET")
+	router.HandleFunc("/employees", auth.RequirePermission(h.Employee.GetEmployees, readEmployees...)).Methods("GET")
+	router.HandleFunc("/employees/{emp_no}/timeline", auth.RequirePermission(h.Employee.GetEmployeeTimeline, readEmployees...)).Methods("GET")
+	router.HandleFunc("/employees_department", auth.RequirePermission(h.Employee.AddEmployeeToDepartment, auth.PermissionWriteDepartments)).Methods("POST")
+	router.HandleFunc("/graphql", auth.RequirePermission(h.GraphQL.Query, append(readEmployees, auth.PermissionReadDepartments)...)).Methods("POST")
+	router.HandleFunc("/reports/headcount", auth.RequirePermission(h.Report.GetHeadcount, auth.PermissionReadReports)).Methods("GET")
+	router.HandleFunc("/reports/salaries", auth.RequirePermission(h.Report.GetSalaryStatistics, auth.PermissionReadSalaries)).Methods("GET")
+	router.HandleFunc("/reports/salaries/gender-gap", auth.RequirePermission(h.Report.GetSalaryGenderGap, auth.PermissionReadSalaries)).Methods("GET")
+	router.HandleFunc("/reports/attrition", auth.RequirePermission(h.Report.GetAttrition, auth.PermissionReadReports)).Methods("GET")
+	router.HandleFunc("/reports/tenure", auth.RequirePermission(h.Report.GetTenure, auth.PermissionReadReports)).Methods("GET")
+	router.HandleFunc("/org-chart", auth.RequirePermission(h.Organization.GetOrgChart, auth.PermissionReadDepartments)).Methods("GET")
+	router.HandleFunc("/org-chart/{dept_no}", auth.RequirePermission(h.Organization.GetOrgChart, auth.PermissionReadDepartments)).Methods("GET")
+	router.HandleFunc("/api-keys", auth.RequirePermission(h.APIKey.IssueAPIKey, auth.PermissionManageAPIKeys)).Methods("POST")
+	router.HandleFunc("/api-keys", auth.RequirePermission(h.APIKey.ListAPIKeys, auth.PermissionManageAPIKeys)).Methods("GET")
+	router.HandleFunc("/api-keys/{id}", auth.RequirePermission(h.APIKey.RevokeAPIKey, auth.PermissionManageAPIKeys)).Methods("DELETE")
+	router.HandleFunc("/audit", auth.RequirePermission(h.Audit.GetAuditLog, auth.PermissionReadAudit)).Methods("GET")
+
+	if h.Export != nil {
+		router.HandleFunc("/exports/anonymized", auth.RequirePermission(h.Export.GetAnonymizedExport, auth.PermissionExportAnonymized)).Methods("GET")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"employee_exercise/src/pkg/controllers"
+	"employee_exercise/src/pkg/libs/apikey"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
+	"employee_exercise/src/pkg/libs/openapi"
+	"employee_exercise/src/pkg/libs/ratelimit"
+	"employee_exercise/src/pkg/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRegisterRoutes_Matches_openapi_document(t *testing.T) {
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	registerRoutes(router, handlers{Metrics: http.NotFoundHandler(), Export: &controllers.ExportController{}})
+
+	var routes []string
+	require.NoError(t, router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+		return nil
+	}))
+	sort.Strings(routes)
+
+	assert.Equal(t, spec.Routes(), routes)
+}
+
+func TestPublicPaths_Match_openapi_document(t *testing.T) {
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+
+	var public []string
+	for _, route := range spec.Routes() {
+		method, path, _ := strings.Cut(route, " ")
+		if operation, _ := spec.Operation(method, path); operation.Public() {
+			public = append(public, path)
+		}
+	}
+
+	assert.ElementsMatch(t, publicPaths, public)
+}
+
+type rejectingKeys struct {
+	attempts int
+}
+
+func (k *rejectingKeys) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	k.attempts++
+	return nil, auth.ErrInvalidAPIKey
+}
+
+func TestProtect_Rate_limits_failed_authentications(t *testing.T) {
+	keys := &rejectingKeys{}
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), func(string) config.RateQuota { return config.RateQuota{Rate: 1, Burst: 3} })
+
+	router := mux.NewRouter()
+	protect(router, auth.New(keys, publicPaths...), limiter)
+	registerRoutes(router, handlers{Metrics: http.NotFoundHandler()})
+
+	var codes []int
+	for i := 0; i < 5; i++ {
+		request := httptest.NewRequest(http.MethodGet, "/employees", nil)
+		request.RemoteAddr = "10.0.0.1:" + strconv.Itoa(5000+i)
+		request.Header.Set(apikey.Header, "emp_guess"+strconv.Itoa(i))
+		recorder := httptest.NewRecorder()
+
+		router.ServeHTTP(recorder, request)
+		codes = append(codes, recorder.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, 3, keys.attempts, "guesses past the quota are not looked up")
+}
@@ -1,48 +1,197 @@
 package main
 
 import (
+	"context"
 	"employee_exercise/src/pkg/controllers"
+	"employee_exercise/src/pkg/libs/anonymize"
+	"employee_exercise/src/pkg/libs/apikey"
+	"employee_exercise/src/pkg/libs/audit"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
 	"employee_exercise/src/pkg/libs/database"
 	"employee_exercise/src/pkg/libs/employee"
-	"github.com/google/logger"
+	"employee_exercise/src/pkg/libs/graph"
+	"employee_exercise/src/pkg/libs/grpcserver"
+	"employee_exercise/src/pkg/libs/health"
+	"employee_exercise/src/pkg/libs/httpserver"
+	"employee_exercise/src/pkg/libs/logging"
+	"employee_exercise/src/pkg/libs/metrics"
+	"employee_exercise/src/pkg/libs/openapi"
+	"employee_exercise/src/pkg/libs/organization"
+	"employee_exercise/src/pkg/libs/privacy"
+	"employee_exercise/src/pkg/libs/ratelimit"
+	"employee_exercise/src/pkg/libs/reports"
+	"employee_exercise/src/pkg/libs/tracing"
 	"github.com/gorilla/mux"
-	"go.uber.org/ratelimit"
-	"net/http"
+	"log/slog"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
 
-	envErrors := readEnv()
-	if len(envErrors) != 0 {
-		logger.Fatal("could not process environment: ", envErrors)
+	cfg, configError := config.Load(os.Args[1:], os.LookupEnv)
+	if configError != nil {
+		slog.Error("could not load configuration", "error", configError)
 		os.Exit(1)
 	}
 
-	rate := os.Getenv("RATE_LIMIT")
-	rateLimit, rateError := strconv.Atoi(rate)
-	if rateError != nil {
-		logger.Fatal("RATE_LIMIT paramter is not a number: ", rateError)
+	slog.SetDefault(logging.New(os.Stdout, cfg.Log))
+	slog.Info("effective configuration", "config", cfg.Redacted())
+
+	db := database.GetDbEngine(cfg.MySQL)
+
+	serverMetrics := metrics.New(db)
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == "redis" {
+		redisStore := ratelimit.NewRedisStore(cfg.Redis)
+		defer redisStore.Client.Close()
+		rateLimitStore = redisStore
+	}
+
+	rateLimiter := ratelimit.New(rateLimitStore, cfg.Quota, "/healthz", "/readyz", "/metrics")
+	rateLimiter.Observer = serverMetrics.RateLimited
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, tracingError := tracing.Setup(ctx, cfg.Tracing, os.Stdout)
+	if tracingError != nil {
+		slog.Error("could not set up tracing", "error", tracingError)
 		os.Exit(1)
 	}
 
-	rateLimiter := ratelimit.New(rateLimit)
+	if waitError := database.WaitForDatabase(ctx, db, cfg.MySQL.ConnectTimeout); waitError != nil {
+		slog.Error("could not reach the database", "error", waitError)
+		os.Exit(1)
+	}
 
+	masker := privacy.NewMasker(cfg.PII)
+	employeeService := &employee.EmployeeService{EmployeeManager: db}
 	employeeController := controllers.EmployeeController{
-		EmployeeService: &employee.EmployeeService{
-			EmployeeManager: database.GetDbEngine(),
+		EmployeeService: employeeService,
+		DefaultPageSize: cfg.DefaultPageSize,
+		Masker:          masker,
+	}
+
+	var reportCache *reports.Cache
+	if cfg.ReportCacheTTL > 0 {
+		reportCache = reports.NewCache(cfg.ReportCacheTTL)
+	}
+
+	reportController := controllers.ReportController{
+		ReportService: &reports.ReportService{
+			ReportManager: db,
+			Cache:         reportCache,
+		},
+		CacheMaxAge: cfg.ReportCacheTTL,
+	}
+
+	organizationController := controllers.OrganizationController{
+		OrganizationService: &organization.OrganizationService{
+			OrganizationManager: db,
+			Scope:               employeeService,
+		},
+	}
+
+	apiKeyService := &apikey.APIKeyService{APIKeyManager: db}
+	apiKeyController := controllers.APIKeyController{APIKeyService: apiKeyService}
+	authenticator := auth.New(apiKeyService, publicPaths...)
+	tokenVerifier, jwksError := auth.NewTokenVerifier(cfg.JWT)
+	if jwksError != nil {
+		slog.Error("error loading jwt signing keys", "error", jwksError)
+		os.Exit(1)
+	}
+	authenticator.Tokens = tokenVerifier
+
+	auditController := controllers.AuditController{
+		AuditService:    &audit.AuditService{AuditManager: db},
+		DefaultPageSize: cfg.DefaultPageSize,
+	}
+
+	healthController := controllers.HealthController{
+		HealthService: &health.HealthService{
+			HealthManager:  db,
+			RequiredTables: health.RequiredTables,
+			PingTimeout:    2 * time.Second,
 		},
-		RateLimiter: rateLimiter,
 	}
 
+	graphServer, graphError := graph.NewServer(employeeService, masker, cfg.DefaultPageSize, cfg.GraphQL)
+	if graphError != nil {
+		slog.Error("could not build the graphql schema", "error", graphError)
+		os.Exit(1)
+	}
+
+	routes := handlers{
+		Metrics:      serverMetrics.Handler(),
+		Health:       healthController,
+		Employee:     employeeController,
+		Report:       reportController,
+		Organization: organizationController,
+		APIKey:       apiKeyController,
+		Audit:        auditController,
+		GraphQL:      controllers.GraphQLController{GraphQLService: graphServer},
+	}
+	if cfg.Export.Key != "" {
+		routes.Export = &controllers.ExportController{
+			ExportService: &anonymize.ExportService{
+				ExportManager: db,
+				Pseudonymizer: anonymize.NewPseudonymizer(cfg.Export.Key, cfg.Export.JitterDays),
+			},
+		}
+	}
+
+	spec, specError := openapi.Load()
+	if specError != nil {
+		slog.Error("could not load the openapi document", "error", specError)
+		os.Exit(1)
+	}
+	contract := openapi.NewValidator(spec, cfg.OpenAPI.ValidateResponses)
+
 	router := mux.NewRouter()
-	router.HandleFunc("/employees", employeeController.GetEmployees).Methods("GET")
-	router.HandleFunc("/employees_department", employeeController.AddEmployeeToDepartment).Methods("POST")
+	router.Use(tracing.Middleware, logging.Middleware, serverMetrics.Middleware)
+	protect(router, authenticator, rateLimiter)
+	router.Use(contract.Middleware)
+	registerRoutes(router, routes)
 
-	err := http.ListenAndServe(":80", router)
+	// Either server failing stops the other one.
+	serveContext, cancelServe := context.WithCancel(ctx)
+	defer cancelServe()
+
+	grpcResult := make(chan error, 1)
+	if cfg.GRPC.Address != "" {
+		grpcServer := grpcserver.New(authenticator, &grpcserver.EmployeeServer{EmployeeService: employeeService, Masker: masker})
+		go func() {
+			grpcError := grpcserver.Run(serveContext, grpcServer, cfg.GRPC.Address, cfg.Server.ShutdownTimeout)
+			cancelServe()
+			grpcResult <- grpcError
+		}()
+	} else {
+		grpcResult <- nil
+	}
+
+	err := httpserver.Run(serveContext, httpserver.New(cfg.Server, router), cfg.Server.ShutdownTimeout)
 	if err != nil {
-		logger.Fatal(nil, "Error listening on port 80 ")
-		return
+		slog.Error("error serving", "address", cfg.Server.Address, "error", err)
+	}
+	cancelServe()
+
+	if grpcError := <-grpcResult; grpcError != nil {
+		slog.Error("error serving grpc", "address", cfg.GRPC.Address, "error", grpcError)
 	}
+
+	if closeError := db.Close(); closeError != nil {
+		slog.Error("error closing database pool", "error", closeError)
+	}
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if flushError := shutdownTracing(flushCtx); flushError != nil {
+		slog.Error("error flushing traces", "error", flushError)
+	}
+
+	slog.Info("server stopped")
 }
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/apikey"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/validation"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type APIKeyManager interface {
+	Issue(ctx context.Context, owner string, admin bool, roles []string) (*models.IssuedAPIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+}
+
+type APIKeyController struct {
+	APIKeyService APIKeyManager
+}
+
+func (a *APIKeyController) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var apiKeyRequest models.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&apiKeyRequest); err != nil {
+		slog.ErrorContext(r.Context(), "error unmarshalling request body", "error", err)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "bad request, wrong request body"))
+		return
+	}
+
+	apiKeyRequest.Owner = strings.TrimSpace(apiKeyRequest.Owner)
+	if violations := validation.Struct(apiKeyRequest); len(violations) != 0 {
+		problem.Write(w, r, problem.Validation(violations))
+		return
+	}
+
+	issued, err := a.APIKeyService.Issue(r.Context(), apiKeyRequest.Owner, apiKeyRequest.Admin, apiKeyRequest.Roles)
+	if err != nil {
+		if errors.Is(err, apikey.ErrUnknownRole) {
+			problem.Write(w, r, problem.InvalidParameter("roles"))
+			return
+		}
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	writeResponse(w, http.StatusCreated, issued)
+}
+
+func (a *APIKeyController) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	keys, err := a.APIKeyService.List(r.Context())
+	if err != nil {
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	writeResponse(w, http.StatusOK, keys)
+}
+
+func (a *APIKeyController) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	response := make(map[string]string)
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id < 1 {
+		problem.Write(w, r, problem.InvalidParameter("id"))
+		return
+	}
+
+	if revokeError := a.APIKeyService.Revoke(r.Context(), id); revokeError != nil {
+		if revokeError == apikey.ErrKeyNotFound {
+			problem.Write(w, r, problem.New(http.StatusNotFound, codeAPIKeyNotFound, "api key not found"))
+			return
+		}
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	response["message"] = "api key revoked successfully"
+	writeResponse(w, http.StatusOK, response)
+}
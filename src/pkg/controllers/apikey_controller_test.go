@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/apikey"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type APIKeyManagerMock struct {
+	issued     *models.IssuedAPIKey
+	keys       []models.APIKey
+	err        error
+	owner      string
+	admin      bool
+	roles      []string
+	revokedKey int64
+}
+
+func (a *APIKeyManagerMock) Issue(ctx context.Context, owner string, admin bool, roles []string) (*models.IssuedAPIKey, error) {
+	a.owner = owner
+	a.admin = admin
+	a.roles = roles
+	return a.issued, a.err
+}
+
+func (a *APIKeyManagerMock) List(ctx context.Context) ([]models.APIKey, error) {
+	return a.keys, a.err
+}
+
+func (a *APIKeyManagerMock) Revoke(ctx context.Context, id int64) error {
+	a.revokedKey = id
+	return a.err
+}
+
+func TestAPIKeyController(t *testing.T) {
+	createdAt := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		apiKeyService        *APIKeyManagerMock
+		method               string
+		url                  string
+		body                 string
+		expectedResponseCode int
+		expectedResponseBody string
+		expectedOwner        string
+		expectedRoles        []string
+		expectedRevokedKey   int64
+	}{
+		{
+			name: "issue api key succeeds",
+			apiKeyService: &APIKeyManagerMock{issued: &models.IssuedAPIKey{
+				APIKey: models.APIKey{ID: 3, Owner: "payroll", Prefix: "emp_abcdefgh", Roles: []string{"hr"}, CreatedAt: createdAt},
+				Key:    "emp_abcdefghijkl",
+			}},
+			method:               http.MethodPost,
+			url:                  "/api-keys",
+			body:                 `{"owner": " payroll ", "roles": ["hr"]}`,
+			expectedResponseCode: http.StatusCreated,
+			expectedResponseBody: `{"id":3,"owner":"payroll","prefix":"emp_abcdefgh","admin":false,"roles":["hr"],"created_at":"2021-03-04T05:06:07Z","key":"emp_abcdefghijkl"}`,
+			expectedOwner:        "payroll",
+			expectedRoles:        []string{"hr"},
+		},
+		{
+			name:                 "issue api key with unknown role returns bad request",
+			apiKeyService:        &APIKeyManagerMock{err: fmt.Errorf("%w: %q", apikey.ErrUnknownRole, "boss")},
+			method:               http.MethodPost,
+			url:                  "/api-keys",
+			body:                 `{"owner": "payroll", "roles": ["boss"]}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong roles parameter","instance":"/api-keys","code":"invalid_parameter","errors":[{"field":"roles","code":"invalid","message":"wrong roles parameter"}]}`,
+			expectedOwner:        "payroll",
+			expectedRoles:        []string{"boss"},
+		},
+		{
+			name:                 "issue api key without owner returns bad request",
+			apiKeyService:        &APIKeyManagerMock{},
+			method:               http.MethodPost,
+			url:                  "/api-keys",
+			body:                 `{"admin": true}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/api-keys","code":"validation_failed","errors":[{"field":"owner","code":"required","message":"is required"}]}`,
+		},
+		{
+			name:                 "issue api key with blank owner returns bad request",
+			apiKeyService:        &APIKeyManagerMock{},
+			method:               http.MethodPost,
+			url:                  "/api-keys",
+			body:                 `{"owner": "   "}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/api-keys","code":"validation_failed","errors":[{"field":"owner","code":"required","message":"is required"}]}`,
+		},
+		{
+			name:                 "issue api key with long owner returns bad request",
+			apiKeyService:        &APIKeyManagerMock{},
+			method:               http.MethodPost,
+			url:                  "/api-keys",
+			body:                 `{"owner": "` + strings.Repeat("a", 101) + `"}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/api-keys","code":"validation_failed","errors":[{"field":"owner","code":"max","message":"must be at most 100 characters"}]}`,
+		},
+		{
+			name:                 "issue api key with wrong body returns bad request",
+			apiKeyService:        &APIKeyManagerMock{},
+			method:               http.MethodPost,
+			url:                  "/api-keys",
+			body:                 `owner`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong request body","instance":"/api-keys","code":"invalid_body"}`,
+		},
+		{
+			name:                 "list api keys succeeds",
+			apiKeyService:        &APIKeyManagerMock{keys: []models.APIKey{{ID: 1, Owner: "ops", Prefix: "emp_12345678", Admin: true, Roles: []string{}, CreatedAt: createdAt, LastUsedAt: &createdAt}}},
+			method:               http.MethodGet,
+			url:                  "/api-keys",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `[{"id":1,"owner":"ops","prefix":"emp_12345678","admin":true,"roles":[],"created_at":"2021-03-04T05:06:07Z","last_used_at":"2021-03-04T05:06:07Z"}]`,
+		},
+		{
+			name:                 "list api keys fails",
+			apiKeyService:        &APIKeyManagerMock{err: errors.New("connection reset")},
+			method:               http.MethodGet,
+			url:                  "/api-keys",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/api-keys","code":"internal_error"}`,
+		},
+		{
+			name:                 "revoke api key succeeds",
+			apiKeyService:        &APIKeyManagerMock{},
+			method:               http.MethodDelete,
+			url:                  "/api-keys/3",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"message":"api key revoked successfully"}`,
+			expectedRevokedKey:   3,
+		},
+		{
+			name:                 "revoke unknown api key returns not found",
+			apiKeyService:        &APIKeyManagerMock{err: apikey.ErrKeyNotFound},
+			method:               http.MethodDelete,
+			url:                  "/api-keys/9",
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"api key not found","instance":"/api-keys/9","code":"api_key_not_found"}`,
+			expectedRevokedKey:   9,
+		},
+		{
+			name:                 "revoke api key with wrong id returns bad request",
+			apiKeyService:        &APIKeyManagerMock{},
+			method:               http.MethodDelete,
+			url:                  "/api-keys/abc",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong id parameter","instance":"/api-keys/abc","code":"invalid_parameter","errors":[{"field":"id","code":"invalid","message":"wrong id parameter"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKeyController := &APIKeyController{APIKeyService: tt.apiKeyService}
+
+			router := mux.NewRouter()
+			router.HandleFunc("/api-keys", apiKeyController.IssueAPIKey).Methods("POST")
+			router.HandleFunc("/api-keys", apiKeyController.ListAPIKeys).Methods("GET")
+			router.HandleFunc("/api-keys/{id}", apiKeyController.RevokeAPIKey).Methods("DELETE")
+
+			request, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, request)
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			expectedContentType := "application/json"
+			if tt.expectedResponseCode >= http.StatusBadRequest {
+				expectedContentType = problem.ContentType
+			}
+			assert.Equal(t, expectedContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedResponseBody, rr.Body.String())
+			assert.Equal(t, tt.expectedOwner, tt.apiKeyService.owner)
+			assert.Equal(t, tt.expectedRoles, tt.apiKeyService.roles)
+			assert.Equal(t, tt.expectedRevokedKey, tt.apiKeyService.revokedKey)
+		})
+	}
+}
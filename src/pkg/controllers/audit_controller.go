@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/validation"
+	"employee_exercise/src/pkg/models"
+	"net/http"
+	"time"
+)
+
+type AuditManager interface {
+	GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type AuditController struct {
+	AuditService    AuditManager
+	DefaultPageSize int
+}
+
+// GetAuditLog lists audit entries, newest first. from and to are inclusive days.
+func (a *AuditController) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var auditQuery models.AuditQuery
+	if violations := validation.Query(r.URL.Query(), &auditQuery); len(violations) != 0 {
+		problem.Write(w, r, problem.Validation(violations))
+		return
+	}
+
+	filter := models.AuditFilter{Actor: auditQuery.Actor}
+	if auditQuery.EmployeeNumber != nil {
+		filter.EmployeeNumber = *auditQuery.EmployeeNumber
+	}
+	if auditQuery.From != "" {
+		filter.From, _ = time.Parse(validation.DateLayout, auditQuery.From)
+	}
+	if auditQuery.To != "" {
+		to, _ := time.Parse(validation.DateLayout, auditQuery.To)
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	limit, page := pageOf(auditQuery.PageRequest, a.DefaultPageSize)
+	filter.Limit = limit
+	filter.Offset = limit * (page - 1)
+
+	entries, err := a.AuditService.GetAuditLog(r.Context(), filter)
+	if err != nil {
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	writeResponse(w, http.StatusOK, models.AuditResponse{Page: page, Entries: entries})
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type AuditManagerMock struct {
+	entries []models.AuditEntry
+	err     error
+	filter  models.AuditFilter
+}
+
+func (a *AuditManagerMock) GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	a.filter = filter
+	return a.entries, a.err
+}
+
+func TestAuditController_GetAuditLog(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	employeeNumber := 10002
+
+	tests := []struct {
+		name                 string
+		auditService         *AuditManagerMock
+		url                  string
+		expectedResponseCode int
+		expectedResponseBody string
+		expectedFilter       models.AuditFilter
+	}{
+		{
+			name: "get audit log succeeds",
+			auditService: &AuditManagerMock{entries: []models.AuditEntry{{
+				ID: 2, CreatedAt: createdAt, Actor: "jwt:alice", RequestID: "abc", Action: "employee_department.update",
+				EmployeeNumber: &employeeNumber, Before: json.RawMessage(`{"dept_no":"d006"}`), After: json.RawMessage(`{"dept_no":"d005"}`),
+			}}},
+			url:                  "/audit?emp_no=10002&actor=jwt:alice&from=2024-03-01&to=2024-03-01&limit=10&page=3",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"page":3,"entries":[{"id":2,"created_at":"2024-03-01T10:00:00Z","actor":"jwt:alice","request_id":"abc","action":"employee_department.update","emp_no":10002,"before":{"dept_no":"d006"},"after":{"dept_no":"d005"}}]}`,
+			expectedFilter: models.AuditFilter{
+				EmployeeNumber: 10002,
+				Actor:          "jwt:alice",
+				From:           time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				To:             time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+				Limit:          10,
+				Offset:         20,
+			},
+		},
+		{
+			name:                 "get audit log without filters uses the default page size",
+			auditService:         &AuditManagerMock{entries: []models.AuditEntry{}},
+			url:                  "/audit",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"page":1,"entries":[]}`,
+			expectedFilter:       models.AuditFilter{Limit: 25},
+		},
+		{
+			name:                 "get audit log with wrong emp_no returns bad request",
+			auditService:         &AuditManagerMock{},
+			url:                  "/audit?emp_no=abc",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/audit","code":"validation_failed","errors":[{"field":"emp_no","code":"invalid","message":"must be an integer"}]}`,
+		},
+		{
+			name:                 "get audit log with wrong from returns bad request",
+			auditService:         &AuditManagerMock{},
+			url:                  "/audit?from=01-03-2024",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/audit","code":"validation_failed","errors":[{"field":"from","code":"date","message":"must be a date formatted as YYYY-MM-DD"}]}`,
+		},
+		{
+			name:                 "get audit log with to before from returns bad request",
+			auditService:         &AuditManagerMock{},
+			url:                  "/audit?from=2024-03-02&to=2024-03-01",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/audit","code":"validation_failed","errors":[{"field":"to","code":"not_before","message":"must not be before from"}]}`,
+		},
+		{
+			name:                 "get audit log with wrong page returns bad request",
+			auditService:         &AuditManagerMock{},
+			url:                  "/audit?page=0",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/audit","code":"validation_failed","errors":[{"field":"page","code":"min","message":"must be at least 1"}]}`,
+		},
+		{
+			name:                 "get audit log fails",
+			auditService:         &AuditManagerMock{err: errors.New("connection reset")},
+			url:                  "/audit",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/audit","code":"internal_error"}`,
+			expectedFilter:       models.AuditFilter{Limit: 25},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := AuditController{AuditService: tt.auditService, DefaultPageSize: 25}
+			request := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			recorder := httptest.NewRecorder()
+
+			controller.GetAuditLog(recorder, request)
+
+			assert.Equal(t, tt.expectedResponseCode, recorder.Code)
+			assert.Equal(t, tt.expectedResponseBody, recorder.Body.String())
+			assert.Equal(t, tt.expectedFilter, tt.auditService.filter)
+		})
+	}
+}
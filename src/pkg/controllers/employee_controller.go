@@ -2,12 +2,13 @@ package controllers
 
 import (
 	"context"
-	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/libs/privacy"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/validation"
 	"employee_exercise/src/pkg/models"
 	"encoding/json"
-	"fmt"
-	"github.com/google/logger"
-	"go.uber.org/ratelimit"
+	"github.com/gorilla/mux"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
@@ -16,108 +17,84 @@ import (
 
 type EmployeeManager interface {
 	GetEmployees(ctx context.Context, parameters map[string]string) (*models.EmployeeResponse, error)
-	UpdateEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) employee.EmployeeError
+	UpdateEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) error
+	GetEmployeeTimeline(ctx context.Context, employeeID int) (*models.EmployeeTimeline, error)
 }
 
+const defaultPageSize = 50
+
 type EmployeeController struct {
 	EmployeeService EmployeeManager
-	RateLimiter     ratelimit.Limiter
+	DefaultPageSize int
+	Masker          *privacy.Masker
 }
 
 func (e *EmployeeController) GetEmployees(w http.ResponseWriter, r *http.Request) {
-	e.RateLimiter.Take()
 	w.Header().Set("Content-Type", "application/json")
-	response := make(map[string]string)
-	parameters := make(map[string]string)
-	var intLimit int
-	var convertError error
-	limit := r.URL.Query().Get("limit")
-	if limit != "" {
-		intLimit, convertError = strconv.Atoi(limit)
-		if convertError != nil || intLimit < 1 {
-			response["message"] = "bad request, wrong limit parameter"
-			writeResponse(w, http.StatusBadRequest, response)
-			return
-		}
-	} else {
-		limit = "50"
-		intLimit, _ = strconv.Atoi(limit)
-	}
-
-	page := r.URL.Query().Get("page")
-	if page == "" {
-		page = "1"
-	}
-
-	intPage, err := strconv.Atoi(page)
-	if err != nil || intPage < 1 {
-		response["message"] = "bad request, wrong page parameter"
-		writeResponse(w, http.StatusBadRequest, response)
+	var employeeQuery models.EmployeeQuery
+	if violations := validation.Query(r.URL.Query(), &employeeQuery); len(violations) != 0 {
+		problem.Write(w, r, problem.Validation(violations))
 		return
 	}
 
-	offset := intLimit * (intPage - 1)
-
-	parameters["offset"] = fmt.Sprintf("%d", offset)
+	limit, page := pageOf(employeeQuery.PageRequest, e.DefaultPageSize)
+	parameters := make(map[string]string)
+	parameters["offset"] = strconv.Itoa(limit * (page - 1))
 
-	order := r.URL.Query().Get("order")
-	order = strings.ToLower(order)
-	if order != "desc" {
+	order := strings.ToLower(employeeQuery.Order)
+	if order == "" {
 		order = "asc"
 	}
 
 	parameters["order"] = order
 
-	orderByColumn := strings.ToUpper(r.URL.Query().Get("orderBy"))
+	orderByColumn := strings.ToLower(employeeQuery.OrderBy)
 	if orderByColumn == "" {
 		orderByColumn = "first_name"
 	}
 
 	parameters["order_by_column"] = orderByColumn
 
-	parameters["limit"] = limit
+	parameters["limit"] = strconv.Itoa(limit)
 	employees, err := e.EmployeeService.GetEmployees(r.Context(), parameters)
 	if err != nil {
-		response["message"] = "internal server error"
-		writeResponse(w, http.StatusInternalServerError, response)
+		writeError(w, r, err)
 		return
 	}
 
-	employees.Page = intPage
+	employees.Page = page
 
-	writeResponse(w, http.StatusOK, employees)
+	writeMaskedResponse(w, r, e.Masker, employees)
 }
 
 func (e *EmployeeController) AddEmployeeToDepartment(w http.ResponseWriter, r *http.Request) {
-	e.RateLimiter.Take()
+	w.Header().Set("Content-Type", "application/json")
 	response := make(map[string]string)
 	var employeeDepartmentRequest models.EmployeeDepartmentRequest
 	unmarshalErr := json.NewDecoder(r.Body).Decode(&employeeDepartmentRequest)
 	if unmarshalErr != nil {
-		logger.Errorf("error unmarshalling request body: %v", unmarshalErr)
-		response["message"] = "bad request, wrong request body"
-		writeResponse(w, http.StatusBadRequest, response)
+		slog.ErrorContext(r.Context(), "error unmarshalling request body", "error", unmarshalErr)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "bad request, wrong request body"))
 		return
 	}
 
-	fromDate, toDate, errorMessage := validateDates(employeeDepartmentRequest.FromDate, employeeDepartmentRequest.ToDate)
-	if errorMessage != "" {
-		response["message"] = errorMessage
-		writeResponse(w, http.StatusBadRequest, response)
+	if violations := validation.Struct(employeeDepartmentRequest); len(violations) != 0 {
+		problem.Write(w, r, problem.Validation(violations))
 		return
 	}
 
+	fromDate, _ := time.Parse(validation.DateLayout, employeeDepartmentRequest.FromDate)
+	toDate, _ := time.Parse(validation.DateLayout, employeeDepartmentRequest.ToDate)
 	employeeDepartment := models.EmployeeDepartment{
 		EmployeeNumber: employeeDepartmentRequest.EmployeeNumber,
 		Department:     employeeDepartmentRequest.Department,
-		FromDate:       *fromDate,
-		ToDate:         *toDate,
+		FromDate:       fromDate,
+		ToDate:         toDate,
 	}
 
 	updateError := e.EmployeeService.UpdateEmployeeDepartment(r.Context(), employeeDepartment)
-	if updateError.Error != nil {
-		response["message"] = updateError.ErrorMessage
-		writeResponse(w, updateError.ResponseStatusCode, response)
+	if updateError != nil {
+		writeError(w, r, updateError)
 		return
 	}
 
@@ -126,26 +103,71 @@ func (e *EmployeeController) AddEmployeeToDepartment(w http.ResponseWriter, r *h
 
 }
 
+func (e *EmployeeController) GetEmployeeTimeline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	employeeID, err := strconv.Atoi(mux.Vars(r)["emp_no"])
+	if err != nil || employeeID < 1 {
+		problem.Write(w, r, problem.InvalidParameter("emp_no"))
+		return
+	}
+
+	timeline, timelineError := e.EmployeeService.GetEmployeeTimeline(r.Context(), employeeID)
+	if timelineError != nil {
+		writeError(w, r, timelineError)
+		return
+	}
+
+	writeMaskedResponse(w, r, e.Masker, timeline)
+}
+
+// writeMaskedResponse writes a 200 response with the caller's personal data masked.
+func writeMaskedResponse(w http.ResponseWriter, r *http.Request, masker *privacy.Masker, response interface{}) {
+	masked, err := masker.Mask(r.Context(), response)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error masking response", "error", err)
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	writeResponse(w, http.StatusOK, masked)
+}
+
 func writeResponse(w http.ResponseWriter, httpStatusCode int, response interface{}) {
 	w.WriteHeader(httpStatusCode)
 	jsonResp, _ := json.Marshal(response)
 	w.Write(jsonResp)
 }
 
-func validateDates(fromDateRequest, toDateRequest string) (*time.Time, *time.Time, string) {
+func validateNamedDates(fromName, fromDateRequest, toName, toDateRequest string) (*time.Time, *time.Time, *problem.Problem) {
 	toDate, err := time.Parse("2006-01-02", toDateRequest)
 	if err != nil {
-		return nil, nil, "bad request, wrong to_date parameter"
+		return nil, nil, problem.InvalidParameter(toName)
 	}
 
 	fromDate, err := time.Parse("2006-01-02", fromDateRequest)
 	if err != nil {
-		return nil, nil, "bad request, wrong from_date parameter"
+		return nil, nil, problem.InvalidParameter(fromName)
 	}
 
 	if toDate.Before(fromDate) {
-		return nil, nil, "bad request, wrong dates range"
+		return nil, nil, datesRangeProblem(fromName, toName)
 	}
 
-	return &fromDate, &toDate, ""
+	return &fromDate, &toDate, nil
+}
+
+// pageOf resolves the limit and page of a listing, defaulting to the configured page size and the first page.
+func pageOf(pageRequest models.PageRequest, defaultLimit int) (int, int) {
+	limit, page := defaultLimit, 1
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+	if pageRequest.Limit != nil {
+		limit = *pageRequest.Limit
+	}
+	if pageRequest.Page != nil {
+		page = *pageRequest.Page
+	}
+	return limit, page
 }
@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
 	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/libs/privacy"
+	"employee_exercise/src/pkg/libs/problem"
 	"employee_exercise/src/pkg/models"
 	"encoding/json"
 	"errors"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
-	"go.uber.org/ratelimit"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,17 +23,22 @@ import (
 type EmployeeManagerMock struct {
 	employeeResponse *models.EmployeeResponse
 	getError         error
-	employeeError    employee.EmployeeError
+	employeeError    error
+	timeline         *models.EmployeeTimeline
 }
 
 func (e *EmployeeManagerMock) GetEmployees(ctx context.Context, parameters map[string]string) (*models.EmployeeResponse, error) {
 	return e.employeeResponse, e.getError
 }
 
-func (e *EmployeeManagerMock) UpdateEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) employee.EmployeeError {
+func (e *EmployeeManagerMock) UpdateEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) error {
 	return e.employeeError
 }
 
+func (e *EmployeeManagerMock) GetEmployeeTimeline(ctx context.Context, employeeID int) (*models.EmployeeTimeline, error) {
+	return e.timeline, e.employeeError
+}
+
 func TestEmployeeController_AddEmployeeToDepartment(t *testing.T) {
 	type fields struct {
 		EmployeeService EmployeeManager
@@ -47,9 +56,7 @@ func TestEmployeeController_AddEmployeeToDepartment(t *testing.T) {
 		{
 			name: "update employee department succeeds",
 			fields: fields{
-				EmployeeService: &EmployeeManagerMock{
-					employeeError: employee.EmployeeError{},
-				},
+				EmployeeService: &EmployeeManagerMock{},
 			},
 			args: args{r: mockUpdateRequest(models.EmployeeDepartmentRequest{
 				EmployeeNumber: 10002,
@@ -111,14 +118,27 @@ func TestEmployeeController_AddEmployeeToDepartment(t *testing.T) {
 			expectedResponseBody: badRequestUpdatedResultInvalidToDate(),
 			expectedResponseCode: http.StatusBadRequest,
 		},
+		{
+			name: "update employee returns Bad request with every invalid field",
+			fields: fields{
+				EmployeeService: &EmployeeManagerMock{},
+			},
+			args: args{r: mockUpdateRequest(models.EmployeeDepartmentRequest{
+				Department: "d00000000000000000006",
+				FromDate:   "1996-08-03",
+			})},
+			expectedResponseBody: badRequestUpdatedResultInvalidFields(),
+			expectedResponseCode: http.StatusBadRequest,
+		},
 		{
 			name: "update employee returns Not found when the employee is not found",
 			fields: fields{
 				EmployeeService: &EmployeeManagerMock{
-					employeeError: employee.EmployeeError{
-						Error:              sql.ErrNoRows,
-						ResponseStatusCode: http.StatusNotFound,
-						ErrorMessage:       "employee not found",
+					employeeError: &employee.Error{
+						Kind:   employee.KindNotFound,
+						Code:   employee.CodeEmployeeNotFound,
+						Detail: "employee not found",
+						Err:    sql.ErrNoRows,
 					},
 				},
 			},
@@ -135,30 +155,74 @@ func TestEmployeeController_AddEmployeeToDepartment(t *testing.T) {
 			name: "update employee returns Not found when the department is not found",
 			fields: fields{
 				EmployeeService: &EmployeeManagerMock{
-					employeeError: employee.EmployeeError{
-						Error:              sql.ErrNoRows,
-						ResponseStatusCode: http.StatusNotFound,
-						ErrorMessage:       "department not found",
+					employeeError: &employee.Error{
+						Kind:   employee.KindNotFound,
+						Code:   employee.CodeDepartmentNotFound,
+						Detail: "department not found",
+						Err:    sql.ErrNoRows,
 					},
 				},
 			},
 			args: args{r: mockUpdateRequest(models.EmployeeDepartmentRequest{
 				EmployeeNumber: 10002,
-				Department:     "department",
+				Department:     "d999",
 				FromDate:       "1996-08-03",
 				ToDate:         "1996-08-04",
 			})},
 			expectedResponseBody: departmentNotFoundUpdatedResult(),
 			expectedResponseCode: http.StatusNotFound,
 		},
+		{
+			name: "update employee returns Conflict when the employee already belongs to the department",
+			fields: fields{
+				EmployeeService: &EmployeeManagerMock{
+					employeeError: &employee.Error{
+						Kind:   employee.KindConflict,
+						Code:   employee.CodeEmployeeDepartmentConflict,
+						Detail: "the employee already belongs to the department for these dates",
+						Err:    errors.New("Error 1062: Duplicate entry"),
+					},
+				},
+			},
+			args: args{r: mockUpdateRequest(models.EmployeeDepartmentRequest{
+				EmployeeNumber: 10002,
+				Department:     "d006",
+				FromDate:       "1996-08-03",
+				ToDate:         "1996-08-04",
+			})},
+			expectedResponseBody: conflictUpdatedResult(),
+			expectedResponseCode: http.StatusConflict,
+		},
+		{
+			name: "update employee returns Bad request with the invalid fields",
+			fields: fields{
+				EmployeeService: &EmployeeManagerMock{
+					employeeError: &employee.Error{
+						Kind:   employee.KindValidation,
+						Code:   problem.CodeValidationFailed,
+						Detail: "the request has invalid fields",
+						Fields: []problem.FieldError{{Field: "dept_no", Code: "required", Message: "is required"}},
+					},
+				},
+			},
+			args: args{r: mockUpdateRequest(models.EmployeeDepartmentRequest{
+				EmployeeNumber: 10002,
+				Department:     "d006",
+				FromDate:       "1996-08-03",
+				ToDate:         "1996-08-04",
+			})},
+			expectedResponseBody: validationFailedUpdatedResult(),
+			expectedResponseCode: http.StatusBadRequest,
+		},
 		{
 			name: "update employee returns internal server error when database queries fail",
 			fields: fields{
 				EmployeeService: &EmployeeManagerMock{
-					employeeError: employee.EmployeeError{
-						Error:              sql.ErrNoRows,
-						ResponseStatusCode: http.StatusInternalServerError,
-						ErrorMessage:       "error in database",
+					employeeError: &employee.Error{
+						Kind:   employee.KindInternal,
+						Code:   problem.CodeInternal,
+						Detail: "error in database",
+						Err:    sql.ErrNoRows,
 					},
 				},
 			},
@@ -176,7 +240,6 @@ func TestEmployeeController_AddEmployeeToDepartment(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			employeeController := &EmployeeController{
 				EmployeeService: tt.fields.EmployeeService,
-				RateLimiter: ratelimit.New(100),
 			}
 			rr := httptest.NewRecorder()
 			employeeController.AddEmployeeToDepartment(rr, tt.args.r)
@@ -243,6 +306,17 @@ func TestEmployeeController_GetEmployees(t *testing.T) {
 			expectedResponseCode: http.StatusBadRequest,
 			expectedResponseBody: badRequestWrongLimitParameterExpectedBody(),
 		},
+		{
+			name: "get employees with an unknown order column returns bad request",
+			fields: fields{
+				EmployeeService: &EmployeeManagerMock{},
+			},
+			args: args{
+				request: mockRequestWithWrongOrderByParameter(),
+			},
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: badRequestWrongOrderByParameterExpectedBody(),
+		},
 		{
 			name: "get employees with wrong page parameter returns bad request",
 			fields: fields{
@@ -269,14 +343,31 @@ func TestEmployeeController_GetEmployees(t *testing.T) {
 				request: mockRequest(),
 			},
 			expectedResponseCode: http.StatusInternalServerError,
-			expectedResponseBody: internalServerErrorExpectedBody(),
+			expectedResponseBody: internalServerErrorExpectedBody("/employees"),
+		},
+		{
+			name: "get employees returns the problem of a service error",
+			fields: fields{
+				EmployeeService: &EmployeeManagerMock{
+					getError: &employee.Error{
+						Kind:   employee.KindForbidden,
+						Code:   problem.CodeForbidden,
+						Detail: "forbidden",
+						Err:    employee.ErrForbidden,
+					},
+				},
+			},
+			args: args{
+				request: mockRequest(),
+			},
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Forbidden","status":403,"detail":"forbidden","instance":"/employees","code":"forbidden"}`)),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			e := &EmployeeController{
 				EmployeeService: tt.fields.EmployeeService,
-				RateLimiter: ratelimit.New(100),
 			}
 
 			rr := httptest.NewRecorder()
@@ -289,6 +380,96 @@ func TestEmployeeController_GetEmployees(t *testing.T) {
 	}
 }
 
+func TestEmployeeController_GetEmployeeTimeline(t *testing.T) {
+	tests := []struct {
+		name                 string
+		employeeService      EmployeeManager
+		masker               *privacy.Masker
+		principal            *auth.Principal
+		url                  string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name: "get employee timeline succeeds",
+			employeeService: &EmployeeManagerMock{
+				timeline: &models.EmployeeTimeline{
+					EmployeeNumber: 1,
+					FirstName:      "Lucas",
+					LastName:       "Lissandrello",
+					Events: []models.TimelineEvent{
+						{Date: time.Date(2022, 06, 20, 0, 0, 0, 0, time.UTC), Type: "hired"},
+						{Date: time.Date(2022, 06, 20, 0, 0, 0, 0, time.UTC), Type: "salary_changed", Salary: 50000},
+					},
+				},
+			},
+			url:                  "/employees/1/timeline",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"emp_no":1,"first_name":"Lucas","last_name":"Lissandrello","events":[{"date":"2022-06-20T00:00:00Z","type":"hired"},{"date":"2022-06-20T00:00:00Z","type":"salary_changed","salary":50000}]}`,
+		},
+		{
+			name: "get employee timeline masks salaries for callers without read:pii",
+			employeeService: &EmployeeManagerMock{
+				timeline: &models.EmployeeTimeline{
+					EmployeeNumber: 1,
+					FirstName:      "Lucas",
+					LastName:       "Lissandrello",
+					Events: []models.TimelineEvent{
+						{Date: time.Date(2022, 06, 20, 0, 0, 0, 0, time.UTC), Type: "salary_changed", Salary: 54321, PreviousSalary: 48000},
+					},
+				},
+			},
+			masker:               privacy.NewMasker(config.PIIConfig{Profile: "standard"}),
+			principal:            &auth.Principal{Roles: []string{auth.RoleHR}},
+			url:                  "/employees/1/timeline",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"emp_no":1,"first_name":"Lucas","last_name":"Lissandrello","events":[{"date":"2022-06-20T00:00:00Z","type":"salary_changed","salary":50000,"previous_salary":40000}]}`,
+		},
+		{
+			name:                 "get employee timeline with wrong emp_no returns bad request",
+			employeeService:      &EmployeeManagerMock{},
+			url:                  "/employees/abc/timeline",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong emp_no parameter","instance":"/employees/abc/timeline","code":"invalid_parameter","errors":[{"field":"emp_no","code":"invalid","message":"wrong emp_no parameter"}]}`,
+		},
+		{
+			name: "get employee timeline returns not found when the employee is not found",
+			employeeService: &EmployeeManagerMock{
+				employeeError: &employee.Error{
+					Kind:   employee.KindNotFound,
+					Code:   employee.CodeEmployeeNotFound,
+					Detail: "employee not found",
+					Err:    sql.ErrNoRows,
+				},
+			},
+			url:                  "/employees/2/timeline",
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"employee not found","instance":"/employees/2/timeline","code":"employee_not_found"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			employeeController := &EmployeeController{
+				EmployeeService: tt.employeeService,
+				Masker:          tt.masker,
+			}
+
+			router := mux.NewRouter()
+			router.HandleFunc("/employees/{emp_no}/timeline", employeeController.GetEmployeeTimeline)
+
+			request, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.principal != nil {
+				request = request.WithContext(auth.WithPrincipal(request.Context(), tt.principal))
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, request)
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			assert.Equal(t, tt.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
 func mockEmployeesResponse() *models.EmployeeResponse {
 	employees := []models.Employee{mockEmployee()}
 	return &models.EmployeeResponse{Employees: employees, Total: 1}
@@ -326,20 +507,29 @@ func mockRequestWithWrongPageParameter() *http.Request {
 	return request
 }
 
+func mockRequestWithWrongOrderByParameter() *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/employees?orderBy=emp_no%20DESC%2C%20(SELECT%201)&order=asc", nil)
+	return request
+}
+
 func statusOkExpectedBody() *bytes.Buffer {
 	return bytes.NewBuffer([]byte(`{"total":1,"page":1,"employees":[{"emp_no":1,"birth_date":"1994-11-08T07:30:00Z","first_name":"Lucas","last_name":"Lissandrello","gender":"M","hire_date":"2022-06-20T15:00:00Z","department":"Development"}]}`))
 }
 
 func badRequestWrongLimitParameterExpectedBody() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"bad request, wrong limit parameter"}`))
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees","code":"validation_failed","errors":[{"field":"limit","code":"invalid","message":"must be an integer"}]}`))
+}
+
+func badRequestWrongOrderByParameterExpectedBody() *bytes.Buffer {
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees","code":"validation_failed","errors":[{"field":"orderBy","code":"invalid","message":"must be one of emp_no, birth_date, first_name, last_name, gender, hire_date"}]}`))
 }
 
 func badRequestWrongPageParameterExpectedBody() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"bad request, wrong page parameter"}`))
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees","code":"validation_failed","errors":[{"field":"page","code":"invalid","message":"must be an integer"}]}`))
 }
 
-func internalServerErrorExpectedBody() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"internal server error"}`))
+func internalServerErrorExpectedBody(path string) *bytes.Buffer {
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"` + path + `","code":"internal_error"}`))
 }
 
 func mockUpdateRequest(employeeBody interface{}) *http.Request {
@@ -353,29 +543,41 @@ func statusOkUpdatedResult() *bytes.Buffer {
 }
 
 func badRequestUpdatedResultWrongBody() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"bad request, wrong request body"}`))
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong request body","instance":"/employees_department","code":"invalid_body"}`))
 }
 
 func badRequestUpdatedResultDatesRange() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"bad request, wrong dates range"}`))
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees_department","code":"validation_failed","errors":[{"field":"to_date","code":"not_before","message":"must not be before from_date"}]}`))
 }
 
 func badRequestUpdatedResultInvalidFromDate() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"bad request, wrong from_date parameter"}`))
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees_department","code":"validation_failed","errors":[{"field":"from_date","code":"date","message":"must be a date formatted as YYYY-MM-DD"}]}`))
 }
 
 func badRequestUpdatedResultInvalidToDate() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"bad request, wrong to_date parameter"}`))
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees_department","code":"validation_failed","errors":[{"field":"to_date","code":"date","message":"must be a date formatted as YYYY-MM-DD"}]}`))
 }
 
 func employeeNotFoundUpdatedResult() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"employee not found"}`))
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Not Found","status":404,"detail":"employee not found","instance":"/employees_department","code":"employee_not_found"}`))
 }
 
 func departmentNotFoundUpdatedResult() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"department not found"}`))
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Not Found","status":404,"detail":"department not found","instance":"/employees_department","code":"department_not_found"}`))
 }
 
 func internalServerErrorUpdateResult() *bytes.Buffer {
-	return bytes.NewBuffer([]byte(`{"message":"error in database"}`))
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/employees_department","code":"internal_error"}`))
+}
+
+func conflictUpdatedResult() *bytes.Buffer {
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Conflict","status":409,"detail":"the employee already belongs to the department for these dates","instance":"/employees_department","code":"employee_department_conflict"}`))
+}
+
+func validationFailedUpdatedResult() *bytes.Buffer {
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees_department","code":"validation_failed","errors":[{"field":"dept_no","code":"required","message":"is required"}]}`))
+}
+
+func badRequestUpdatedResultInvalidFields() *bytes.Buffer {
+	return bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees_department","code":"validation_failed","errors":[{"field":"emp_no","code":"required","message":"is required"},{"field":"dept_no","code":"pattern","message":"must match ^d\\d{3}$"},{"field":"to_date","code":"required","message":"is required"}]}`))
 }
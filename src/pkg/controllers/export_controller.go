@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/problem"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type ExportManager interface {
+	Export(ctx context.Context, w io.Writer) error
+}
+
+type ExportController struct {
+	ExportService ExportManager
+}
+
+// GetAnonymizedExport streams the anonymized dataset as JSON lines. It lifts the server write timeout,
+// the export takes as long as the tables take to read.
+func (e *ExportController) GetAnonymizedExport(w http.ResponseWriter, r *http.Request) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	writer := &exportWriter{ResponseWriter: w}
+	if err := e.ExportService.Export(r.Context(), writer); err != nil {
+		if writer.started {
+			slog.ErrorContext(r.Context(), "anonymized export interrupted", "error", err)
+			return
+		}
+
+		problem.Write(w, r, problem.Internal())
+	}
+}
+
+// exportWriter sends the headers with the first line, so a failure before any row still gets an error
+// response.
+type exportWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.Header().Set("Content-Type", "application/x-ndjson")
+		e.Header().Set("Content-Disposition", `attachment; filename="employees_anonymized.jsonl"`)
+		e.WriteHeader(http.StatusOK)
+	}
+	return e.ResponseWriter.Write(p)
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ExportManagerMock struct {
+	lines []string
+	err   error
+}
+
+func (e *ExportManagerMock) Export(ctx context.Context, w io.Writer) error {
+	for _, line := range e.lines {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
+		}
+	}
+	return e.err
+}
+
+func TestExportController_GetAnonymizedExport(t *testing.T) {
+	tests := []struct {
+		name                 string
+		exportService        *ExportManagerMock
+		expectedResponseCode int
+		expectedContentType  string
+		expectedResponseBody string
+	}{
+		{
+			name:                 "export succeeds",
+			exportService:        &ExportManagerMock{lines: []string{`{"table":"departments","dept_no":"d005","dept_name":"Development"}`}},
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "application/x-ndjson",
+			expectedResponseBody: "{\"table\":\"departments\",\"dept_no\":\"d005\",\"dept_name\":\"Development\"}\n",
+		},
+		{
+			name:                 "export fails before the first row",
+			exportService:        &ExportManagerMock{err: errors.New("connection reset")},
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedContentType:  "application/problem+json",
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/exports/anonymized","code":"internal_error"}`,
+		},
+		{
+			name: "export fails after the first row",
+			exportService: &ExportManagerMock{
+				lines: []string{`{"table":"departments","dept_no":"d005","dept_name":"Development"}`},
+				err:   errors.New("connection reset"),
+			},
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "application/x-ndjson",
+			expectedResponseBody: "{\"table\":\"departments\",\"dept_no\":\"d005\",\"dept_name\":\"Development\"}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := ExportController{ExportService: tt.exportService}
+			recorder := httptest.NewRecorder()
+
+			controller.GetAnonymizedExport(recorder, httptest.NewRequest(http.MethodGet, "/exports/anonymized", nil))
+
+			assert.Equal(t, tt.expectedResponseCode, recorder.Code)
+			assert.Equal(t, tt.expectedContentType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedResponseBody, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/graph"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/validation"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type GraphQLExecutor interface {
+	Execute(ctx context.Context, request models.GraphQLRequest) *graph.Response
+}
+
+type GraphQLController struct {
+	GraphQLService GraphQLExecutor
+}
+
+// Query answers a GraphQL query. Errors of the query itself are part of the response, as GraphQL clients
+// expect, so only a body that is not a query is answered with a problem.
+func (g *GraphQLController) Query(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var graphQLRequest models.GraphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&graphQLRequest); err != nil {
+		slog.ErrorContext(r.Context(), "error unmarshalling request body", "error", err)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "bad request, wrong request body"))
+		return
+	}
+
+	if violations := validation.Struct(graphQLRequest); len(violations) != 0 {
+		problem.Write(w, r, problem.Validation(violations))
+		return
+	}
+
+	writeResponse(w, http.StatusOK, g.GraphQLService.Execute(r.Context(), graphQLRequest))
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/graph"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type GraphQLExecutorMock struct {
+	response *graph.Response
+	request  models.GraphQLRequest
+}
+
+func (g *GraphQLExecutorMock) Execute(ctx context.Context, request models.GraphQLRequest) *graph.Response {
+	g.request = request
+	return g.response
+}
+
+func TestGraphQLController_Query(t *testing.T) {
+	tests := []struct {
+		name                 string
+		graphQLService       *GraphQLExecutorMock
+		body                 string
+		expectedResponseCode int
+		expectedContentType  string
+		expectedResponseBody string
+		expectedRequest      models.GraphQLRequest
+	}{
+		{
+			name:                 "query succeeds",
+			graphQLService:       &GraphQLExecutorMock{response: &graph.Response{Data: map[string]interface{}{"employee": map[string]interface{}{"first_name": "Lucas"}}}},
+			body:                 `{"query":"query One($emp_no: Int!) { employee(emp_no: $emp_no) { first_name } }","operationName":"One","variables":{"emp_no":1}}`,
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "application/json",
+			expectedResponseBody: `{"data":{"employee":{"first_name":"Lucas"}}}`,
+			expectedRequest:      models.GraphQLRequest{Query: "query One($emp_no: Int!) { employee(emp_no: $emp_no) { first_name } }", OperationName: "One", Variables: map[string]interface{}{"emp_no": float64(1)}},
+		},
+		{
+			name: "query with errors is answered with ok",
+			graphQLService: &GraphQLExecutorMock{response: &graph.Response{Errors: []graph.Error{{
+				Message: "the query is 9 fields deep, more than the limit of 8", Extensions: graph.ErrorExtensions{Code: graph.CodeQueryTooDeep},
+			}}}},
+			body:                 `{"query":"{ employees { manager { manager { manager { manager { manager { manager { manager { emp_no } } } } } } } } }"}`,
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "application/json",
+			expectedResponseBody: `{"errors":[{"message":"the query is 9 fields deep, more than the limit of 8","extensions":{"code":"query_too_deep"}}]}`,
+			expectedRequest:      models.GraphQLRequest{Query: "{ employees { manager { manager { manager { manager { manager { manager { manager { emp_no } } } } } } } } }"},
+		},
+		{
+			name:                 "query with wrong body returns bad request",
+			graphQLService:       &GraphQLExecutorMock{},
+			body:                 `"wrong body"`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong request body","instance":"/graphql","code":"invalid_body"}`,
+		},
+		{
+			name:                 "query without query returns bad request",
+			graphQLService:       &GraphQLExecutorMock{},
+			body:                 `{"variables":{}}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/graphql","code":"validation_failed","errors":[{"field":"query","code":"required","message":"is required"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graphQLController := &GraphQLController{
+				GraphQLService: tt.graphQLService,
+			}
+
+			request, _ := http.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(graphQLController.Query).ServeHTTP(rr, request)
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedResponseBody, rr.Body.String())
+			assert.Equal(t, tt.expectedRequest, tt.graphQLService.request)
+		})
+	}
+}
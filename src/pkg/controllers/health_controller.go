@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/health"
+	"employee_exercise/src/pkg/models"
+	"net/http"
+)
+
+type HealthManager interface {
+	Liveness(ctx context.Context) models.HealthReport
+	Readiness(ctx context.Context) models.HealthReport
+}
+
+// HealthController serves the probe endpoints. They are not rate limited so probes keep working under load.
+type HealthController struct {
+	HealthService HealthManager
+}
+
+func (h *HealthController) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	writeResponse(w, http.StatusOK, h.HealthService.Liveness(r.Context()))
+}
+
+func (h *HealthController) Readiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	report := h.HealthService.Readiness(r.Context())
+	if report.Status != health.StatusOK {
+		writeResponse(w, http.StatusServiceUnavailable, report)
+		return
+	}
+
+	writeResponse(w, http.StatusOK, report)
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type HealthManagerMock struct {
+	readiness models.HealthReport
+}
+
+func (h *HealthManagerMock) Liveness(ctx context.Context) models.HealthReport {
+	return models.HealthReport{Status: "ok"}
+}
+
+func (h *HealthManagerMock) Readiness(ctx context.Context) models.HealthReport {
+	return h.readiness
+}
+
+func TestHealthController_Liveness(t *testing.T) {
+	controller := HealthController{HealthService: &HealthManagerMock{}}
+	recorder := httptest.NewRecorder()
+
+	controller.Liveness(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, `{"status":"ok"}`, recorder.Body.String())
+}
+
+func TestHealthController_Readiness(t *testing.T) {
+	tests := []struct {
+		name                 string
+		readiness            models.HealthReport
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name: "ready",
+			readiness: models.HealthReport{Status: "ok", Checks: map[string]models.HealthCheck{
+				"database": {Status: "ok"},
+				"pool":     {Status: "ok", Details: map[string]interface{}{"in_use": 1}},
+			}},
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"status":"ok","checks":{"database":{"status":"ok"},"pool":{"status":"ok","details":{"in_use":1}}}}`,
+		},
+		{
+			name: "not ready",
+			readiness: models.HealthReport{Status: "fail", Checks: map[string]models.HealthCheck{
+				"database": {Status: "fail", Message: "connection refused"},
+			}},
+			expectedResponseCode: http.StatusServiceUnavailable,
+			expectedResponseBody: `{"status":"fail","checks":{"database":{"status":"fail","message":"connection refused"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := HealthController{HealthService: &HealthManagerMock{readiness: tt.readiness}}
+			recorder := httptest.NewRecorder()
+
+			controller.Readiness(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.expectedResponseCode, recorder.Code)
+			assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedResponseBody, recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"bytes"
+	"employee_exercise/src/pkg/libs/apikey"
+	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/libs/graph"
+	"employee_exercise/src/pkg/libs/openapi"
+	"employee_exercise/src/pkg/libs/organization"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestControllers_Match_openapi_document runs every handler on requests that reach each documented outcome and
+// fails when a status, content type or body is not what the OpenAPI document describes.
+func TestControllers_Match_openapi_document(t *testing.T) {
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+
+	date := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	employeeNumber := 10002
+	notFound := &employee.Error{Kind: employee.KindNotFound, Code: employee.CodeEmployeeNotFound, Detail: "employee not found"}
+	conflict := &employee.Error{Kind: employee.KindConflict, Code: employee.CodeEmployeeDepartmentConflict, Detail: "conflict"}
+	validDepartmentRequest := `{"emp_no":10002,"dept_no":"d006","from_date":"1996-08-04","to_date":"1996-08-09"}`
+
+	employees := func(manager *EmployeeManagerMock) *EmployeeController {
+		return &EmployeeController{EmployeeService: manager}
+	}
+	reports := func(manager *ReportManagerMock) *ReportController {
+		return &ReportController{ReportService: manager}
+	}
+	chart := &OrganizationController{OrganizationService: &OrganizationManagerMock{orgChart: mockOrgChart()}}
+	apiKeys := func(manager *APIKeyManagerMock) *APIKeyController {
+		return &APIKeyController{APIKeyService: manager}
+	}
+	audit := func(manager *AuditManagerMock) *AuditController {
+		return &AuditController{AuditService: manager}
+	}
+	health := func(report models.HealthReport) *HealthController {
+		return &HealthController{HealthService: &HealthManagerMock{readiness: report}}
+	}
+	export := func(manager *ExportManagerMock) *ExportController {
+		return &ExportController{ExportService: manager}
+	}
+	graphQL := func(response *graph.Response) *GraphQLController {
+		return &GraphQLController{GraphQLService: &GraphQLExecutorMock{response: response}}
+	}
+
+	tests := []struct {
+		name                 string
+		method               string
+		route                string
+		url                  string
+		body                 string
+		handler              http.HandlerFunc
+		expectedResponseCode int
+	}{
+		{"liveness", "GET", "/healthz", "/healthz", "", health(models.HealthReport{}).Liveness, http.StatusOK},
+		{"ready", "GET", "/readyz", "/readyz", "", health(models.HealthReport{Status: "ok", Checks: map[string]models.HealthCheck{"pool": {Status: "ok", Details: map[string]interface{}{"in_use": 1}}}}).Readiness, http.StatusOK},
+		{"not ready", "GET", "/readyz", "/readyz", "", health(models.HealthReport{Status: "fail", Checks: map[string]models.HealthCheck{"database": {Status: "fail", Message: "connection refused"}}}).Readiness, http.StatusServiceUnavailable},
+
+		{"employees", "GET", "/employees", "/employees?limit=5&page=1", "", employees(&EmployeeManagerMock{employeeResponse: mockEmployeesResponse()}).GetEmployees, http.StatusOK},
+		{"employees with a wrong limit", "GET", "/employees", "/employees?limit=abc", "", employees(&EmployeeManagerMock{}).GetEmployees, http.StatusBadRequest},
+		{"employees failing", "GET", "/employees", "/employees", "", employees(&EmployeeManagerMock{getError: errors.New("connection reset")}).GetEmployees, http.StatusInternalServerError},
+		{"timeline", "GET", "/employees/{emp_no}/timeline", "/employees/1/timeline", "", employees(&EmployeeManagerMock{timeline: &models.EmployeeTimeline{
+			EmployeeNumber: 1, FirstName: "Lucas", LastName: "Lissandrello",
+			Events: []models.TimelineEvent{{Date: date, Type: "hired", Department: "Development", Title: "Engineer"}, {Date: date, Type: "salary_changed", Salary: 50000, PreviousSalary: 48000}},
+		}}).GetEmployeeTimeline, http.StatusOK},
+		{"timeline with a wrong emp_no", "GET", "/employees/{emp_no}/timeline", "/employees/abc/timeline", "", employees(&EmployeeManagerMock{}).GetEmployeeTimeline, http.StatusBadRequest},
+		{"timeline of an unknown employee", "GET", "/employees/{emp_no}/timeline", "/employees/1/timeline", "", employees(&EmployeeManagerMock{employeeError: notFound}).GetEmployeeTimeline, http.StatusNotFound},
+		{"department change", "POST", "/employees_department", "/employees_department", validDepartmentRequest, employees(&EmployeeManagerMock{}).AddEmployeeToDepartment, http.StatusOK},
+		{"department change with a wrong body", "POST", "/employees_department", "/employees_department", `"wrong body"`, employees(&EmployeeManagerMock{}).AddEmployeeToDepartment, http.StatusBadRequest},
+		{"department change with invalid fields", "POST", "/employees_department", "/employees_department", `{"dept_no":"d0006"}`, employees(&EmployeeManagerMock{}).AddEmployeeToDepartment, http.StatusBadRequest},
+		{"department change of an unknown employee", "POST", "/employees_department", "/employees_department", validDepartmentRequest, employees(&EmployeeManagerMock{employeeError: notFound}).AddEmployeeToDepartment, http.StatusNotFound},
+		{"conflicting department change", "POST", "/employees_department", "/employees_department", validDepartmentRequest, employees(&EmployeeManagerMock{employeeError: conflict}).AddEmployeeToDepartment, http.StatusConflict},
+
+		{"graphql query", "POST", "/graphql", "/graphql", `{"query":"{ employee(emp_no: 1) { emp_no titles { title } } }","variables":null}`, graphQL(&graph.Response{
+			Data:   map[string]interface{}{"employee": map[string]interface{}{"emp_no": 1, "titles": nil}},
+			Errors: []graph.Error{{Message: "internal server error", Locations: []graph.Location{{Line: 1, Column: 33}}, Path: []interface{}{"employee", "titles"}, Extensions: graph.ErrorExtensions{Code: problem.CodeInternal}}},
+		}).Query, http.StatusOK},
+		{"rejected graphql query", "POST", "/graphql", "/graphql", `{"query":"{ employees { emp_no } }"}`, graphQL(&graph.Response{Errors: []graph.Error{{
+			Message: "the query may resolve 1001 fields, more than the limit of 1000", Extensions: graph.ErrorExtensions{Code: graph.CodeQueryTooComplex},
+		}}}).Query, http.StatusOK},
+		{"graphql query with a wrong body", "POST", "/graphql", "/graphql", `{"query":""}`, graphQL(nil).Query, http.StatusBadRequest},
+
+		{"headcount", "GET", "/reports/headcount", "/reports/headcount?from=1990-01-01&to=1990-01-01&interval=year&groupBy=department", "", reports(&ReportManagerMock{headcountReport: mockHeadcountReport()}).GetHeadcount, http.StatusOK},
+		{"headcount with wrong dates", "GET", "/reports/headcount", "/reports/headcount?from=asdf&to=1990-01-01", "", reports(&ReportManagerMock{}).GetHeadcount, http.StatusBadRequest},
+		{"salaries", "GET", "/reports/salaries", "/reports/salaries?asOf=2000-01-01&groupBy=title", "", reports(&ReportManagerMock{salaryReport: &models.SalaryReport{
+			AsOf: date, GroupBy: "title", Groups: []models.SalaryStatistics{{Group: "Engineer", SampleSize: 2, Min: 40000, Max: 60000, Average: 50000, Median: 50000, P10: 42000, P25: 45000, P75: 55000, P90: 58000}},
+		}}).GetSalaryStatistics, http.StatusOK},
+		{"salary gender gap", "GET", "/reports/salaries/gender-gap", "/reports/salaries/gender-gap?asOf=2000-01-01", "", reports(&ReportManagerMock{salaryGenderGapReport: &models.SalaryGenderGapReport{
+			AsOf: date, GroupBy: "department", Groups: []models.SalaryGenderGap{{Group: "Development", SampleSize: 2, Male: models.GenderSalary{SampleSize: 1, Average: 50000, Median: 50000}, Female: models.GenderSalary{SampleSize: 1, Average: 49000, Median: 49000}, AverageGap: 2, MedianGap: 2}},
+		}}).GetSalaryGenderGap, http.StatusOK},
+		{"attrition", "GET", "/reports/attrition", "/reports/attrition?from=1990-01-01&to=1991-01-01", "", reports(&ReportManagerMock{attritionReport: &models.AttritionReport{
+			From: date, To: date, Interval: "year",
+			Periods: []models.AttritionPeriod{{Start: date, End: date, Total: models.DepartmentAttrition{Department: "total", Headcount: 10, Departures: 1, AttritionRate: 10}, Departments: []models.DepartmentAttrition{{Department: "Development", Headcount: 10, Departures: 1, AttritionRate: 10}}}},
+		}}).GetAttrition, http.StatusOK},
+		{"tenure", "GET", "/reports/tenure", "/reports/tenure?asOf=2000-01-01", "", reports(&ReportManagerMock{tenureReport: &models.TenureReport{
+			AsOf:        date,
+			Total:       models.DepartmentTenure{Department: "total", Employees: 1, AverageYears: 3, MedianYears: 3, Distribution: []models.TenureBucket{{Label: "2-5", Employees: 1}}},
+			Departments: []models.DepartmentTenure{{Department: "Development", Employees: 1, AverageYears: 3, MedianYears: 3, Distribution: []models.TenureBucket{{Label: "2-5", Employees: 1}}}},
+		}}).GetTenure, http.StatusOK},
+
+		{"org chart", "GET", "/org-chart", "/org-chart?asOf=2000-01-01", "", chart.GetOrgChart, http.StatusOK},
+		{"department org chart", "GET", "/org-chart/{dept_no}", "/org-chart/d001", "", chart.GetOrgChart, http.StatusOK},
+		{"unknown department org chart", "GET", "/org-chart/{dept_no}", "/org-chart/d001", "", (&OrganizationController{OrganizationService: &OrganizationManagerMock{getError: organization.ErrDepartmentNotFound}}).GetOrgChart, http.StatusNotFound},
+
+		{"issue api key", "POST", "/api-keys", "/api-keys", `{"owner":"payroll","roles":["hr","analyst"]}`, apiKeys(&APIKeyManagerMock{issued: &models.IssuedAPIKey{APIKey: models.APIKey{ID: 3, Owner: "payroll", Prefix: "emp_abcdefgh", Roles: []string{"hr", "analyst"}, CreatedAt: date}, Key: "emp_abcdefgh_secret"}}).IssueAPIKey, http.StatusCreated},
+		{"issue api key without owner", "POST", "/api-keys", "/api-keys", `{}`, apiKeys(&APIKeyManagerMock{}).IssueAPIKey, http.StatusBadRequest},
+		{"list api keys", "GET", "/api-keys", "/api-keys", "", apiKeys(&APIKeyManagerMock{keys: []models.APIKey{{ID: 1, Owner: "ops", Prefix: "emp_12345678", Admin: true, Roles: []string{}, CreatedAt: date, LastUsedAt: &date}}}).ListAPIKeys, http.StatusOK},
+		{"revoke api key", "DELETE", "/api-keys/{id}", "/api-keys/1", "", apiKeys(&APIKeyManagerMock{}).RevokeAPIKey, http.StatusOK},
+		{"revoke unknown api key", "DELETE", "/api-keys/{id}", "/api-keys/1", "", apiKeys(&APIKeyManagerMock{err: apikey.ErrKeyNotFound}).RevokeAPIKey, http.StatusNotFound},
+
+		{"audit log", "GET", "/audit", "/audit?emp_no=10002", "", audit(&AuditManagerMock{entries: []models.AuditEntry{{
+			ID: 2, CreatedAt: date, Actor: "jwt:alice", RequestID: "abc", Action: "employee_department.update",
+			EmployeeNumber: &employeeNumber, Before: json.RawMessage(`{"dept_no":"d006"}`), After: json.RawMessage(`{"dept_no":"d005"}`),
+		}}}).GetAuditLog, http.StatusOK},
+		{"audit log with a wrong page", "GET", "/audit", "/audit?page=0", "", audit(&AuditManagerMock{}).GetAuditLog, http.StatusBadRequest},
+
+		{"anonymized export", "GET", "/exports/anonymized", "/exports/anonymized", "", export(&ExportManagerMock{lines: []string{`{"table":"departments","dept_no":"d005","dept_name":"Development"}`}}).GetAnonymizedExport, http.StatusOK},
+		{"failing anonymized export", "GET", "/exports/anonymized", "/exports/anonymized", "", export(&ExportManagerMock{err: errors.New("connection reset")}).GetAnonymizedExport, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation, ok := spec.Operation(tt.method, tt.route)
+			require.True(t, ok, "%s %s is not documented", tt.method, tt.route)
+
+			router := mux.NewRouter()
+			router.HandleFunc(tt.route, tt.handler).Methods(tt.method)
+			request := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, request)
+
+			require.Equal(t, tt.expectedResponseCode, rr.Code, rr.Body.String())
+			response, ok := spec.Response(operation, rr.Code)
+			require.True(t, ok, "status %d is not documented", rr.Code)
+
+			contentType, _, err := mime.ParseMediaType(rr.Header().Get("Content-Type"))
+			require.NoError(t, err)
+			media, ok := response.Content[contentType]
+			require.True(t, ok, "content type %s is not documented", contentType)
+
+			var body interface{} = rr.Body.String()
+			if contentType == "application/json" || contentType == problem.ContentType {
+				require.NoError(t, json.NewDecoder(bytes.NewReader(rr.Body.Bytes())).Decode(&body))
+			}
+			assert.Empty(t, spec.Validate(media.Schema, body))
+		})
+	}
+}
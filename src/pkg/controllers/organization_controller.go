@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/libs/organization"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/models"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type OrganizationManager interface {
+	GetOrgChart(ctx context.Context, asOf time.Time, departmentID string) (*models.OrgChart, error)
+}
+
+type OrganizationController struct {
+	OrganizationService OrganizationManager
+}
+
+func (o *OrganizationController) GetOrgChart(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	asOf, invalid := parseDateParameter(r, "asOf")
+	if invalid != nil {
+		problem.Write(w, r, invalid)
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "json"
+	}
+
+	if format != "json" && format != "dot" {
+		problem.Write(w, r, problem.InvalidParameter("format"))
+		return
+	}
+
+	chart, err := o.OrganizationService.GetOrgChart(r.Context(), *asOf, mux.Vars(r)["dept_no"])
+	if err != nil {
+		if err == organization.ErrDepartmentNotFound {
+			problem.Write(w, r, problem.New(http.StatusNotFound, employee.CodeDepartmentNotFound, "department not found"))
+			return
+		}
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	if format == "dot" {
+		var buffer bytes.Buffer
+		if dotError := organization.WriteDOT(&buffer, chart); dotError != nil {
+			slog.ErrorContext(r.Context(), "error writing org chart dot", "error", dotError)
+			problem.Write(w, r, problem.Internal())
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.WriteHeader(http.StatusOK)
+		w.Write(buffer.Bytes())
+		return
+	}
+
+	writeResponse(w, http.StatusOK, chart)
+}
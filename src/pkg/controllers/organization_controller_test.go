@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/organization"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type OrganizationManagerMock struct {
+	orgChart     *models.OrgChart
+	getError     error
+	asOf         time.Time
+	departmentID string
+}
+
+func (o *OrganizationManagerMock) GetOrgChart(ctx context.Context, asOf time.Time, departmentID string) (*models.OrgChart, error) {
+	o.asOf = asOf
+	o.departmentID = departmentID
+	return o.orgChart, o.getError
+}
+
+func TestOrganizationController_GetOrgChart(t *testing.T) {
+	tests := []struct {
+		name                 string
+		organizationService  *OrganizationManagerMock
+		url                  string
+		expectedResponseCode int
+		expectedContentType  string
+		expectedResponseBody string
+		expectedDepartmentID string
+	}{
+		{
+			name:                 "get org chart succeeds",
+			organizationService:  &OrganizationManagerMock{orgChart: mockOrgChart()},
+			url:                  "/org-chart?asOf=2000-01-01",
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "application/json",
+			expectedResponseBody: `{"as_of":"2000-01-01T00:00:00Z","departments":[{"dept_no":"d001","dept_name":"Marketing","manager":{"emp_no":110022,"first_name":"Margareta","last_name":"Markovitch","title":"Manager"},"members":[{"emp_no":10017,"first_name":"Cristinel","last_name":"Bouloucos","title":"Senior Staff"}]}]}`,
+		},
+		{
+			name:                 "get org chart for a department as dot succeeds",
+			organizationService:  &OrganizationManagerMock{orgChart: mockOrgChart()},
+			url:                  "/org-chart/d001?asOf=2000-01-01&format=dot",
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "text/vnd.graphviz",
+			expectedResponseBody: "digraph org_chart {\n\trankdir=TB;\n\tnode [shape=box];\n\torganization [label=\"Organization\\nas of 2000-01-01\"];\n\t\"d001\" [label=\"d001\\nMarketing\", shape=folder];\n\torganization -> \"d001\";\n\t\"e110022\" [label=\"Margareta Markovitch\\n#110022\\nManager\", style=bold];\n\t\"d001\" -> \"e110022\";\n\t\"e10017\" [label=\"Cristinel Bouloucos\\n#10017\\nSenior Staff\"];\n\t\"e110022\" -> \"e10017\";\n}\n",
+			expectedDepartmentID: "d001",
+		},
+		{
+			name:                 "get org chart with wrong format parameter returns bad request",
+			organizationService:  &OrganizationManagerMock{},
+			url:                  "/org-chart?format=svg",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong format parameter","instance":"/org-chart","code":"invalid_parameter","errors":[{"field":"format","code":"invalid","message":"wrong format parameter"}]}`,
+		},
+		{
+			name:                 "get org chart with wrong asOf parameter returns bad request",
+			organizationService:  &OrganizationManagerMock{},
+			url:                  "/org-chart?asOf=yesterday",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong asOf parameter","instance":"/org-chart","code":"invalid_parameter","errors":[{"field":"asOf","code":"invalid","message":"wrong asOf parameter"}]}`,
+		},
+		{
+			name:                 "get org chart for an unknown department returns not found",
+			organizationService:  &OrganizationManagerMock{getError: organization.ErrDepartmentNotFound},
+			url:                  "/org-chart/d999?asOf=2000-01-01",
+			expectedResponseCode: http.StatusNotFound,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"department not found","instance":"/org-chart/d999","code":"department_not_found"}`,
+			expectedDepartmentID: "d999",
+		},
+		{
+			name:                 "get org chart fails getting data from database, returns internal server error",
+			organizationService:  &OrganizationManagerMock{getError: errors.New("error getting data from database")},
+			url:                  "/org-chart?asOf=2000-01-01",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/org-chart","code":"internal_error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			organizationController := &OrganizationController{
+				OrganizationService: tt.organizationService,
+			}
+
+			router := mux.NewRouter()
+			router.HandleFunc("/org-chart", organizationController.GetOrgChart)
+			router.HandleFunc("/org-chart/{dept_no}", organizationController.GetOrgChart)
+
+			request, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, request)
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedResponseBody, rr.Body.String())
+			assert.Equal(t, tt.expectedDepartmentID, tt.organizationService.departmentID)
+		})
+	}
+}
+
+func mockOrgChart() *models.OrgChart {
+	return &models.OrgChart{
+		AsOf: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		Departments: []models.OrgChartDepartment{{
+			DepartmentNumber: "d001",
+			DepartmentName:   "Marketing",
+			Manager:          &models.OrgChartEmployee{EmployeeNumber: 110022, FirstName: "Margareta", LastName: "Markovitch", Title: "Manager"},
+			Members:          []models.OrgChartEmployee{{EmployeeNumber: 10017, FirstName: "Cristinel", LastName: "Bouloucos", Title: "Senior Staff"}},
+		}},
+	}
+}
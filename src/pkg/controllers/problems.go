@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/validation"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+const codeAPIKeyNotFound = "api_key_not_found"
+
+var employeeErrorStatus = map[employee.ErrorKind]int{
+	employee.KindNotFound:   http.StatusNotFound,
+	employee.KindConflict:   http.StatusConflict,
+	employee.KindValidation: http.StatusBadRequest,
+	employee.KindForbidden:  http.StatusForbidden,
+}
+
+// writeError maps a service error to its problem. Anything that is not a known client error is logged
+// and answered with a bare internal error, so causes never reach the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var employeeError *employee.Error
+	if errors.As(err, &employeeError) {
+		if status, ok := employeeErrorStatus[employeeError.Kind]; ok {
+			response := problem.New(status, employeeError.Code, employeeError.Detail)
+			response.Errors = employeeError.Fields
+			problem.Write(w, r, response)
+			return
+		}
+	}
+
+	slog.ErrorContext(r.Context(), "request failed", "error", err)
+	problem.Write(w, r, problem.Internal())
+}
+
+// datesRangeProblem is the problem of a to date before its from date.
+func datesRangeProblem(fromName, toName string) *problem.Problem {
+	response := problem.New(http.StatusBadRequest, problem.CodeInvalidParameter, "bad request, wrong dates range")
+	response.Errors = []problem.FieldError{{Field: toName, Code: validation.CodeNotBefore, Message: "must not be before " + fromName}}
+	return response
+}
@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/reports"
+	"employee_exercise/src/pkg/models"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ReportManager interface {
+	GetHeadcount(ctx context.Context, parameters models.HeadcountParameters) (*models.HeadcountReport, error)
+	GetSalaryStatistics(ctx context.Context, parameters models.SalaryParameters) (*models.SalaryReport, error)
+	GetSalaryGenderGap(ctx context.Context, parameters models.SalaryParameters) (*models.SalaryGenderGapReport, error)
+	GetAttrition(ctx context.Context, parameters models.AttritionParameters) (*models.AttritionReport, error)
+	GetTenure(ctx context.Context, asOf time.Time) (*models.TenureReport, error)
+}
+
+type ReportController struct {
+	ReportService ReportManager
+	CacheMaxAge   time.Duration
+}
+
+func (rc *ReportController) GetHeadcount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, to, invalid := validateNamedDates("from", r.URL.Query().Get("from"), "to", r.URL.Query().Get("to"))
+	if invalid != nil {
+		problem.Write(w, r, invalid)
+		return
+	}
+
+	interval, intervalOk := intervalParameter(r)
+	if !intervalOk {
+		problem.Write(w, r, problem.InvalidParameter("interval"))
+		return
+	}
+
+	groupBy := strings.ToLower(r.URL.Query().Get("groupBy"))
+	if groupBy == "" {
+		groupBy = reports.GroupByDepartment
+	}
+
+	if groupBy != reports.GroupByDepartment && groupBy != reports.GroupByGender && groupBy != reports.GroupByTitle {
+		problem.Write(w, r, problem.InvalidParameter("groupBy"))
+		return
+	}
+
+	if _, periodsError := reports.PeriodDates(*from, *to, interval); periodsError != nil {
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("bad request, %v", periodsError)))
+		return
+	}
+
+	format, formatOk := responseFormat(r)
+	if !formatOk {
+		problem.Write(w, r, problem.InvalidParameter("format"))
+		return
+	}
+
+	report, err := rc.ReportService.GetHeadcount(r.Context(), models.HeadcountParameters{
+		From:     *from,
+		To:       *to,
+		Interval: interval,
+		GroupBy:  groupBy,
+	})
+	if err != nil {
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	rc.setCacheHeaders(w)
+
+	if format == "csv" {
+		var buffer bytes.Buffer
+		if csvError := reports.WriteHeadcountCSV(&buffer, report); csvError != nil {
+			slog.ErrorContext(r.Context(), "error writing headcount csv", "error", csvError)
+			problem.Write(w, r, problem.Internal())
+			return
+		}
+
+		writeCSV(w, "headcount.csv", buffer.Bytes())
+		return
+	}
+
+	writeResponse(w, http.StatusOK, report)
+}
+
+func (rc *ReportController) GetSalaryStatistics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parameters, invalid := salaryParameters(r)
+	if invalid != nil {
+		problem.Write(w, r, invalid)
+		return
+	}
+
+	report, err := rc.ReportService.GetSalaryStatistics(r.Context(), *parameters)
+	if err != nil {
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	rc.setCacheHeaders(w)
+	writeResponse(w, http.StatusOK, report)
+}
+
+func (rc *ReportController) GetSalaryGenderGap(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parameters, invalid := salaryParameters(r)
+	if invalid != nil {
+		problem.Write(w, r, invalid)
+		return
+	}
+
+	report, err := rc.ReportService.GetSalaryGenderGap(r.Context(), *parameters)
+	if err != nil {
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	rc.setCacheHeaders(w)
+	writeResponse(w, http.StatusOK, report)
+}
+
+func (rc *ReportController) GetAttrition(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	from, to, invalid := validateNamedDates("from", r.URL.Query().Get("from"), "to", r.URL.Query().Get("to"))
+	if invalid != nil {
+		problem.Write(w, r, invalid)
+		return
+	}
+
+	interval, intervalOk := intervalParameter(r)
+	if !intervalOk {
+		problem.Write(w, r, problem.InvalidParameter("interval"))
+		return
+	}
+
+	if _, periodsError := reports.PeriodRanges(*from, *to, interval); periodsError != nil {
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("bad request, %v", periodsError)))
+		return
+	}
+
+	report, err := rc.ReportService.GetAttrition(r.Context(), models.AttritionParameters{
+		From:     *from,
+		To:       *to,
+		Interval: interval,
+	})
+	if err != nil {
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	rc.setCacheHeaders(w)
+	writeResponse(w, http.StatusOK, report)
+}
+
+func (rc *ReportController) GetTenure(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	asOf, invalid := parseDateParameter(r, "asOf")
+	if invalid != nil {
+		problem.Write(w, r, invalid)
+		return
+	}
+
+	report, err := rc.ReportService.GetTenure(r.Context(), *asOf)
+	if err != nil {
+		problem.Write(w, r, problem.Internal())
+		return
+	}
+
+	rc.setCacheHeaders(w)
+	writeResponse(w, http.StatusOK, report)
+}
+
+// setCacheHeaders lets the caller, and only the caller, reuse a report: reports are behind credentials and
+// permissions, so shared caches must not keep them, and they differ by format and caller.
+func (rc *ReportController) setCacheHeaders(w http.ResponseWriter) {
+	w.Header().Set("Vary", "Accept, Authorization, X-API-Key")
+	if rc.CacheMaxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(rc.CacheMaxAge.Seconds())))
+	}
+}
+
+func intervalParameter(r *http.Request) (string, bool) {
+	interval := strings.ToLower(r.URL.Query().Get("interval"))
+	if interval == "" {
+		return reports.IntervalYear, true
+	}
+
+	return interval, interval == reports.IntervalMonth || interval == reports.IntervalQuarter || interval == reports.IntervalYear
+}
+
+func salaryParameters(r *http.Request) (*models.SalaryParameters, *problem.Problem) {
+	asOf, invalid := parseDateParameter(r, "asOf")
+	if invalid != nil {
+		return nil, invalid
+	}
+
+	groupBy := strings.ToLower(r.URL.Query().Get("groupBy"))
+	if groupBy == "" {
+		groupBy = reports.GroupByDepartment
+	}
+
+	if groupBy != reports.GroupByDepartment && groupBy != reports.GroupByTitle {
+		return nil, problem.InvalidParameter("groupBy")
+	}
+
+	return &models.SalaryParameters{AsOf: *asOf, GroupBy: groupBy}, nil
+}
+
+func parseDateParameter(r *http.Request, name string) (*time.Time, *problem.Problem) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		return &today, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, problem.InvalidParameter(name)
+	}
+
+	return &date, nil
+}
+
+func responseFormat(r *http.Request) (string, bool) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+			return "csv", true
+		}
+		return "json", true
+	}
+
+	return format, format == "json" || format == "csv"
+}
+
+func writeCSV(w http.ResponseWriter, filename string, body []byte) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
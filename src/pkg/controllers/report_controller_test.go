@@ -0,0 +1,402 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type ReportManagerMock struct {
+	headcountReport       *models.HeadcountReport
+	salaryReport          *models.SalaryReport
+	salaryGenderGapReport *models.SalaryGenderGapReport
+	attritionReport       *models.AttritionReport
+	tenureReport          *models.TenureReport
+	getError              error
+	parameters            models.HeadcountParameters
+	salaryParameters      models.SalaryParameters
+	attritionParameters   models.AttritionParameters
+}
+
+func (r *ReportManagerMock) GetHeadcount(ctx context.Context, parameters models.HeadcountParameters) (*models.HeadcountReport, error) {
+	r.parameters = parameters
+	return r.headcountReport, r.getError
+}
+
+func (r *ReportManagerMock) GetSalaryStatistics(ctx context.Context, parameters models.SalaryParameters) (*models.SalaryReport, error) {
+	r.salaryParameters = parameters
+	return r.salaryReport, r.getError
+}
+
+func (r *ReportManagerMock) GetSalaryGenderGap(ctx context.Context, parameters models.SalaryParameters) (*models.SalaryGenderGapReport, error) {
+	r.salaryParameters = parameters
+	return r.salaryGenderGapReport, r.getError
+}
+
+func (r *ReportManagerMock) GetAttrition(ctx context.Context, parameters models.AttritionParameters) (*models.AttritionReport, error) {
+	r.attritionParameters = parameters
+	return r.attritionReport, r.getError
+}
+
+func (r *ReportManagerMock) GetTenure(ctx context.Context, asOf time.Time) (*models.TenureReport, error) {
+	return r.tenureReport, r.getError
+}
+
+func TestReportController_GetHeadcount(t *testing.T) {
+	type fields struct {
+		ReportService *ReportManagerMock
+	}
+	type args struct {
+		request *http.Request
+	}
+	tests := []struct {
+		name                 string
+		fields               fields
+		args                 args
+		expectedResponseCode int
+		expectedContentType  string
+		expectedResponseBody *bytes.Buffer
+	}{
+		{
+			name: "get headcount with all url parameters succeeds",
+			fields: fields{
+				ReportService: &ReportManagerMock{headcountReport: mockHeadcountReport()},
+			},
+			args: args{
+				request: mockHeadcountRequest("from=1990-01-01&to=1990-01-01&interval=year&groupBy=department"),
+			},
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "application/json",
+			expectedResponseBody: statusOkHeadcountExpectedBody(),
+		},
+		{
+			name: "get headcount as csv succeeds",
+			fields: fields{
+				ReportService: &ReportManagerMock{headcountReport: mockHeadcountReport()},
+			},
+			args: args{
+				request: mockHeadcountRequest("from=1990-01-01&to=1990-01-01&format=csv"),
+			},
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "text/csv",
+			expectedResponseBody: bytes.NewBuffer([]byte("date,department,headcount\n1990-01-01,Development,10\n")),
+		},
+		{
+			name: "get headcount with wrong from parameter returns bad request",
+			fields: fields{
+				ReportService: &ReportManagerMock{},
+			},
+			args: args{
+				request: mockHeadcountRequest("from=asdf&to=1990-01-01"),
+			},
+			expectedResponseCode: http.StatusBadRequest,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong from parameter","instance":"/reports/headcount","code":"invalid_parameter","errors":[{"field":"from","code":"invalid","message":"wrong from parameter"}]}`)),
+		},
+		{
+			name: "get headcount with wrong interval parameter returns bad request",
+			fields: fields{
+				ReportService: &ReportManagerMock{},
+			},
+			args: args{
+				request: mockHeadcountRequest("from=1990-01-01&to=1990-01-01&interval=week"),
+			},
+			expectedResponseCode: http.StatusBadRequest,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong interval parameter","instance":"/reports/headcount","code":"invalid_parameter","errors":[{"field":"interval","code":"invalid","message":"wrong interval parameter"}]}`)),
+		},
+		{
+			name: "get headcount with wrong groupBy parameter returns bad request",
+			fields: fields{
+				ReportService: &ReportManagerMock{},
+			},
+			args: args{
+				request: mockHeadcountRequest("from=1990-01-01&to=1990-01-01&groupBy=salary"),
+			},
+			expectedResponseCode: http.StatusBadRequest,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong groupBy parameter","instance":"/reports/headcount","code":"invalid_parameter","errors":[{"field":"groupBy","code":"invalid","message":"wrong groupBy parameter"}]}`)),
+		},
+		{
+			name: "get headcount with too many periods returns bad request",
+			fields: fields{
+				ReportService: &ReportManagerMock{},
+			},
+			args: args{
+				request: mockHeadcountRequest("from=1900-01-01&to=2000-01-01&interval=month"),
+			},
+			expectedResponseCode: http.StatusBadRequest,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, too many periods, the maximum is 500","instance":"/reports/headcount","code":"invalid_parameter"}`)),
+		},
+		{
+			name: "get headcount fails getting data from database, returns internal server error",
+			fields: fields{
+				ReportService: &ReportManagerMock{getError: errors.New("error getting data from database")},
+			},
+			args: args{
+				request: mockHeadcountRequest("from=1990-01-01&to=1990-01-01"),
+			},
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedContentType:  problem.ContentType,
+			expectedResponseBody: internalServerErrorExpectedBody("/reports/headcount"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportController := &ReportController{
+				ReportService: tt.fields.ReportService,
+			}
+
+			rr := httptest.NewRecorder()
+			reportController.GetHeadcount(rr, tt.args.request)
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedResponseBody, rr.Body)
+		})
+	}
+}
+
+func TestReportController_GetHeadcount_Applies_defaults_and_cache_headers(t *testing.T) {
+	reportService := &ReportManagerMock{headcountReport: mockHeadcountReport()}
+	reportController := &ReportController{
+		ReportService: reportService,
+		CacheMaxAge:   5 * time.Minute,
+	}
+
+	rr := httptest.NewRecorder()
+	reportController.GetHeadcount(rr, mockHeadcountRequest("from=1990-01-01&to=1991-01-01"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "private, max-age=300", rr.Header().Get("Cache-Control"))
+	assert.Equal(t, "Accept, Authorization, X-API-Key", rr.Header().Get("Vary"))
+	assert.Equal(t, "year", reportService.parameters.Interval)
+	assert.Equal(t, "department", reportService.parameters.GroupBy)
+}
+
+func TestReportController_GetSalaryStatistics(t *testing.T) {
+	tests := []struct {
+		name                 string
+		reportService        *ReportManagerMock
+		query                string
+		expectedResponseCode int
+		expectedResponseBody *bytes.Buffer
+		expectedParameters   models.SalaryParameters
+	}{
+		{
+			name: "get salary statistics succeeds",
+			reportService: &ReportManagerMock{salaryReport: &models.SalaryReport{
+				AsOf:    time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+				GroupBy: "title",
+				Groups:  []models.SalaryStatistics{{Group: "Engineer", SampleSize: 1, Min: 10, Max: 10, Average: 10, Median: 10, P10: 10, P25: 10, P75: 10, P90: 10}},
+			}},
+			query:                "asOf=2000-01-01&groupBy=title",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: bytes.NewBuffer([]byte(`{"as_of":"2000-01-01T00:00:00Z","group_by":"title","groups":[{"group":"Engineer","sample_size":1,"min":10,"max":10,"average":10,"median":10,"p10":10,"p25":10,"p75":10,"p90":10}]}`)),
+			expectedParameters:   models.SalaryParameters{AsOf: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), GroupBy: "title"},
+		},
+		{
+			name:                 "get salary statistics with wrong asOf parameter returns bad request",
+			reportService:        &ReportManagerMock{},
+			query:                "asOf=asdf",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong asOf parameter","instance":"/reports/salaries","code":"invalid_parameter","errors":[{"field":"asOf","code":"invalid","message":"wrong asOf parameter"}]}`)),
+		},
+		{
+			name:                 "get salary statistics with wrong groupBy parameter returns bad request",
+			reportService:        &ReportManagerMock{},
+			query:                "asOf=2000-01-01&groupBy=gender",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: bytes.NewBuffer([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong groupBy parameter","instance":"/reports/salaries","code":"invalid_parameter","errors":[{"field":"groupBy","code":"invalid","message":"wrong groupBy parameter"}]}`)),
+		},
+		{
+			name:                 "get salary statistics fails getting data from database, returns internal server error",
+			reportService:        &ReportManagerMock{getError: errors.New("error getting data from database")},
+			query:                "asOf=2000-01-01",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: internalServerErrorExpectedBody("/reports/salaries"),
+			expectedParameters:   models.SalaryParameters{AsOf: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), GroupBy: "department"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportController := &ReportController{
+				ReportService: tt.reportService,
+			}
+
+			request, _ := http.NewRequest(http.MethodGet, "/reports/salaries?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			reportController.GetSalaryStatistics(rr, request)
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			assert.Equal(t, tt.expectedResponseBody, rr.Body)
+			assert.Equal(t, tt.expectedParameters, tt.reportService.salaryParameters)
+		})
+	}
+}
+
+func TestReportController_GetSalaryGenderGap(t *testing.T) {
+	reportService := &ReportManagerMock{salaryGenderGapReport: &models.SalaryGenderGapReport{
+		AsOf:    time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		GroupBy: "department",
+		Groups: []models.SalaryGenderGap{{
+			Group:      "Sales",
+			SampleSize: 2,
+			Male:       models.GenderSalary{SampleSize: 1, Average: 100, Median: 100},
+			Female:     models.GenderSalary{SampleSize: 1, Average: 90, Median: 90},
+			AverageGap: 10,
+			MedianGap:  10,
+		}},
+	}}
+	reportController := &ReportController{
+		ReportService: reportService,
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "/reports/salaries/gender-gap?asOf=2000-01-01", nil)
+	rr := httptest.NewRecorder()
+	reportController.GetSalaryGenderGap(rr, request)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"as_of":"2000-01-01T00:00:00Z","group_by":"department","groups":[{"group":"Sales","sample_size":2,"male":{"sample_size":1,"average":100,"median":100},"female":{"sample_size":1,"average":90,"median":90},"average_gap_percent":10,"median_gap_percent":10}]}`, rr.Body.String())
+}
+
+func TestReportController_GetAttrition(t *testing.T) {
+	tests := []struct {
+		name                 string
+		reportService        *ReportManagerMock
+		query                string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name: "get attrition succeeds",
+			reportService: &ReportManagerMock{attritionReport: &models.AttritionReport{
+				From:     time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+				Interval: "year",
+				Periods:  []models.AttritionPeriod{},
+			}},
+			query:                "from=1990-01-01&to=1990-01-01",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"from":"1990-01-01T00:00:00Z","to":"1990-01-01T00:00:00Z","interval":"year","periods":[]}`,
+		},
+		{
+			name:                 "get attrition with wrong to parameter returns bad request",
+			reportService:        &ReportManagerMock{},
+			query:                "from=1990-01-01&to=asdf",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong to parameter","instance":"/reports/attrition","code":"invalid_parameter","errors":[{"field":"to","code":"invalid","message":"wrong to parameter"}]}`,
+		},
+		{
+			name:                 "get attrition with wrong interval parameter returns bad request",
+			reportService:        &ReportManagerMock{},
+			query:                "from=1990-01-01&to=1991-01-01&interval=day",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong interval parameter","instance":"/reports/attrition","code":"invalid_parameter","errors":[{"field":"interval","code":"invalid","message":"wrong interval parameter"}]}`,
+		},
+		{
+			name:                 "get attrition fails getting data from database, returns internal server error",
+			reportService:        &ReportManagerMock{getError: errors.New("error getting data from database")},
+			query:                "from=1990-01-01&to=1991-01-01&interval=quarter",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/reports/attrition","code":"internal_error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportController := &ReportController{
+				ReportService: tt.reportService,
+			}
+
+			request, _ := http.NewRequest(http.MethodGet, "/reports/attrition?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			reportController.GetAttrition(rr, request)
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			assert.Equal(t, tt.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestReportController_GetTenure(t *testing.T) {
+	tests := []struct {
+		name                 string
+		reportService        *ReportManagerMock
+		query                string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name: "get tenure succeeds",
+			reportService: &ReportManagerMock{tenureReport: &models.TenureReport{
+				AsOf:        time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+				Total:       models.DepartmentTenure{Department: "all", Distribution: []models.TenureBucket{}},
+				Departments: []models.DepartmentTenure{},
+			}},
+			query:                "asOf=2000-01-01",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"as_of":"2000-01-01T00:00:00Z","total":{"department":"all","employees":0,"average_years":0,"median_years":0,"distribution":[]},"departments":[]}`,
+		},
+		{
+			name:                 "get tenure with wrong asOf parameter returns bad request",
+			reportService:        &ReportManagerMock{},
+			query:                "asOf=2000-13-01",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong asOf parameter","instance":"/reports/tenure","code":"invalid_parameter","errors":[{"field":"asOf","code":"invalid","message":"wrong asOf parameter"}]}`,
+		},
+		{
+			name:                 "get tenure fails getting data from database, returns internal server error",
+			reportService:        &ReportManagerMock{getError: errors.New("error getting data from database")},
+			query:                "asOf=2000-01-01",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/reports/tenure","code":"internal_error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reportController := &ReportController{
+				ReportService: tt.reportService,
+			}
+
+			request, _ := http.NewRequest(http.MethodGet, "/reports/tenure?"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			reportController.GetTenure(rr, request)
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			assert.Equal(t, tt.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
+func mockHeadcountReport() *models.HeadcountReport {
+	return &models.HeadcountReport{
+		From:     time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+		Interval: "year",
+		GroupBy:  "department",
+		Periods: []models.HeadcountPeriod{
+			{
+				Date:   time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+				Total:  10,
+				Groups: []models.HeadcountGroup{{Group: "Development", Headcount: 10}},
+			},
+		},
+	}
+}
+
+func mockHeadcountRequest(query string) *http.Request {
+	request, _ := http.NewRequest(http.MethodGet, "/reports/headcount?"+query, nil)
+	return request
+}
+
+func statusOkHeadcountExpectedBody() *bytes.Buffer {
+	return bytes.NewBuffer([]byte(`{"from":"1990-01-01T00:00:00Z","to":"1990-01-01T00:00:00Z","interval":"year","group_by":"department","periods":[{"date":"1990-01-01T00:00:00Z","total":10,"groups":[{"group":"Development","headcount":10}]}]}`))
+}
@@ -0,0 +1,242 @@
+package anonymize
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"employee_exercise/src/pkg/libs/tracing"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"time"
+)
+
+const (
+	departmentsQuery = "SELECT dept_no, dept_name FROM departments ORDER BY dept_no"
+	employeesQuery   = "SELECT emp_no, birth_date, gender, hire_date FROM employees ORDER BY SHA2(CONCAT(?, emp_no), 256)"
+	deptEmpQuery     = "SELECT emp_no, dept_no, from_date, to_date FROM dept_emp ORDER BY SHA2(CONCAT(?, emp_no), 256), from_date"
+	titlesQuery      = "SELECT emp_no, title, from_date, to_date FROM titles ORDER BY SHA2(CONCAT(?, emp_no), 256), from_date"
+	salariesQuery    = "SELECT emp_no, salary, from_date, to_date FROM salaries ORDER BY SHA2(CONCAT(?, emp_no), 256), from_date"
+
+	dateLayout = "2006-01-02"
+)
+
+type department struct {
+	Table            string `json:"table"`
+	DepartmentNumber string `json:"dept_no"`
+	DepartmentName   string `json:"dept_name"`
+}
+
+type employee struct {
+	Table          string `json:"table"`
+	EmployeeNumber int    `json:"emp_no"`
+	BirthDate      string `json:"birth_date"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	Gender         string `json:"gender"`
+	HireDate       string `json:"hire_date"`
+}
+
+type departmentEmployee struct {
+	Table            string `json:"table"`
+	EmployeeNumber   int    `json:"emp_no"`
+	DepartmentNumber string `json:"dept_no"`
+	FromDate         string `json:"from_date"`
+	ToDate           string `json:"to_date"`
+}
+
+type title struct {
+	Table          string `json:"table"`
+	EmployeeNumber int    `json:"emp_no"`
+	Title          string `json:"title"`
+	FromDate       string `json:"from_date"`
+	ToDate         string `json:"to_date"`
+}
+
+type salary struct {
+	Table          string `json:"table"`
+	EmployeeNumber int    `json:"emp_no"`
+	Salary         int    `json:"salary"`
+	FromDate       string `json:"from_date"`
+	ToDate         string `json:"to_date"`
+}
+
+type ExportService struct {
+	ExportManager *sql.DB
+	Pseudonymizer *Pseudonymizer
+}
+
+// Export writes departments, employees, dept_emp, titles and salaries as JSON lines, one object per row
+// with its table name. Rows are read in a single read-only transaction, so the tables are consistent
+// with each other.
+//
+// The export applies its own policy, the same for every caller, while scanning the rows: employee
+// numbers are replaced by pseudonyms, names by fake ones and dates are shifted, the same way in every
+// table. Departments, titles, genders, salaries and the shifted birth dates are kept as they are, so
+// aggregates match the real data up to the date shift. PII_PROFILE does not apply, its masking would
+// flatten the distributions the export is for.
+func (e *ExportService) Export(ctx context.Context, w io.Writer) error {
+	tx, err := e.ExportManager.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		slog.ErrorContext(ctx, "error starting export transaction", "error", err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	orderKey := e.Pseudonymizer.OrderKey()
+	keys := &employeeKeys{pseudonymizer: e.Pseudonymizer}
+
+	tables := []struct {
+		name  string
+		query string
+		args  []interface{}
+		scan  func(rows *sql.Rows) (interface{}, error)
+	}{
+		{"departments", departmentsQuery, nil, scanDepartment},
+		{"employees", employeesQuery, []interface{}{orderKey}, keys.scanEmployee},
+		{"dept_emp", deptEmpQuery, []interface{}{orderKey}, keys.scanDepartmentEmployee},
+		{"titles", titlesQuery, []interface{}{orderKey}, keys.scanTitle},
+		{"salaries", salariesQuery, []interface{}{orderKey}, keys.scanSalary},
+	}
+
+	for _, table := range tables {
+		count, tableError := e.exportTable(ctx, tx, encoder, table.name, table.query, table.args, table.scan)
+		if tableError != nil {
+			return tableError
+		}
+		slog.InfoContext(ctx, "exported table", "table", table.name, "rows", count)
+	}
+
+	return writer.Flush()
+}
+
+func (e *ExportService) exportTable(ctx context.Context, tx *sql.Tx, encoder *json.Encoder, name, query string,
+	args []interface{}, scan func(rows *sql.Rows) (interface{}, error)) (int, error) {
+	ctx, span := tracing.StartSQL(ctx, "exportTable", query)
+	defer span.End()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql select query for export", "table", name, "error", err)
+		tracing.RecordError(span, err)
+		return 0, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql select query for export", "table", name, "error", err)
+		tracing.RecordError(span, err)
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		row, scanError := scan(rows)
+		if scanError != nil {
+			slog.ErrorContext(ctx, "error scanning sql select query for export", "table", name, "error", scanError)
+			tracing.RecordError(span, scanError)
+			return count, scanError
+		}
+
+		if err = encoder.Encode(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "error reading sql select query for export", "table", name, "error", err)
+		tracing.RecordError(span, err)
+	}
+
+	return count, err
+}
+
+func scanDepartment(rows *sql.Rows) (interface{}, error) {
+	row := department{Table: "departments"}
+	err := rows.Scan(&row.DepartmentNumber, &row.DepartmentName)
+	return row, err
+}
+
+// employeeKeys remembers the pseudonym of the last employee, rows of an employee come one after another.
+type employeeKeys struct {
+	pseudonymizer  *Pseudonymizer
+	employeeNumber int
+	pseudonym      int
+}
+
+func (k *employeeKeys) pseudonymOf(employeeNumber int) int {
+	if employeeNumber != k.employeeNumber || k.pseudonym == 0 {
+		k.employeeNumber = employeeNumber
+		k.pseudonym = k.pseudonymizer.EmployeeNumber(employeeNumber)
+	}
+	return k.pseudonym
+}
+
+func (k *employeeKeys) date(employeeNumber int, date time.Time) string {
+	return k.pseudonymizer.Date(employeeNumber, date).Format(dateLayout)
+}
+
+func (k *employeeKeys) scanEmployee(rows *sql.Rows) (interface{}, error) {
+	var employeeNumber int
+	var birthDate, hireDate time.Time
+	row := employee{Table: "employees"}
+	if err := rows.Scan(&employeeNumber, &birthDate, &row.Gender, &hireDate); err != nil {
+		return nil, err
+	}
+
+	row.EmployeeNumber = k.pseudonymOf(employeeNumber)
+	row.FirstName, row.LastName = k.pseudonymizer.Name(employeeNumber)
+	row.BirthDate = k.date(employeeNumber, birthDate)
+	row.HireDate = k.date(employeeNumber, hireDate)
+	return row, nil
+}
+
+func (k *employeeKeys) scanDepartmentEmployee(rows *sql.Rows) (interface{}, error) {
+	var employeeNumber int
+	var fromDate, toDate time.Time
+	row := departmentEmployee{Table: "dept_emp"}
+	if err := rows.Scan(&employeeNumber, &row.DepartmentNumber, &fromDate, &toDate); err != nil {
+		return nil, err
+	}
+
+	row.EmployeeNumber = k.pseudonymOf(employeeNumber)
+	row.FromDate = k.date(employeeNumber, fromDate)
+	row.ToDate = k.date(employeeNumber, toDate)
+	return row, nil
+}
+
+func (k *employeeKeys) scanTitle(rows *sql.Rows) (interface{}, error) {
+	var employeeNumber int
+	var fromDate, toDate time.Time
+	row := title{Table: "titles"}
+	if err := rows.Scan(&employeeNumber, &row.Title, &fromDate, &toDate); err != nil {
+		return nil, err
+	}
+
+	row.EmployeeNumber = k.pseudonymOf(employeeNumber)
+	row.FromDate = k.date(employeeNumber, fromDate)
+	row.ToDate = k.date(employeeNumber, toDate)
+	return row, nil
+}
+
+func (k *employeeKeys) scanSalary(rows *sql.Rows) (interface{}, error) {
+	var employeeNumber int
+	var fromDate, toDate time.Time
+	row := salary{Table: "salaries"}
+	if err := rows.Scan(&employeeNumber, &row.Salary, &fromDate, &toDate); err != nil {
+		return nil, err
+	}
+
+	row.EmployeeNumber = k.pseudonymOf(employeeNumber)
+	row.FromDate = k.date(employeeNumber, fromDate)
+	row.ToDate = k.date(employeeNumber, toDate)
+	return row, nil
+}
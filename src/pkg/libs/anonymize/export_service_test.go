@@ -0,0 +1,93 @@
+package anonymize
+
+import (
+	"bytes"
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportService_Export(t *testing.T) {
+	pseudonymizer := NewPseudonymizer(testKey, 30)
+	pseudonym := pseudonymizer.EmployeeNumber(10001)
+	firstName, lastName := pseudonymizer.Name(10001)
+	shift := func(date time.Time) string { return pseudonymizer.Date(10001, date).Format(dateLayout) }
+	birthDate := time.Date(1953, 9, 2, 0, 0, 0, 0, time.UTC)
+	hireDate := time.Date(1986, 6, 26, 0, 0, 0, 0, time.UTC)
+	promotion := time.Date(1995, 6, 26, 0, 0, 0, 0, time.UTC)
+	current := time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+	}{
+		{name: "caller with read:pii", principal: &auth.Principal{Roles: []string{auth.RoleHRAdmin}}},
+		{name: "caller without read:pii gets the same export", principal: &auth.Principal{Roles: []string{auth.RoleAnalyst}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			orderKey := pseudonymizer.OrderKey()
+			mock.ExpectBegin()
+			mock.ExpectPrepare(regexp.QuoteMeta(departmentsQuery)).ExpectQuery().
+				WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name"}).AddRow("d005", "Development"))
+			mock.ExpectPrepare(regexp.QuoteMeta(employeesQuery)).ExpectQuery().WithArgs(orderKey).
+				WillReturnRows(sqlmock.NewRows([]string{"emp_no", "birth_date", "gender", "hire_date"}).AddRow(10001, birthDate, "M", hireDate))
+			mock.ExpectPrepare(regexp.QuoteMeta(deptEmpQuery)).ExpectQuery().WithArgs(orderKey).
+				WillReturnRows(sqlmock.NewRows([]string{"emp_no", "dept_no", "from_date", "to_date"}).AddRow(10001, "d005", hireDate, current))
+			mock.ExpectPrepare(regexp.QuoteMeta(titlesQuery)).ExpectQuery().WithArgs(orderKey).
+				WillReturnRows(sqlmock.NewRows([]string{"emp_no", "title", "from_date", "to_date"}).
+					AddRow(10001, "Engineer", hireDate, promotion).
+					AddRow(10001, "Senior Engineer", promotion, current))
+			mock.ExpectPrepare(regexp.QuoteMeta(salariesQuery)).ExpectQuery().WithArgs(orderKey).
+				WillReturnRows(sqlmock.NewRows([]string{"emp_no", "salary", "from_date", "to_date"}).AddRow(10001, 60117, hireDate, promotion))
+			mock.ExpectRollback()
+
+			service := ExportService{ExportManager: db, Pseudonymizer: pseudonymizer}
+
+			var output bytes.Buffer
+			err = service.Export(auth.WithPrincipal(context.Background(), tt.principal), &output)
+
+			assert.NoError(t, err)
+			assert.Equal(t, strings.Join([]string{
+				`{"table":"departments","dept_no":"d005","dept_name":"Development"}`,
+				fmt.Sprintf(`{"table":"employees","emp_no":%d,"birth_date":"%s","first_name":"%s","last_name":"%s","gender":"M","hire_date":"%s"}`,
+					pseudonym, shift(birthDate), firstName, lastName, shift(hireDate)),
+				fmt.Sprintf(`{"table":"dept_emp","emp_no":%d,"dept_no":"d005","from_date":"%s","to_date":"9999-01-01"}`, pseudonym, shift(hireDate)),
+				fmt.Sprintf(`{"table":"titles","emp_no":%d,"title":"Engineer","from_date":"%s","to_date":"%s"}`, pseudonym, shift(hireDate), shift(promotion)),
+				fmt.Sprintf(`{"table":"titles","emp_no":%d,"title":"Senior Engineer","from_date":"%s","to_date":"9999-01-01"}`, pseudonym, shift(promotion)),
+				fmt.Sprintf(`{"table":"salaries","emp_no":%d,"salary":60117,"from_date":"%s","to_date":"%s"}`, pseudonym, shift(hireDate), shift(promotion)),
+			}, "\n")+"\n", output.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExportService_Export_Fails_reading_a_table(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta(departmentsQuery)).ExpectQuery().WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	service := ExportService{ExportManager: db, Pseudonymizer: NewPseudonymizer(testKey, 30)}
+
+	var output bytes.Buffer
+	err = service.Export(context.Background(), &output)
+
+	assert.Equal(t, errors.New("connection reset"), err)
+	assert.Empty(t, output.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,18 @@
+package anonymize
+
+// firstNames are used for every employee, whatever their gender, so a fake name says nothing about it.
+var firstNames = []string{
+	"Addison", "Alex", "Ariel", "Avery", "Bailey", "Blair", "Cameron", "Carmen", "Casey", "Charlie",
+	"Dakota", "Drew", "Eden", "Elliot", "Emerson", "Finley", "Frankie", "Gale", "Harper", "Hayden",
+	"Jamie", "Jesse", "Jordan", "Jules", "Kai", "Kendall", "Kim", "Lee", "Logan", "Morgan",
+	"Noa", "Parker", "Quinn", "Reese", "Riley", "Robin", "Rowan", "Sage", "Sam", "Shay",
+	"Sidney", "Skyler", "Taylor", "Terry", "Toni", "Val", "Wren", "Yael", "Yuki", "Zion",
+}
+
+var lastNames = []string{
+	"Abbott", "Baker", "Barros", "Becker", "Bianchi", "Brandt", "Carter", "Castro", "Cohen", "Costa",
+	"Dalton", "Dubois", "Ellis", "Ferrari", "Fischer", "Fontaine", "Garcia", "Gray", "Hansen", "Hoffman",
+	"Ito", "Jensen", "Keller", "Kim", "Kowalski", "Lambert", "Larsen", "Lopez", "Marsh", "Moreau",
+	"Nakamura", "Novak", "Olsen", "Parker", "Petrov", "Quinn", "Reyes", "Rossi", "Sato", "Schmidt",
+	"Silva", "Stone", "Tanaka", "Turner", "Vidal", "Wagner", "Walsh", "Weber", "Young", "Zimmer",
+}
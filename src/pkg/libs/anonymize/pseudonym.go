@@ -0,0 +1,85 @@
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"time"
+)
+
+const feistelRounds = 4
+
+// Pseudonymizer derives every pseudonym from a secret key, so the same employee gets the same pseudonym,
+// name and date shift in every table and every export made with the key.
+type Pseudonymizer struct {
+	key        []byte
+	jitterDays int
+}
+
+func NewPseudonymizer(key string, jitterDays int) *Pseudonymizer {
+	return &Pseudonymizer{key: []byte(key), jitterDays: jitterDays}
+}
+
+// EmployeeNumber maps an employee number to a pseudonym in 1..math.MaxInt32. It is a keyed permutation,
+// a Feistel network over 32 bits with HMAC-SHA256 rounds, so two employees never share a pseudonym.
+// Values outside the range are permuted again until they land in it.
+func (p *Pseudonymizer) EmployeeNumber(employeeNumber int) int {
+	value := uint32(employeeNumber)
+	for {
+		value = p.permute(value)
+		if value != 0 && value <= math.MaxInt32 {
+			return int(value)
+		}
+	}
+}
+
+// Name picks a fake first and last name. First names come from one gender-neutral list, so the name does
+// not give away the gender even where the gender is not exported.
+func (p *Pseudonymizer) Name(employeeNumber int) (string, string) {
+	sum := p.sum("name", uint32(employeeNumber))
+	return firstNames[binary.BigEndian.Uint32(sum[0:4])%uint32(len(firstNames))],
+		lastNames[binary.BigEndian.Uint32(sum[4:8])%uint32(len(lastNames))]
+}
+
+// Date shifts a date of the employee by the employee's offset, up to jitterDays either way. Every date
+// of an employee moves together, so their order and durations are kept. The 9999-01-01 end date of
+// current rows is left alone.
+func (p *Pseudonymizer) Date(employeeNumber int, date time.Time) time.Time {
+	if date.Year() == 9999 {
+		return date
+	}
+	return date.AddDate(0, 0, p.offset(employeeNumber))
+}
+
+func (p *Pseudonymizer) offset(employeeNumber int) int {
+	if p.jitterDays == 0 {
+		return 0
+	}
+
+	sum := p.sum("jitter", uint32(employeeNumber))
+	return int(binary.BigEndian.Uint32(sum)%uint32(2*p.jitterDays+1)) - p.jitterDays
+}
+
+// OrderKey is a secret derived from the key for the database to sort rows by, so their order does not
+// follow the real employee numbers. The key itself never reaches the database.
+func (p *Pseudonymizer) OrderKey() string {
+	return hex.EncodeToString(p.sum("order", 0))
+}
+
+func (p *Pseudonymizer) permute(value uint32) uint32 {
+	left, right := uint16(value>>16), uint16(value)
+	for round := 0; round < feistelRounds; round++ {
+		sum := p.sum("round", uint32(round)<<16|uint32(right))
+		left, right = right, left^binary.BigEndian.Uint16(sum)
+	}
+	return uint32(left)<<16 | uint32(right)
+}
+
+func (p *Pseudonymizer) sum(purpose string, value uint32) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(purpose))
+	mac.Write(binary.BigEndian.AppendUint32(nil, value))
+	return mac.Sum(nil)
+}
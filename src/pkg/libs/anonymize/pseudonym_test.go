@@ -0,0 +1,57 @@
+package anonymize
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func TestPseudonymizer_EmployeeNumber(t *testing.T) {
+	pseudonymizer := NewPseudonymizer(testKey, 30)
+
+	seen := make(map[int]int)
+	for employeeNumber := 10001; employeeNumber <= 60000; employeeNumber++ {
+		pseudonym := pseudonymizer.EmployeeNumber(employeeNumber)
+		assert.True(t, pseudonym >= 1 && pseudonym <= math.MaxInt32, "pseudonym %d out of range", pseudonym)
+		if previous, ok := seen[pseudonym]; ok {
+			t.Fatalf("employees %d and %d share pseudonym %d", previous, employeeNumber, pseudonym)
+		}
+		seen[pseudonym] = employeeNumber
+	}
+
+	assert.Equal(t, pseudonymizer.EmployeeNumber(10001), NewPseudonymizer(testKey, 0).EmployeeNumber(10001))
+	assert.NotEqual(t, pseudonymizer.EmployeeNumber(10001), NewPseudonymizer(testKey+"x", 30).EmployeeNumber(10001))
+}
+
+func TestPseudonymizer_Name(t *testing.T) {
+	pseudonymizer := NewPseudonymizer(testKey, 30)
+
+	first, last := pseudonymizer.Name(10001)
+	assert.Contains(t, firstNames, first)
+	assert.Contains(t, lastNames, last)
+
+	sameFirst, sameLast := pseudonymizer.Name(10001)
+	assert.Equal(t, first, sameFirst)
+	assert.Equal(t, last, sameLast)
+}
+
+func TestPseudonymizer_Date(t *testing.T) {
+	pseudonymizer := NewPseudonymizer(testKey, 30)
+	hired := time.Date(1986, 6, 26, 0, 0, 0, 0, time.UTC)
+	current := time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for employeeNumber := 10001; employeeNumber <= 10500; employeeNumber++ {
+		shifted := pseudonymizer.Date(employeeNumber, hired)
+		days := int(shifted.Sub(hired).Hours() / 24)
+		assert.True(t, days >= -30 && days <= 30, "shift of %d days", days)
+
+		later := pseudonymizer.Date(employeeNumber, hired.AddDate(1, 0, 0))
+		assert.Equal(t, hired.AddDate(1, 0, 0).Sub(hired), later.Sub(shifted))
+		assert.Equal(t, current, pseudonymizer.Date(employeeNumber, current))
+	}
+
+	assert.Equal(t, hired, NewPseudonymizer(testKey, 0).Date(10001, hired))
+}
@@ -0,0 +1,294 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"employee_exercise/src/pkg/libs/audit"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/models"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	Header = auth.APIKeyHeader
+
+	keyPrefix    = "emp_"
+	prefixLength = 12
+
+	insertQuery       = "INSERT INTO api_keys (owner, prefix, key_hash, admin, roles, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	selectColumns     = "SELECT id, owner, prefix, admin, roles, created_at, last_used_at, revoked_at FROM api_keys"
+	listQuery         = selectColumns + " ORDER BY id"
+	authenticateQuery = selectColumns + " WHERE key_hash = ? AND revoked_at IS NULL"
+	lockActiveQuery   = selectColumns + " WHERE id = ? AND revoked_at IS NULL FOR UPDATE"
+	revokeQuery       = "UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL"
+	touchQuery        = "UPDATE api_keys SET last_used_at = ? WHERE id = ?"
+)
+
+var (
+	ErrKeyNotFound = errors.New("api key not found")
+	ErrInvalidKey  = auth.ErrInvalidAPIKey
+	ErrUnknownRole = errors.New("unknown api key role")
+)
+
+type APIKeyService struct {
+	APIKeyManager *sql.DB
+	now           func() time.Time
+}
+
+// Issue creates a key for owner with roles, which must be roles of auth.RolePermissions other than admin, use
+// admin for that, and manager, which is scoped to the departments of an employee a key is not linked to. The plain key is only part of the returned value, the database keeps its hash.
+func (a *APIKeyService) Issue(ctx context.Context, owner string, admin bool, roles []string) (*models.IssuedAPIKey, error) {
+	roles, err := checkRoles(roles)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := GenerateKey()
+	if err != nil {
+		slog.ErrorContext(ctx, "error generating api key", "error", err)
+		return nil, err
+	}
+
+	issued := models.IssuedAPIKey{
+		APIKey: models.APIKey{
+			Owner:     owner,
+			Prefix:    key[:prefixLength],
+			Admin:     admin,
+			Roles:     roles,
+			CreatedAt: a.currentTime(),
+		},
+		Key: key,
+	}
+
+	tx, err := a.APIKeyManager.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "error starting transaction for api key", "error", err)
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql insert query for api key", "error", err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, owner, issued.Prefix, Hash(key), admin, strings.Join(roles, ","), issued.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql insert query for api key", "error", err)
+		return nil, err
+	}
+
+	if issued.ID, err = result.LastInsertId(); err != nil {
+		slog.ErrorContext(ctx, "error reading api key id", "error", err)
+		return nil, err
+	}
+
+	if err = audit.Record(ctx, tx, audit.ActionAPIKeyIssue, 0, nil, issued.APIKey); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "error committing transaction for api key", "error", err)
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "api key issued", "api_key_id", issued.ID, "owner", owner, "admin", admin, "roles", roles)
+	return &issued, nil
+}
+
+func (a *APIKeyService) List(ctx context.Context) ([]models.APIKey, error) {
+	stmt, err := a.APIKeyManager.PrepareContext(ctx, listQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql select query for api keys", "error", err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql select query for api keys", "error", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, scanError := scanKey(rows)
+		if scanError != nil {
+			slog.ErrorContext(ctx, "error scanning sql select query for api keys", "error", scanError)
+			return nil, scanError
+		}
+		keys = append(keys, *key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (a *APIKeyService) Revoke(ctx context.Context, id int64) error {
+	tx, err := a.APIKeyManager.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "error starting transaction for api key", "api_key_id", id, "error", err)
+		return err
+	}
+
+	defer tx.Rollback()
+
+	lockStmt, err := tx.PrepareContext(ctx, lockActiveQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql select query for api key", "api_key_id", id, "error", err)
+		return err
+	}
+
+	defer lockStmt.Close()
+
+	active, err := scanKey(lockStmt.QueryRowContext(ctx, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrKeyNotFound
+		}
+		slog.ErrorContext(ctx, "error scanning sql select query for api key", "api_key_id", id, "error", err)
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, revokeQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql update query for api key", "api_key_id", id, "error", err)
+		return err
+	}
+
+	defer stmt.Close()
+
+	revokedAt := a.currentTime()
+	if _, err = stmt.ExecContext(ctx, revokedAt, id); err != nil {
+		slog.ErrorContext(ctx, "error executing sql update query for api key", "api_key_id", id, "error", err)
+		return err
+	}
+
+	revoked := *active
+	revoked.RevokedAt = &revokedAt
+	if err = audit.Record(ctx, tx, audit.ActionAPIKeyRevoke, 0, active, revoked); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "error committing transaction for api key", "api_key_id", id, "error", err)
+		return err
+	}
+
+	slog.InfoContext(ctx, "api key revoked", "api_key_id", id)
+	return nil
+}
+
+// Authenticate returns the active key matching the plain key and records that it was used.
+func (a *APIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	stmt, err := a.APIKeyManager.PrepareContext(ctx, authenticateQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql select query for api key", "error", err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	apiKey, err := scanKey(stmt.QueryRowContext(ctx, Hash(key)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidKey
+		}
+		slog.ErrorContext(ctx, "error scanning sql select query for api key", "error", err)
+		return nil, err
+	}
+
+	usedAt := a.currentTime()
+	if _, touchError := a.APIKeyManager.ExecContext(ctx, touchQuery, usedAt, apiKey.ID); touchError != nil {
+		slog.ErrorContext(ctx, "error recording api key use", "api_key_id", apiKey.ID, "error", touchError)
+	} else {
+		apiKey.LastUsedAt = &usedAt
+	}
+
+	return apiKey, nil
+}
+
+// GenerateKey returns a new random key, recognisable by its emp_ prefix.
+func GenerateKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *APIKeyService) currentTime() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// checkRoles returns the roles without duplicates, failing with ErrUnknownRole for roles keys cannot have.
+func checkRoles(roles []string) ([]string, error) {
+	checked := []string{}
+	for _, role := range roles {
+		if _, known := auth.RolePermissions[role]; !known || role == auth.RoleAdmin || role == auth.RoleManager {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownRole, role)
+		}
+		if !contains(checked, role) {
+			checked = append(checked, role)
+		}
+	}
+	return checked, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func scanKey(row scanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var roles string
+	var lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Owner, &key.Prefix, &key.Admin, &roles, &key.CreatedAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+
+	key.Roles = []string{}
+	if roles != "" {
+		key.Roles = strings.Split(roles, ",")
+	}
+
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
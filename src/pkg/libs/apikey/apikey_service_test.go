@@ -0,0 +1,207 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"employee_exercise/src/pkg/libs/audit"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+func TestAPIKeyService_Issue(t *testing.T) {
+	db, mock := newMock(t)
+
+	var storedHash string
+	mock.ExpectBegin()
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(insertQuery)).
+		ExpectExec().
+		WithArgs("payroll", sqlmock.AnyArg(), hashArgument{&storedHash}, false, "hr,analyst", now).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	expectAudit(mock, audit.ActionAPIKeyIssue, nil, sqlmock.AnyArg())
+	mock.ExpectCommit()
+
+	service := APIKeyService{APIKeyManager: db, now: func() time.Time { return now }}
+	issued, err := service.Issue(context.Background(), "payroll", false, []string{"hr", "analyst", "hr"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), issued.ID)
+	assert.True(t, strings.HasPrefix(issued.Key, "emp_"))
+	assert.Len(t, issued.Key, 47)
+	assert.Equal(t, issued.Key[:12], issued.Prefix)
+	assert.Equal(t, Hash(issued.Key), storedHash)
+	assert.Equal(t, []string{"hr", "analyst"}, issued.Roles)
+	assert.Equal(t, now, issued.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyService_Issue_Fails_with_unknown_role(t *testing.T) {
+	for _, role := range []string{"boss", "admin", "manager"} {
+		t.Run(role, func(t *testing.T) {
+			db, mock := newMock(t)
+
+			service := APIKeyService{APIKeyManager: db}
+			issued, err := service.Issue(context.Background(), "payroll", false, []string{"hr", role})
+
+			assert.Nil(t, issued)
+			assert.ErrorIs(t, err, ErrUnknownRole)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPIKeyService_List(t *testing.T) {
+	db, mock := newMock(t)
+
+	usedAt := now.Add(time.Hour)
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(listQuery)).
+		ExpectQuery().
+		WillReturnRows(keyRows().
+			AddRow(1, "payroll", "emp_abcdefgh", true, "", now, usedAt, nil).
+			AddRow(2, "reporting", "emp_ijklmnop", false, "hr,analyst", now, nil, usedAt))
+
+	service := APIKeyService{APIKeyManager: db}
+	keys, err := service.List(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.APIKey{
+		{ID: 1, Owner: "payroll", Prefix: "emp_abcdefgh", Admin: true, Roles: []string{}, CreatedAt: now, LastUsedAt: &usedAt},
+		{ID: 2, Owner: "reporting", Prefix: "emp_ijklmnop", Roles: []string{"hr", "analyst"}, CreatedAt: now, RevokedAt: &usedAt},
+	}, keys)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	revokedAt := `{"id":3,"owner":"payroll","prefix":"emp_abcdefgh","admin":false,"roles":["hr"],"created_at":"2021-03-04T05:06:07Z","revoked_at":"2021-03-04T05:06:07Z"}`
+
+	tests := []struct {
+		name          string
+		active        bool
+		execError     error
+		expectedError error
+	}{
+		{name: "revokes an active key", active: true},
+		{name: "unknown or already revoked key", active: false, expectedError: ErrKeyNotFound},
+		{name: "database error", active: true, execError: errors.New("connection reset"), expectedError: errors.New("connection reset")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMock(t)
+
+			mock.ExpectBegin()
+			rows := keyRows()
+			if tt.active {
+				rows.AddRow(3, "payroll", "emp_abcdefgh", false, "hr", now, nil, nil)
+			}
+			mock.
+				ExpectPrepare(regexp.QuoteMeta(lockActiveQuery)).
+				ExpectQuery().
+				WithArgs(int64(3)).
+				WillReturnRows(rows)
+
+			if tt.active {
+				exec := mock.
+					ExpectPrepare(regexp.QuoteMeta(revokeQuery)).
+					ExpectExec().
+					WithArgs(now, int64(3))
+				if tt.execError != nil {
+					exec.WillReturnError(tt.execError)
+				} else {
+					exec.WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+
+			if tt.expectedError == nil {
+				expectAudit(mock, audit.ActionAPIKeyRevoke, sqlmock.AnyArg(), revokedAt)
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			service := APIKeyService{APIKeyManager: db, now: func() time.Time { return now }}
+			err := service.Revoke(context.Background(), 3)
+
+			assert.Equal(t, tt.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(authenticateQuery)).
+		ExpectQuery().
+		WithArgs(Hash("emp_secret")).
+		WillReturnRows(keyRows().AddRow(4, "payroll", "emp_secret", false, "manager", now, nil, nil))
+	mock.
+		ExpectExec(regexp.QuoteMeta(touchQuery)).
+		WithArgs(now, int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := APIKeyService{APIKeyManager: db, now: func() time.Time { return now }}
+	key, err := service.Authenticate(context.Background(), "emp_secret")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.APIKey{ID: 4, Owner: "payroll", Prefix: "emp_secret", Roles: []string{"manager"}, CreatedAt: now, LastUsedAt: &now}, key)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyService_Authenticate_Fails_with_unknown_key(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(authenticateQuery)).
+		ExpectQuery().
+		WithArgs(Hash("emp_revoked")).
+		WillReturnError(sql.ErrNoRows)
+
+	service := APIKeyService{APIKeyManager: db}
+	key, err := service.Authenticate(context.Background(), "emp_revoked")
+
+	assert.Nil(t, key)
+	assert.Equal(t, ErrInvalidKey, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type hashArgument struct {
+	value *string
+}
+
+func (h hashArgument) Match(v driver.Value) bool {
+	hash, ok := v.(string)
+	*h.value = hash
+	return ok && len(hash) == 64
+}
+
+func keyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "owner", "prefix", "admin", "roles", "created_at", "last_used_at", "revoked_at"})
+}
+
+func newMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db, mock
+}
+
+func expectAudit(mock sqlmock.Sqlmock, action string, before, after driver.Value) {
+	mock.
+		ExpectPrepare(regexp.QuoteMeta("INSERT INTO audit_log")).
+		ExpectExec().
+		WithArgs(audit.SystemActor, "", action, nil, before, after).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/logging"
+	"employee_exercise/src/pkg/libs/tracing"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+const (
+	ActionEmployeeDepartmentCreate = "employee_department.create"
+	ActionEmployeeDepartmentUpdate = "employee_department.update"
+	ActionAPIKeyIssue              = "api_key.issue"
+	ActionAPIKeyRevoke             = "api_key.revoke"
+
+	// SystemActor records mutations made without an authenticated principal.
+	SystemActor = "system"
+
+	insertQuery = "INSERT INTO audit_log (actor, request_id, action, emp_no, before_state, after_state) VALUES (?, ?, ?, ?, ?, ?)"
+	selectQuery = "SELECT id, created_at, actor, request_id, action, emp_no, before_state, after_state FROM audit_log"
+)
+
+// Record appends an entry to the audit log in the transaction of the mutation it describes, so both are
+// committed or rolled back together. The actor and request ID come from the context, employeeNumber 0
+// means the mutation concerns no employee and a nil before or after state is stored as NULL.
+func Record(ctx context.Context, tx *sql.Tx, action string, employeeNumber int, before, after interface{}) error {
+	ctx, span := tracing.StartSQL(ctx, "recordAudit", insertQuery)
+	defer span.End()
+
+	beforeState, err := state(before)
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding audit before state", "action", action, "error", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	afterState, err := state(after)
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding audit after state", "action", action, "error", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	var employee interface{}
+	if employeeNumber != 0 {
+		employee = employeeNumber
+	}
+
+	stmt, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql insert query for audit log", "action", action, "error", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, Actor(ctx), logging.RequestID(ctx), action, employee, beforeState, afterState); err != nil {
+		slog.ErrorContext(ctx, "error executing sql insert query for audit log", "action", action, "error", err)
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// Actor is the subject of the principal in the context, or SystemActor.
+func Actor(ctx context.Context) string {
+	if principal := auth.PrincipalFromContext(ctx); principal != nil {
+		return principal.Subject
+	}
+	return SystemActor
+}
+
+func state(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil || string(encoded) == "null" {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+type AuditService struct {
+	AuditManager *sql.DB
+}
+
+// GetAuditLog returns the entries matching the filter, newest first.
+func (a *AuditService) GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var conditions []string
+	var arguments []interface{}
+	if filter.EmployeeNumber != 0 {
+		conditions = append(conditions, "emp_no = ?")
+		arguments = append(arguments, filter.EmployeeNumber)
+	}
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		arguments = append(arguments, filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		arguments = append(arguments, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		arguments = append(arguments, filter.To)
+	}
+
+	query := selectQuery
+	if len(conditions) != 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	arguments = append(arguments, filter.Limit, filter.Offset)
+
+	ctx, span := tracing.StartSQL(ctx, "getAuditLog", query)
+	defer span.End()
+
+	stmt, err := a.AuditManager.PrepareContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql select query for audit log", "error", err)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, arguments...)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql select query for audit log", "error", err)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var employee sql.NullInt64
+		var before, after []byte
+		err = rows.Scan(&entry.ID, &entry.CreatedAt, &entry.Actor, &entry.RequestID, &entry.Action, &employee, &before, &after)
+		if err != nil {
+			slog.ErrorContext(ctx, "error scanning sql select query for audit log", "error", err)
+			tracing.RecordError(span, err)
+			return nil, err
+		}
+
+		if employee.Valid {
+			employeeNumber := int(employee.Int64)
+			entry.EmployeeNumber = &employeeNumber
+		}
+		if before != nil {
+			entry.Before = json.RawMessage(before)
+		}
+		if after != nil {
+			entry.After = json.RawMessage(after)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
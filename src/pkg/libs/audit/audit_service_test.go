@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/logging"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectBegin()
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(insertQuery)).
+		ExpectExec().
+		WithArgs("jwt:alice", "4bf92f3577b34da6a3ce929d0e0e4736", ActionEmployeeDepartmentUpdate, 10002,
+			`{"dept_no":"d006","dept_name":"Research"}`, `{"dept_no":"d005","emp_no":10002}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "jwt:alice"})
+	ctx = logging.WithRequestID(ctx, "4bf92f3577b34da6a3ce929d0e0e4736")
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	err = Record(ctx, tx, ActionEmployeeDepartmentUpdate, 10002,
+		&models.Department{DepartmentNumber: "d006", DepartmentName: "Research"}, map[string]interface{}{"emp_no": 10002, "dept_no": "d005"})
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecord_Stores_missing_states_and_employee_as_null(t *testing.T) {
+	db, mock := newMock(t)
+
+	var missing *models.EmployeeDepartment
+	mock.ExpectBegin()
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(insertQuery)).
+		ExpectExec().
+		WithArgs(SystemActor, "", ActionAPIKeyIssue, nil, nil, `{"id":7}`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, Record(context.Background(), tx, ActionAPIKeyIssue, 0, missing, map[string]int{"id": 7}))
+	assert.NoError(t, tx.Rollback())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditService_GetAuditLog(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	employeeNumber := 10002
+
+	tests := []struct {
+		name            string
+		filter          models.AuditFilter
+		expectedQuery   string
+		expectedArgs    []interface{}
+		queryError      error
+		expectedEntries []models.AuditEntry
+		expectedError   error
+	}{
+		{
+			name:          "no filter",
+			filter:        models.AuditFilter{Limit: 50},
+			expectedQuery: selectQuery + " ORDER BY id DESC LIMIT ? OFFSET ?",
+			expectedArgs:  []interface{}{50, 0},
+			expectedEntries: []models.AuditEntry{
+				{
+					ID: 2, CreatedAt: createdAt, Actor: "jwt:alice", RequestID: "abc", Action: ActionEmployeeDepartmentUpdate,
+					EmployeeNumber: &employeeNumber, Before: json.RawMessage(`{"dept_no":"d006"}`), After: json.RawMessage(`{"dept_no":"d005"}`),
+				},
+				{ID: 1, CreatedAt: createdAt, Actor: SystemActor, Action: ActionAPIKeyIssue, After: json.RawMessage(`{"id":7}`)},
+			},
+		},
+		{
+			name:            "every filter",
+			filter:          models.AuditFilter{EmployeeNumber: 10002, Actor: "jwt:alice", From: from, To: to, Limit: 10, Offset: 20},
+			expectedQuery:   selectQuery + " WHERE emp_no = ? AND actor = ? AND created_at >= ? AND created_at < ? ORDER BY id DESC LIMIT ? OFFSET ?",
+			expectedArgs:    []interface{}{10002, "jwt:alice", from, to, 10, 20},
+			expectedEntries: []models.AuditEntry{},
+		},
+		{
+			name:          "database error",
+			filter:        models.AuditFilter{Actor: "system", Limit: 10},
+			expectedQuery: selectQuery + " WHERE actor = ? ORDER BY id DESC LIMIT ? OFFSET ?",
+			expectedArgs:  []interface{}{"system", 10, 0},
+			queryError:    errors.New("connection reset"),
+			expectedError: errors.New("connection reset"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMock(t)
+
+			query := mock.
+				ExpectPrepare(regexp.QuoteMeta(tt.expectedQuery) + "$").
+				ExpectQuery().
+				WithArgs(toValues(tt.expectedArgs)...)
+			if tt.queryError != nil {
+				query.WillReturnError(tt.queryError)
+			} else {
+				rows := sqlmock.NewRows([]string{"id", "created_at", "actor", "request_id", "action", "emp_no", "before_state", "after_state"})
+				for _, entry := range tt.expectedEntries {
+					var employee, before interface{}
+					if entry.EmployeeNumber != nil {
+						employee = *entry.EmployeeNumber
+					}
+					if entry.Before != nil {
+						before = []byte(entry.Before)
+					}
+					rows.AddRow(entry.ID, entry.CreatedAt, entry.Actor, entry.RequestID, entry.Action, employee, before, []byte(entry.After))
+				}
+				query.WillReturnRows(rows)
+			}
+
+			service := AuditService{AuditManager: db}
+			entries, err := service.GetAuditLog(context.Background(), tt.filter)
+
+			assert.Equal(t, tt.expectedError, err)
+			if tt.expectedError == nil {
+				assert.Equal(t, tt.expectedEntries, entries)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func toValues(arguments []interface{}) []driver.Value {
+	values := make([]driver.Value, 0, len(arguments))
+	for _, argument := range arguments {
+		values = append(values, argument)
+	}
+	return values
+}
+
+func newMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db, mock
+}
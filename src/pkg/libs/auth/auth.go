@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	MethodAPIKey = "api_key"
+	APIKeyHeader = "X-API-Key"
+)
+
+// ErrInvalidAPIKey is returned by a KeyAuthenticator for unknown and revoked keys.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// Unauthenticated is returned by Authenticate for missing or invalid credentials. Message is safe to show
+// to clients.
+type Unauthenticated struct {
+	Message string
+}
+
+func (u *Unauthenticated) Error() string {
+	return u.Message
+}
+
+// Principal is the authenticated caller of a request. EmployeeNumber links it to an employee, managers
+// are scoped to the departments that employee manages.
+type Principal struct {
+	Subject        string
+	Name           string
+	Method         string
+	Admin          bool
+	Roles          []string
+	EmployeeNumber int
+}
+
+type KeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+type Authenticator struct {
+	Keys KeyAuthenticator
+	// Tokens verifies bearer tokens, they are rejected when it is nil.
+	Tokens *TokenVerifier
+	public map[string]bool
+}
+
+func New(keys KeyAuthenticator, publicRoutes ...string) *Authenticator {
+	public := make(map[string]bool, len(publicRoutes))
+	for _, route := range publicRoutes {
+		public[route] = true
+	}
+
+	return &Authenticator{Keys: keys, public: public}
+}
+
+// Middleware rejects requests to non public routes that carry neither a valid bearer token in the
+// Authorization header nor a valid API key in the X-API-Key header.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil && a.public[template] {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		principal, err := a.Authenticate(r.Context(), r.Header.Get("Authorization"), r.Header.Get(APIKeyHeader))
+		if err != nil {
+			var unauthenticated *Unauthenticated
+			if errors.As(err, &unauthenticated) {
+				a.unauthorized(w, r, unauthenticated.Message)
+				return
+			}
+			problem.Write(w, r, problem.Internal())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// Authenticate resolves the principal of a bearer token in authorization, the value of an Authorization
+// header, or else of apiKey. Missing and invalid credentials are an *Unauthenticated error.
+func (a *Authenticator) Authenticate(ctx context.Context, authorization, apiKey string) (*Principal, error) {
+	if token, ok := bearerToken(authorization); ok && a.Tokens != nil {
+		principal, err := a.Tokens.Verify(ctx, token)
+		if err != nil {
+			slog.WarnContext(ctx, "rejected bearer token", "error", err)
+			return nil, &Unauthenticated{Message: "unauthorized, invalid bearer token"}
+		}
+		return principal, nil
+	}
+
+	if apiKey == "" {
+		return nil, &Unauthenticated{Message: a.missingCredentials()}
+	}
+
+	key, err := a.Keys.Authenticate(ctx, apiKey)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAPIKey) {
+			slog.WarnContext(ctx, "rejected invalid api key")
+			return nil, &Unauthenticated{Message: "unauthorized, invalid api key"}
+		}
+		return nil, err
+	}
+
+	return &Principal{
+		Subject: fmt.Sprintf("api_key:%d", key.ID),
+		Name:    key.Owner,
+		Method:  MethodAPIKey,
+		Admin:   key.Admin,
+		Roles:   apiKeyRoles(key),
+	}, nil
+}
+
+func (a *Authenticator) missingCredentials() string {
+	if a.Tokens != nil {
+		return "unauthorized, missing bearer token or api key"
+	}
+	return "unauthorized, missing api key"
+}
+
+func (a *Authenticator) unauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	if a.Tokens != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, message))
+}
+
+func bearerToken(authorization string) (string, bool) {
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// apiKeyRoles returns the roles a key was issued with, admin keys also having the admin role. A key issued
+// without roles gets no permission at all.
+func apiKeyRoles(apiKey *models.APIKey) []string {
+	roles := append([]string{}, apiKey.Roles...)
+	if apiKey.Admin {
+		roles = append([]string{RoleAdmin}, roles...)
+	}
+	return roles
+}
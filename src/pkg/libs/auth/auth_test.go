@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type KeyAuthenticatorMock struct {
+	keys map[string]*models.APIKey
+	err  error
+}
+
+func (k *KeyAuthenticatorMock) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if k.err != nil {
+		return nil, k.err
+	}
+	if apiKey, ok := k.keys[key]; ok {
+		return apiKey, nil
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	keys := &KeyAuthenticatorMock{keys: map[string]*models.APIKey{
+		"emp_admin": {ID: 1, Owner: "ops", Admin: true},
+		"emp_user":  {ID: 2, Owner: "payroll"},
+		"emp_hr":    {ID: 3, Owner: "people", Roles: []string{RoleHR}},
+	}}
+
+	tests := []struct {
+		name                 string
+		authenticator        *KeyAuthenticatorMock
+		url                  string
+		key                  string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "valid key",
+			authenticator:        keys,
+			url:                  "/employees",
+			key:                  "emp_user",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "api_key:2 payroll api_key false",
+		},
+		{
+			name:                 "missing key",
+			authenticator:        keys,
+			url:                  "/employees",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"unauthorized, missing api key","instance":"/employees","code":"unauthorized"}`,
+		},
+		{
+			name:                 "invalid key",
+			authenticator:        keys,
+			url:                  "/employees",
+			key:                  "emp_revoked",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"unauthorized, invalid api key","instance":"/employees","code":"unauthorized"}`,
+		},
+		{
+			name:                 "store error",
+			authenticator:        &KeyAuthenticatorMock{err: errors.New("connection reset")},
+			url:                  "/employees",
+			key:                  "emp_user",
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/employees","code":"internal_error"}`,
+		},
+		{
+			name:                 "public route",
+			authenticator:        keys,
+			url:                  "/healthz",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "anonymous",
+		},
+		{
+			name:                 "admin route with user key",
+			authenticator:        keys,
+			url:                  "/api-keys",
+			key:                  "emp_user",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"forbidden","instance":"/api-keys","code":"forbidden"}`,
+		},
+		{
+			name:                 "key without roles gets no permission",
+			authenticator:        keys,
+			url:                  "/reports",
+			key:                  "emp_user",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"forbidden","instance":"/reports","code":"forbidden"}`,
+		},
+		{
+			name:                 "key gets the permissions of its roles",
+			authenticator:        keys,
+			url:                  "/reports",
+			key:                  "emp_hr",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "api_key:3 people api_key false",
+		},
+		{
+			name:                 "key roles do not include admin",
+			authenticator:        keys,
+			url:                  "/api-keys",
+			key:                  "emp_hr",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `{"type":"about:blank","title":"Forbidden","status":403,"detail":"forbidden","instance":"/api-keys","code":"forbidden"}`,
+		},
+		{
+			name:                 "admin route with admin key",
+			authenticator:        keys,
+			url:                  "/api-keys",
+			key:                  "emp_admin",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "api_key:1 ops api_key true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Use(New(tt.authenticator, "/healthz").Middleware)
+			router.HandleFunc("/employees", writePrincipal)
+			router.HandleFunc("/healthz", writePrincipal)
+			router.HandleFunc("/api-keys", RequirePermission(writePrincipal, PermissionManageAPIKeys))
+			router.HandleFunc("/reports", RequirePermission(writePrincipal, PermissionReadReports))
+
+			request := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.key != "" {
+				request.Header.Set(APIKeyHeader, tt.key)
+			}
+			recorder := httptest.NewRecorder()
+
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedResponseCode, recorder.Code)
+			assert.Equal(t, tt.expectedResponseBody, recorder.Body.String())
+		})
+	}
+}
+
+func writePrincipal(w http.ResponseWriter, r *http.Request) {
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil {
+		w.Write([]byte("anonymous"))
+		return
+	}
+
+	w.Write([]byte(principal.Subject + " " + principal.Name + " " + principal.Method + " " + strconv.FormatBool(principal.Admin)))
+}
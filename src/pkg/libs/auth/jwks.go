@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// minRefetchInterval bounds how often an unknown key ID may trigger a JWKS download.
+	minRefetchInterval = time.Minute
+	fetchTimeout       = 10 * time.Second
+)
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+type verificationKey struct {
+	alg string
+	key interface{}
+}
+
+// KeySet holds the verification keys of a JWKS document read from a file or downloaded from a URL.
+// Downloaded sets are refreshed every Refresh and when a token names an unknown key.
+type KeySet struct {
+	URL     string
+	Refresh time.Duration
+	Client  *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]verificationKey
+	fetched time.Time
+	group   singleflight.Group
+}
+
+func LoadKeySetFile(path string) (*KeySet, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading jwks file: %w", err)
+	}
+
+	keys, err := parseKeySet(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing jwks file %s: %w", path, err)
+	}
+
+	return &KeySet{keys: keys}, nil
+}
+
+func NewKeySetURL(url string, refresh time.Duration) *KeySet {
+	return &KeySet{URL: url, Refresh: refresh, Client: &http.Client{Timeout: fetchTimeout}}
+}
+
+// lookup returns the key named kid, or the only key when the token does not name one. Refreshes run on their
+// own, one at a time: requests keep verifying with the cached keys meanwhile and only wait when there are none
+// yet or kid is not among them.
+func (k *KeySet) lookup(ctx context.Context, kid string) (verificationKey, error) {
+	if k.URL != "" {
+		k.mu.Lock()
+		_, known := k.keys[kid]
+		empty := k.keys == nil
+		stale := empty || time.Since(k.fetched) > k.Refresh
+		refetch := !known && time.Since(k.fetched) > minRefetchInterval
+		k.mu.Unlock()
+
+		if stale || refetch {
+			refreshed := k.refresh()
+			if empty || !known {
+				select {
+				case result := <-refreshed:
+					if result.Err != nil && empty {
+						return verificationKey{}, result.Err
+					}
+				case <-ctx.Done():
+					return verificationKey{}, ctx.Err()
+				}
+			}
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, nil
+		}
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return verificationKey{}, fmt.Errorf("unknown key %q", kid)
+	}
+
+	return key, nil
+}
+
+// refresh downloads the key set unless a download is already running, on a context of its own so a request
+// going away does not abort it for the others.
+func (k *KeySet) refresh() <-chan singleflight.Result {
+	return k.group.DoChan(k.URL, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+
+		err := k.fetch(ctx)
+		if err != nil {
+			slog.Warn("could not refresh the jwks", "url", k.URL, "error", err)
+		}
+		return nil, err
+	})
+}
+
+func (k *KeySet) fetch(ctx context.Context) error {
+	k.mu.Lock()
+	k.fetched = time.Now()
+	k.mu.Unlock()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, k.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := k.Client.Do(request)
+	if err != nil {
+		return fmt.Errorf("downloading jwks: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading jwks: unexpected status %d", response.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("downloading jwks: %w", err)
+	}
+
+	keys, err := parseKeySet(content)
+	if err != nil {
+		return fmt.Errorf("parsing jwks from %s: %w", k.URL, err)
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+func parseKeySet(content []byte) (map[string]verificationKey, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]verificationKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var key verificationKey
+		switch jwk.Kty {
+		case "RSA":
+			publicKey, err := rsaPublicKey(jwk)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+			}
+			key = verificationKey{alg: "RS256", key: publicKey}
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+			if err != nil || len(secret) == 0 {
+				return nil, fmt.Errorf("key %q: invalid k", jwk.Kid)
+			}
+			key = verificationKey{alg: "HS256", key: secret}
+		default:
+			continue
+		}
+
+		if jwk.Alg != "" && jwk.Alg != key.alg {
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RS256 or HS256 signing keys")
+	}
+
+	return keys, nil
+}
+
+func rsaPublicKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil || len(modulus) == 0 {
+		return nil, fmt.Errorf("invalid n")
+	}
+
+	exponent, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, fmt.Errorf("invalid e")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
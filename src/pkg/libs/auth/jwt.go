@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+const (
+	MethodJWT = "jwt"
+
+	// AdminRole in a token's roles claim grants the same rights as an admin API key.
+	AdminRole = "admin"
+)
+
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Email             string   `json:"email"`
+	Roles             []string `json:"roles"`
+	EmployeeNumber    int      `json:"emp_no"`
+}
+
+// TokenVerifier checks the signature, issuer, audience and expiry of bearer tokens.
+type TokenVerifier struct {
+	Keys     *KeySet
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// NewTokenVerifier loads the JWKS named by the configuration, it returns nil when bearer tokens are disabled.
+func NewTokenVerifier(cfg config.JWTConfig) (*TokenVerifier, error) {
+	var keys *KeySet
+	switch {
+	case cfg.JWKSFile != "":
+		var err error
+		if keys, err = LoadKeySetFile(cfg.JWKSFile); err != nil {
+			return nil, err
+		}
+	case cfg.JWKSURL != "":
+		keys = NewKeySetURL(cfg.JWKSURL, cfg.JWKSRefresh)
+	default:
+		return nil, nil
+	}
+
+	return &TokenVerifier{Keys: keys, Issuer: cfg.Issuer, Audience: cfg.Audience, Leeway: cfg.Leeway}, nil
+}
+
+// Verify returns the principal a valid token was issued to, or an error wrapping ErrInvalidToken.
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	claims := &tokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(parsed *jwt.Token) (interface{}, error) {
+		kid, _ := parsed.Header["kid"].(string)
+		key, err := v.Keys.lookup(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if parsed.Method.Alg() != key.alg {
+			return nil, fmt.Errorf("key %q does not sign %s tokens", kid, parsed.Method.Alg())
+		}
+		return key.key, nil
+	},
+		jwt.WithValidMethods([]string{"HS256", "RS256"}),
+		jwt.WithIssuer(v.Issuer),
+		jwt.WithAudience(v.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.Leeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+
+	principal := &Principal{
+		Subject:        "jwt:" + claims.Subject,
+		Name:           firstNonEmpty(claims.Name, claims.PreferredUsername, claims.Email, claims.Subject),
+		Method:         MethodJWT,
+		Roles:          claims.Roles,
+		EmployeeNumber: claims.EmployeeNumber,
+	}
+	for _, role := range claims.Roles {
+		if role == AdminRole {
+			principal.Admin = true
+		}
+	}
+
+	return principal, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
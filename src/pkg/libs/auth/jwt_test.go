@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"employee_exercise/src/pkg/libs/config"
+	"employee_exercise/src/pkg/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "employees"
+)
+
+var hmacSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestTokenVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	verifier, err := NewTokenVerifier(config.JWTConfig{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		JWKSFile: writeKeySet(t, rsaKey),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		token             string
+		expectedPrincipal *Principal
+		expectedError     string
+	}{
+		{
+			name:  "rs256",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims()),
+			expectedPrincipal: &Principal{
+				Subject: "jwt:alice", Name: "Alice Smith", Method: MethodJWT, Roles: []string{"hr"},
+			},
+		},
+		{
+			name: "hs256 admin",
+			token: sign(t, jwt.SigningMethodHS256, "hmac", hmacSecret, withClaim(validClaims(), func(c jwt.MapClaims) {
+				c["roles"] = []string{"hr", AdminRole}
+				c["emp_no"] = 110022
+				delete(c, "name")
+			})),
+			expectedPrincipal: &Principal{
+				Subject: "jwt:alice", Name: "alice@example.com", Method: MethodJWT, Admin: true, Roles: []string{"hr", AdminRole}, EmployeeNumber: 110022,
+			},
+		},
+		{
+			name:          "expired",
+			token:         sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, withClaim(validClaims(), func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() })),
+			expectedError: "token is expired",
+		},
+		{
+			name:          "without expiry",
+			token:         sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, withClaim(validClaims(), func(c jwt.MapClaims) { delete(c, "exp") })),
+			expectedError: "exp claim is required",
+		},
+		{
+			name:          "wrong issuer",
+			token:         sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, withClaim(validClaims(), func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" })),
+			expectedError: "token has invalid issuer",
+		},
+		{
+			name:          "wrong audience",
+			token:         sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, withClaim(validClaims(), func(c jwt.MapClaims) { c["aud"] = "payroll" })),
+			expectedError: "token has invalid audience",
+		},
+		{
+			name:          "missing subject",
+			token:         sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, withClaim(validClaims(), func(c jwt.MapClaims) { delete(c, "sub") })),
+			expectedError: "missing sub claim",
+		},
+		{
+			name:          "unknown key",
+			token:         sign(t, jwt.SigningMethodHS256, "rotated", hmacSecret, validClaims()),
+			expectedError: `unknown key "rotated"`,
+		},
+		{
+			name:          "algorithm of another key",
+			token:         sign(t, jwt.SigningMethodHS256, "rsa", hmacSecret, validClaims()),
+			expectedError: `key "rsa" does not sign HS256 tokens`,
+		},
+		{
+			name:          "unsigned",
+			token:         sign(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType, validClaims()),
+			expectedError: "signing method none is invalid",
+		},
+		{
+			name:          "forged signature",
+			token:         sign(t, jwt.SigningMethodHS256, "hmac", []byte("another secret"), validClaims()),
+			expectedError: "signature is invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), tt.token)
+
+			if tt.expectedError != "" {
+				assert.True(t, errors.Is(err, ErrInvalidToken))
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.Nil(t, principal)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedPrincipal, principal)
+		})
+	}
+}
+
+func TestKeySet_URL(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		w.Write(keySet(t, rsaKey))
+	}))
+	defer server.Close()
+
+	verifier, err := NewTokenVerifier(config.JWTConfig{
+		Issuer:      testIssuer,
+		Audience:    testAudience,
+		JWKSURL:     server.URL,
+		JWKSRefresh: time.Hour,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims()))
+		assert.NoError(t, err)
+	}
+
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rotated", rsaKey, validClaims()))
+	assert.ErrorContains(t, err, `unknown key "rotated"`)
+	assert.Equal(t, int32(1), downloads.Load())
+}
+
+func TestKeySet_URL_Refreshes_without_blocking_requests(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var downloads atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if downloads.Add(1) > 1 {
+			<-release
+		}
+		w.Write(keySet(t, rsaKey))
+	}))
+	defer server.Close()
+
+	keys := NewKeySetURL(server.URL, time.Hour)
+	_, err = keys.lookup(context.Background(), "rsa")
+	require.NoError(t, err)
+
+	keys.mu.Lock()
+	keys.fetched = time.Now().Add(-2 * time.Hour)
+	keys.mu.Unlock()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = keys.lookup(cancelled, "rotated")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = keys.lookup(context.Background(), "rsa")
+	assert.NoError(t, err, "a stale set is served while it refreshes")
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		keys.mu.Lock()
+		defer keys.mu.Unlock()
+		return time.Since(keys.fetched) < time.Minute && downloads.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = keys.lookup(context.Background(), "hmac")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), downloads.Load(), "the refreshes were deduplicated and the cancelled request did not abort them")
+}
+
+func TestNewTokenVerifier(t *testing.T) {
+	verifier, err := NewTokenVerifier(config.JWTConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, verifier)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys":[{"kid":"enc","kty":"oct","use":"enc","k":"c2VjcmV0"}]}`), 0o600))
+	_, err = NewTokenVerifier(config.JWTConfig{JWKSFile: path})
+	assert.ErrorContains(t, err, "no RS256 or HS256 signing keys")
+}
+
+func TestAuthenticator_MiddlewareBearer(t *testing.T) {
+	authenticator := New(&KeyAuthenticatorMock{keys: map[string]*models.APIKey{}}, "/healthz")
+	authenticator.Tokens = &TokenVerifier{Keys: &KeySet{keys: map[string]verificationKey{"hmac": {alg: "HS256", key: hmacSecret}}}, Issuer: testIssuer, Audience: testAudience}
+
+	tests := []struct {
+		name                 string
+		authorization        string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "valid token",
+			authorization:        "Bearer " + sign(t, jwt.SigningMethodHS256, "hmac", hmacSecret, validClaims()),
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "jwt:alice Alice Smith jwt false",
+		},
+		{
+			name:                 "invalid token",
+			authorization:        "Bearer " + sign(t, jwt.SigningMethodHS256, "hmac", []byte("another secret"), validClaims()),
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"unauthorized, invalid bearer token","instance":"/employees","code":"unauthorized"}`,
+		},
+		{
+			name:                 "missing credentials",
+			authorization:        "Basic YWxpY2U6c2VjcmV0",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"unauthorized, missing bearer token or api key","instance":"/employees","code":"unauthorized"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Use(authenticator.Middleware)
+			router.HandleFunc("/employees", writePrincipal)
+
+			request := httptest.NewRequest(http.MethodGet, "/employees", nil)
+			request.Header.Set("Authorization", tt.authorization)
+			recorder := httptest.NewRecorder()
+
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedResponseCode, recorder.Code)
+			assert.Equal(t, tt.expectedResponseBody, recorder.Body.String())
+			if tt.expectedResponseCode == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", recorder.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "alice",
+		"name":  "Alice Smith",
+		"email": "alice@example.com",
+		"roles": []string{"hr"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func withClaim(claims jwt.MapClaims, change func(jwt.MapClaims)) jwt.MapClaims {
+	change(claims)
+	return claims
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func keySet(t *testing.T, rsaKey *rsa.PrivateKey) []byte {
+	document := map[string][]map[string]string{"keys": {
+		{
+			"kid": "rsa",
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		},
+		{
+			"kid": "hmac",
+			"kty": "oct",
+			"alg": "HS256",
+			"k":   base64.RawURLEncoding.EncodeToString(hmacSecret),
+		},
+	}}
+
+	content, err := json.Marshal(document)
+	require.NoError(t, err)
+	return content
+}
+
+func writeKeySet(t *testing.T, rsaKey *rsa.PrivateKey) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, keySet(t, rsaKey), 0o600))
+	return path
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"employee_exercise/src/pkg/libs/problem"
+	"net/http"
+)
+
+type Permission string
+
+const (
+	PermissionReadEmployees           Permission = "read:employees"
+	PermissionReadDepartmentEmployees Permission = "read:department_employees"
+	PermissionWriteDepartments        Permission = "write:departments"
+	PermissionReadSalaries            Permission = "read:salaries"
+	PermissionReadReports             Permission = "read:reports"
+	PermissionReadDepartments         Permission = "read:departments"
+	PermissionManageAPIKeys           Permission = "manage:api_keys"
+	PermissionReadAudit               Permission = "read:audit"
+	PermissionReadPII                 Permission = "read:pii"
+	PermissionExportAnonymized        Permission = "export:anonymized"
+)
+
+const (
+	RoleAdmin   = AdminRole
+	RoleHRAdmin = "hr_admin"
+	RoleHR      = "hr"
+	RoleManager = "manager"
+	RoleAuditor = "auditor"
+	RoleAnalyst = "analyst"
+)
+
+// RolePermissions grants permissions to roles. Managers only see the employees of the departments they
+// currently manage, EmployeeService scopes their queries.
+var RolePermissions = map[string][]Permission{
+	RoleAdmin: {
+		PermissionReadEmployees, PermissionWriteDepartments, PermissionReadSalaries, PermissionReadReports,
+		PermissionReadDepartments, PermissionManageAPIKeys, PermissionReadAudit, PermissionReadPII,
+		PermissionExportAnonymized,
+	},
+	RoleHRAdmin: {
+		PermissionReadEmployees, PermissionWriteDepartments, PermissionReadSalaries, PermissionReadReports,
+		PermissionReadDepartments, PermissionReadPII, PermissionExportAnonymized,
+	},
+	RoleHR:      {PermissionReadEmployees, PermissionReadReports, PermissionReadDepartments},
+	RoleManager: {PermissionReadDepartmentEmployees, PermissionReadDepartments},
+	RoleAuditor: {PermissionReadAudit},
+	RoleAnalyst: {PermissionExportAnonymized},
+}
+
+// Can reports whether one of the principal's roles grants the permission.
+func (p *Principal) Can(permission Permission) bool {
+	if p == nil {
+		return false
+	}
+
+	roles := p.Roles
+	if p.Admin {
+		roles = append([]string{RoleAdmin}, roles...)
+	}
+
+	for _, role := range roles {
+		for _, granted := range RolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// RequirePermission only lets principals holding one of the permissions through.
+func RequirePermission(next http.HandlerFunc, anyOf ...Permission) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := PrincipalFromContext(r.Context())
+		for _, permission := range anyOf {
+			if principal.Can(permission) {
+				next(w, r)
+				return
+			}
+		}
+
+		problem.Write(w, r, problem.New(http.StatusForbidden, problem.CodeForbidden, "forbidden"))
+	}
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPrincipal_Can(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *Principal
+		permission Permission
+		expected   bool
+	}{
+		{name: "anonymous", principal: nil, permission: PermissionReadEmployees, expected: false},
+		{name: "admin flag", principal: &Principal{Admin: true}, permission: PermissionManageAPIKeys, expected: true},
+		{name: "hr admin transfers", principal: &Principal{Roles: []string{RoleHRAdmin}}, permission: PermissionWriteDepartments, expected: true},
+		{name: "hr admin does not manage keys", principal: &Principal{Roles: []string{RoleHRAdmin}}, permission: PermissionManageAPIKeys, expected: false},
+		{name: "hr does not see salaries", principal: &Principal{Roles: []string{RoleHR}}, permission: PermissionReadSalaries, expected: false},
+		{name: "manager sees department", principal: &Principal{Roles: []string{RoleManager}}, permission: PermissionReadDepartmentEmployees, expected: true},
+		{name: "manager does not see everyone", principal: &Principal{Roles: []string{RoleManager}}, permission: PermissionReadEmployees, expected: false},
+		{name: "roles add up", principal: &Principal{Roles: []string{RoleManager, RoleHR}}, permission: PermissionReadEmployees, expected: true},
+		{name: "auditor reads the audit log", principal: &Principal{Roles: []string{RoleAuditor}}, permission: PermissionReadAudit, expected: true},
+		{name: "hr admin does not read the audit log", principal: &Principal{Roles: []string{RoleHRAdmin}}, permission: PermissionReadAudit, expected: false},
+		{name: "unknown role", principal: &Principal{Roles: []string{"intern"}}, permission: PermissionReadDepartments, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.principal.Can(tt.permission))
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name                 string
+		principal            *Principal
+		expectedResponseCode int
+	}{
+		{name: "anonymous", expectedResponseCode: http.StatusForbidden},
+		{name: "first permission", principal: &Principal{Roles: []string{RoleHR}}, expectedResponseCode: http.StatusOK},
+		{name: "second permission", principal: &Principal{Roles: []string{RoleManager}}, expectedResponseCode: http.StatusOK},
+		{name: "neither permission", principal: &Principal{Roles: []string{"intern"}}, expectedResponseCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequirePermission(writePrincipal, PermissionReadEmployees, PermissionReadDepartmentEmployees)
+			request := httptest.NewRequest(http.MethodGet, "/employees", nil)
+			if tt.principal != nil {
+				request = request.WithContext(WithPrincipal(request.Context(), tt.principal))
+			}
+			recorder := httptest.NewRecorder()
+
+			handler(recorder, request)
+
+			assert.Equal(t, tt.expectedResponseCode, recorder.Code)
+		})
+	}
+}
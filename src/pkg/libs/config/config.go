@@ -0,0 +1,500 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	configFileEnv  = "CONFIG_FILE"
+	configFileFlag = "config"
+	redacted       = "****"
+)
+
+type ServerConfig struct {
+	Address         string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// GRPCConfig is the address of the gRPC server, which is disabled when it is empty.
+type GRPCConfig struct {
+	Address string
+}
+
+type MySQLConfig struct {
+	User                  string
+	Password              string
+	Host                  string
+	Port                  int
+	Database              string
+	MaxOpenConnections    int
+	MaxIdleConnections    int
+	MaxConnectionLifetime time.Duration
+	ConnectTimeout        time.Duration
+}
+
+type TracingConfig struct {
+	Exporter     string
+	OTLPEndpoint string
+}
+
+type RedisConfig struct {
+	Address  string
+	Password string
+	Database int
+}
+
+// JWTConfig enables bearer tokens when JWKSFile or JWKSURL is set.
+type JWTConfig struct {
+	Issuer      string
+	Audience    string
+	JWKSFile    string
+	JWKSURL     string
+	JWKSRefresh time.Duration
+	Leeway      time.Duration
+}
+
+// PIIConfig masks personal data for callers without the read:pii permission. Fields overrides the
+// action of the profile for single fields.
+type PIIConfig struct {
+	Profile string
+	Fields  map[string]string
+}
+
+// ExportConfig enables the anonymized export when Key is set. Key derives the pseudonyms, JitterDays
+// bounds how far dates are shifted.
+type ExportConfig struct {
+	Key        string
+	JitterDays int
+}
+
+// OpenAPIConfig controls how the OpenAPI document is enforced. Requests are always validated, ValidateResponses
+// validates responses too and is meant for tests and staging.
+type OpenAPIConfig struct {
+	ValidateResponses bool
+}
+
+// GraphQLConfig bounds the queries /graphql runs, 0 leaves a bound off. The depth is the deepest nesting of
+// fields, the complexity the number of fields a query may resolve, with lists counted at their limit.
+type GraphQLConfig struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+// RateQuota allows Rate requests per second per client with bursts of up to Burst requests.
+type RateQuota struct {
+	Rate  int
+	Burst int
+}
+
+type Config struct {
+	Server          ServerConfig
+	GRPC            GRPCConfig
+	MySQL           MySQLConfig
+	Tracing         TracingConfig
+	Log             LogConfig
+	JWT             JWTConfig
+	PII             PIIConfig
+	Export          ExportConfig
+	OpenAPI         OpenAPIConfig
+	GraphQL         GraphQLConfig
+	Redis           RedisConfig
+	RateLimit       int
+	RateLimitBurst  int
+	RateLimitRoutes map[string]RateQuota
+	RateLimitStore  string
+	DefaultPageSize int
+	ReportCacheTTL  time.Duration
+}
+
+type ValidationErrors []error
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, err := range v {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Default returns the settings used when no file, environment variable or flag overrides them.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Address:         ":80",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		GRPC: GRPCConfig{
+			Address: ":9090",
+		},
+		MySQL: MySQLConfig{
+			Port:                  3306,
+			MaxOpenConnections:    100,
+			MaxIdleConnections:    10,
+			MaxConnectionLifetime: time.Hour,
+			ConnectTimeout:        time.Minute,
+		},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			OTLPEndpoint: "http://localhost:4318",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		JWT: JWTConfig{
+			JWKSRefresh: time.Hour,
+			Leeway:      30 * time.Second,
+		},
+		PII: PIIConfig{
+			Profile: "standard",
+		},
+		Export: ExportConfig{
+			JitterDays: 30,
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      8,
+			MaxComplexity: 10000,
+		},
+		RateLimit:       100,
+		RateLimitStore:  "memory",
+		DefaultPageSize: 50,
+		ReportCacheTTL:  5 * time.Minute,
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence: defaults, the file named by
+// the -config flag or CONFIG_FILE, environment variables and command line flags. The result is validated.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	flagSet := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := flagSet.String(configFileFlag, "", "path to a YAML or JSON configuration file")
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.key] = flagSet.String(s.flag, "", fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errs ValidationErrors
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv(configFileEnv)
+	}
+
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range sortedKeys(values) {
+			s, ok := settingsByKey[key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, key))
+				continue
+			}
+			if err = s.apply(&cfg, values[key]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %v", path, key, err))
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok && value != "" {
+			if err := s.apply(&cfg, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", s.env, err))
+			}
+		}
+	}
+
+	flagSet.Visit(func(f *flag.Flag) {
+		if s, ok := settingsByFlag[f.Name]; ok {
+			if err := s.apply(&cfg, *flagValues[s.key]); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %v", f.Name, err))
+			}
+		}
+	})
+
+	errs = append(errs, cfg.Validate()...)
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
+	return &cfg, nil
+}
+
+func (c Config) Validate() ValidationErrors {
+	var errs ValidationErrors
+
+	required := map[string]string{
+		"MYSQL_USER":     c.MySQL.User,
+		"MYSQL_PASSWORD": c.MySQL.Password,
+		"MYSQL_HOST":     c.MySQL.Host,
+		"DB_NAME":        c.MySQL.Database,
+	}
+	for _, env := range []string{"MYSQL_USER", "MYSQL_PASSWORD", "MYSQL_HOST", "DB_NAME"} {
+		if required[env] == "" {
+			errs = append(errs, fmt.Errorf("%s: is required", env))
+		}
+	}
+
+	if c.Server.Address == "" {
+		errs = append(errs, fmt.Errorf("SERVER_ADDRESS: is required"))
+	}
+
+	timeouts := map[string]time.Duration{
+		"SERVER_READ_TIMEOUT":     c.Server.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT":    c.Server.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":     c.Server.IdleTimeout,
+		"SERVER_SHUTDOWN_TIMEOUT": c.Server.ShutdownTimeout,
+	}
+	for _, env := range []string{"SERVER_READ_TIMEOUT", "SERVER_WRITE_TIMEOUT", "SERVER_IDLE_TIMEOUT", "SERVER_SHUTDOWN_TIMEOUT"} {
+		if timeouts[env] <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be a positive duration, got %s", env, timeouts[env]))
+		}
+	}
+
+	if c.MySQL.Port < 1 || c.MySQL.Port > 65535 {
+		errs = append(errs, fmt.Errorf("MYSQL_PORT: must be between 1 and 65535, got %d", c.MySQL.Port))
+	}
+
+	if c.RateLimit < 1 {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT: must be a positive integer, got %d", c.RateLimit))
+	}
+
+	if c.RateLimitBurst < 0 {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_BURST: must not be negative, got %d", c.RateLimitBurst))
+	}
+
+	for _, route := range sortedQuotaRoutes(c.RateLimitRoutes) {
+		quota := c.RateLimitRoutes[route]
+		if !strings.HasPrefix(route, "/") || quota.Rate < 1 || quota.Burst < 0 {
+			errs = append(errs, fmt.Errorf("RATE_LIMIT_ROUTES: %s must be a route with a positive rate, got %s", route, formatRateQuota(quota)))
+		}
+	}
+
+	switch c.RateLimitStore {
+	case "memory":
+	case "redis":
+		if c.Redis.Address == "" {
+			errs = append(errs, fmt.Errorf("REDIS_ADDRESS: is required with the redis rate limit store"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE: must be memory or redis, got %q", c.RateLimitStore))
+	}
+
+	if c.MySQL.MaxOpenConnections < 1 {
+		errs = append(errs, fmt.Errorf("MYSQL_MAX_OPEN_CONNECTIONS: must be a positive integer, got %d", c.MySQL.MaxOpenConnections))
+	}
+
+	if c.MySQL.MaxIdleConnections < 0 || c.MySQL.MaxIdleConnections > c.MySQL.MaxOpenConnections {
+		errs = append(errs, fmt.Errorf("MYSQL_MAX_IDLE_CONNECTIONS: must be between 0 and MYSQL_MAX_OPEN_CONNECTIONS, got %d", c.MySQL.MaxIdleConnections))
+	}
+
+	if c.MySQL.MaxConnectionLifetime <= 0 {
+		errs = append(errs, fmt.Errorf("MYSQL_MAX_CONNECTION_LIFETIME: must be a positive duration, got %s", c.MySQL.MaxConnectionLifetime))
+	}
+
+	if c.MySQL.ConnectTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("MYSQL_CONNECT_TIMEOUT: must be a positive duration, got %s", c.MySQL.ConnectTimeout))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER: must be none, stdout or otlp, got %q", c.Tracing.Exporter))
+	}
+
+	if c.Tracing.Exporter == "otlp" && c.Tracing.OTLPEndpoint == "" {
+		errs = append(errs, fmt.Errorf("TRACING_OTLP_ENDPOINT: is required with the otlp exporter"))
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT: must be json or text, got %q", c.Log.Format))
+	}
+
+	if c.JWT.JWKSFile != "" && c.JWT.JWKSURL != "" {
+		errs = append(errs, fmt.Errorf("JWT_JWKS_URL: must not be set together with JWT_JWKS_FILE"))
+	}
+
+	if c.JWT.JWKSFile != "" || c.JWT.JWKSURL != "" {
+		if c.JWT.Issuer == "" {
+			errs = append(errs, fmt.Errorf("JWT_ISSUER: is required when bearer tokens are enabled"))
+		}
+		if c.JWT.Audience == "" {
+			errs = append(errs, fmt.Errorf("JWT_AUDIENCE: is required when bearer tokens are enabled"))
+		}
+	}
+
+	if c.JWT.JWKSURL != "" && !strings.HasPrefix(c.JWT.JWKSURL, "https://") && !strings.HasPrefix(c.JWT.JWKSURL, "http://") {
+		errs = append(errs, fmt.Errorf("JWT_JWKS_URL: must be an http or https URL, got %q", c.JWT.JWKSURL))
+	}
+
+	if c.JWT.JWKSRefresh <= 0 {
+		errs = append(errs, fmt.Errorf("JWT_JWKS_REFRESH: must be a positive duration, got %s", c.JWT.JWKSRefresh))
+	}
+
+	if c.JWT.Leeway < 0 {
+		errs = append(errs, fmt.Errorf("JWT_LEEWAY: must not be negative, got %s", c.JWT.Leeway))
+	}
+
+	switch c.PII.Profile {
+	case "none", "standard", "strict":
+	default:
+		errs = append(errs, fmt.Errorf("PII_PROFILE: must be none, standard or strict, got %q", c.PII.Profile))
+	}
+
+	for _, field := range sortedKeys(c.PII.Fields) {
+		switch c.PII.Fields[field] {
+		case "keep", "omit", "redact", "generalize":
+		default:
+			errs = append(errs, fmt.Errorf("PII_FIELDS: %s must be keep, omit, redact or generalize, got %q", field, c.PII.Fields[field]))
+		}
+	}
+
+	if c.Export.Key != "" && len(c.Export.Key) < 32 {
+		errs = append(errs, fmt.Errorf("EXPORT_KEY: must be at least 32 characters"))
+	}
+
+	if c.Export.JitterDays < 0 || c.Export.JitterDays > 365 {
+		errs = append(errs, fmt.Errorf("EXPORT_JITTER_DAYS: must be between 0 and 365, got %d", c.Export.JitterDays))
+	}
+
+	if c.GraphQL.MaxDepth < 0 {
+		errs = append(errs, fmt.Errorf("GRAPHQL_MAX_DEPTH: must not be negative, got %d", c.GraphQL.MaxDepth))
+	}
+
+	if c.GraphQL.MaxComplexity < 0 {
+		errs = append(errs, fmt.Errorf("GRAPHQL_MAX_COMPLEXITY: must not be negative, got %d", c.GraphQL.MaxComplexity))
+	}
+
+	if c.DefaultPageSize < 1 || c.DefaultPageSize > 1000 {
+		errs = append(errs, fmt.Errorf("DEFAULT_PAGE_SIZE: must be between 1 and 1000, got %d", c.DefaultPageSize))
+	}
+
+	if c.ReportCacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("REPORT_CACHE_TTL: must not be negative, got %s", c.ReportCacheTTL))
+	}
+
+	return errs
+}
+
+// Redacted renders the effective configuration one "key=value" per line with secrets masked.
+func (c Config) Redacted() string {
+	lines := make([]string, 0, len(settings))
+	for _, s := range settings {
+		value := s.get(&c)
+		if s.secret && value != "" {
+			value = redacted
+		}
+		lines = append(lines, fmt.Sprintf("%s=%s", s.key, value))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var document map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &document)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &document)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension: %s", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", document, values)
+
+	return values, nil
+}
+
+func flatten(prefix string, document map[string]interface{}, values map[string]string) {
+	for key, value := range document {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(key, nested, values)
+			continue
+		}
+
+		values[key] = fmt.Sprint(value)
+	}
+}
+
+// Quota returns the quota for a route template, falling back to RATE_LIMIT and RATE_LIMIT_BURST.
+// A zero burst means one second worth of requests.
+func (c Config) Quota(route string) RateQuota {
+	quota, ok := c.RateLimitRoutes[route]
+	if !ok {
+		quota = RateQuota{Rate: c.RateLimit, Burst: c.RateLimitBurst}
+	}
+
+	if quota.Burst == 0 {
+		quota.Burst = quota.Rate
+	}
+
+	return quota
+}
+
+func sortedQuotaRoutes(quotas map[string]RateQuota) []string {
+	routes := make([]string, 0, len(quotas))
+	for route := range quotas {
+		routes = append(routes, route)
+	}
+
+	sort.Strings(routes)
+	return routes
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
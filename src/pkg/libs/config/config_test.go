@@ -0,0 +1,331 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad_Uses_defaults_and_environment(t *testing.T) {
+	cfg, err := Load(nil, mockEnv(requiredEnv()))
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{
+		Server: ServerConfig{
+			Address:         ":80",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		GRPC: GRPCConfig{
+			Address: ":9090",
+		},
+		MySQL: MySQLConfig{
+			User:                  "root",
+			Password:              "secret",
+			Host:                  "database",
+			Port:                  3306,
+			Database:              "employees",
+			MaxOpenConnections:    100,
+			MaxIdleConnections:    10,
+			MaxConnectionLifetime: time.Hour,
+			ConnectTimeout:        time.Minute,
+		},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			OTLPEndpoint: "http://localhost:4318",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		JWT: JWTConfig{
+			JWKSRefresh: time.Hour,
+			Leeway:      30 * time.Second,
+		},
+		PII: PIIConfig{
+			Profile: "standard",
+		},
+		Export: ExportConfig{
+			JitterDays: 30,
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      8,
+			MaxComplexity: 10000,
+		},
+		RateLimit:       100,
+		RateLimitStore:  "memory",
+		DefaultPageSize: 50,
+		ReportCacheTTL:  5 * time.Minute,
+	}, cfg)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  address: ":8080"
+mysql:
+  host: file-host
+  max_open_connections: 20
+  max_connection_lifetime: 30m
+rate_limit: 10
+default_page_size: 25
+`)
+	env := requiredEnv()
+	env["CONFIG_FILE"] = path
+	env["RATE_LIMIT"] = "50"
+	delete(env, "MYSQL_HOST")
+
+	cfg, err := Load([]string{"-rate-limit", "75", "-server-address=:9090"}, mockEnv(env))
+
+	assert.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.Server.Address)
+	assert.Equal(t, "file-host", cfg.MySQL.Host)
+	assert.Equal(t, 20, cfg.MySQL.MaxOpenConnections)
+	assert.Equal(t, 30*time.Minute, cfg.MySQL.MaxConnectionLifetime)
+	assert.Equal(t, 75, cfg.RateLimit)
+	assert.Equal(t, 25, cfg.DefaultPageSize)
+}
+
+func TestLoad_Reads_json_file_from_flag(t *testing.T) {
+	path := writeFile(t, "config.json", `{"mysql": {"port": 3307}, "report_cache_ttl": "0s", "openapi": {"validate_responses": true}}`)
+
+	cfg, err := Load([]string{"-config", path}, mockEnv(requiredEnv()))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3307, cfg.MySQL.Port)
+	assert.Equal(t, time.Duration(0), cfg.ReportCacheTTL)
+	assert.True(t, cfg.OpenAPI.ValidateResponses)
+}
+
+func TestLoad_Fails(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		env           map[string]string
+		file          string
+		expectedError string
+	}{
+		{
+			name:          "missing required settings",
+			env:           map[string]string{},
+			expectedError: "MYSQL_USER: is required; MYSQL_PASSWORD: is required; MYSQL_HOST: is required; DB_NAME: is required",
+		},
+		{
+			name:          "rate limit is not a number",
+			env:           withEnv("RATE_LIMIT", "fast"),
+			expectedError: `RATE_LIMIT: must be an integer, got "fast"`,
+		},
+		{
+			name:          "rate limit is not positive",
+			args:          []string{"-rate-limit", "-5"},
+			env:           requiredEnv(),
+			expectedError: "RATE_LIMIT: must be a positive integer, got -5",
+		},
+		{
+			name:          "invalid duration and pool sizes",
+			env:           withEnv("MYSQL_MAX_CONNECTION_LIFETIME", "forever"),
+			args:          []string{"-mysql-max-idle-connections", "500"},
+			expectedError: `MYSQL_MAX_CONNECTION_LIFETIME: must be a duration such as 30s or 1h, got "forever"; MYSQL_MAX_IDLE_CONNECTIONS: must be between 0 and MYSQL_MAX_OPEN_CONNECTIONS, got 500`,
+		},
+		{
+			name:          "unknown setting in file",
+			env:           requiredEnv(),
+			file:          "mysql:\n  colour: blue\n",
+			expectedError: `unknown setting "mysql.colour"`,
+		},
+		{
+			name:          "shutdown timeout is not positive",
+			env:           withEnv("SERVER_SHUTDOWN_TIMEOUT", "0s"),
+			expectedError: "SERVER_SHUTDOWN_TIMEOUT: must be a positive duration, got 0s",
+		},
+		{
+			name:          "response validation is not a boolean",
+			env:           withEnv("OPENAPI_VALIDATE_RESPONSES", "sometimes"),
+			expectedError: `OPENAPI_VALIDATE_RESPONSES: must be true or false, got "sometimes"`,
+		},
+		{
+			name:          "unknown tracing exporter",
+			env:           withEnv("TRACING_EXPORTER", "jaeger"),
+			expectedError: `TRACING_EXPORTER: must be none, stdout or otlp, got "jaeger"`,
+		},
+		{
+			name:          "malformed route quota",
+			env:           withEnv("RATE_LIMIT_ROUTES", "/employees=fast"),
+			expectedError: `RATE_LIMIT_ROUTES: rate of /employees must be an integer, got "fast"`,
+		},
+		{
+			name:          "route quota without rate",
+			args:          []string{"-rate-limit-routes", "/employees=0,reports=5/-1"},
+			env:           requiredEnv(),
+			expectedError: "RATE_LIMIT_ROUTES: /employees must be a route with a positive rate, got 0; RATE_LIMIT_ROUTES: reports must be a route with a positive rate, got 5/-1",
+		},
+		{
+			name:          "redis store without address",
+			env:           withEnv("RATE_LIMIT_STORE", "redis"),
+			expectedError: "REDIS_ADDRESS: is required with the redis rate limit store",
+		},
+		{
+			name:          "unknown log level and format",
+			env:           withEnv("LOG_LEVEL", "verbose"),
+			args:          []string{"-log-format", "xml"},
+			expectedError: `LOG_LEVEL: must be debug, info, warn or error, got "verbose"; LOG_FORMAT: must be json or text, got "xml"`,
+		},
+		{
+			name:          "jwks without issuer and audience",
+			env:           withEnv("JWT_JWKS_URL", "https://sso.example.com/jwks.json"),
+			expectedError: "JWT_ISSUER: is required when bearer tokens are enabled; JWT_AUDIENCE: is required when bearer tokens are enabled",
+		},
+		{
+			name:          "jwks file and url",
+			env:           withEnv("JWT_JWKS_FILE", "/etc/jwks.json"),
+			args:          []string{"-jwt-jwks-url", "sso.example.com/jwks.json", "-jwt-issuer", "https://sso.example.com", "-jwt-audience", "employees"},
+			expectedError: `JWT_JWKS_URL: must not be set together with JWT_JWKS_FILE; JWT_JWKS_URL: must be an http or https URL, got "sso.example.com/jwks.json"`,
+		},
+		{
+			name:          "unknown pii profile and action",
+			env:           withEnv("PII_PROFILE", "paranoid"),
+			args:          []string{"-pii-fields", "gender=hash,salary=omit"},
+			expectedError: `PII_PROFILE: must be none, standard or strict, got "paranoid"; PII_FIELDS: gender must be keep, omit, redact or generalize, got "hash"`,
+		},
+		{
+			name:          "malformed pii fields",
+			env:           withEnv("PII_FIELDS", "gender"),
+			expectedError: `PII_FIELDS: must be name=value entries, got "gender"`,
+		},
+		{
+			name:          "short export key and jitter out of range",
+			env:           withEnv("EXPORT_KEY", "hunter2"),
+			args:          []string{"-export-jitter-days", "-1"},
+			expectedError: "EXPORT_KEY: must be at least 32 characters; EXPORT_JITTER_DAYS: must be between 0 and 365, got -1",
+		},
+		{
+			name:          "negative graphql limits",
+			env:           withEnv("GRAPHQL_MAX_DEPTH", "-1"),
+			args:          []string{"-graphql-max-complexity", "-10"},
+			expectedError: "GRAPHQL_MAX_DEPTH: must not be negative, got -1; GRAPHQL_MAX_COMPLEXITY: must not be negative, got -10",
+		},
+		{
+			name:          "page size out of range",
+			env:           withEnv("DEFAULT_PAGE_SIZE", "5000"),
+			expectedError: "DEFAULT_PAGE_SIZE: must be between 1 and 1000, got 5000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append(args, "-config", writeFile(t, "config.yml", tt.file))
+			}
+
+			cfg, err := Load(args, mockEnv(tt.env))
+
+			assert.Nil(t, cfg)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestLoad_Fails_with_unsupported_file_extension(t *testing.T) {
+	cfg, err := Load([]string{"-config", writeFile(t, "config.toml", "")}, mockEnv(requiredEnv()))
+
+	assert.Nil(t, cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported config file extension")
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	env := withEnv("RATE_LIMIT_ROUTES", "/reports/salaries=10, /reports/headcount=2/5")
+	env["RATE_LIMIT_STORE"] = "redis"
+	env["REDIS_ADDRESS"] = "redis:6379"
+	env["REDIS_PASSWORD"] = "hunter2"
+	env["PII_FIELDS"] = "gender=keep, birth_date=omit"
+	env["EXPORT_KEY"] = "0123456789abcdef0123456789abcdef"
+	cfg, err := Load(nil, mockEnv(env))
+	assert.NoError(t, err)
+
+	assert.Equal(t, `server.address=:80
+server.read_timeout=15s
+server.write_timeout=30s
+server.idle_timeout=2m0s
+server.shutdown_timeout=30s
+grpc.address=:9090
+mysql.user=root
+mysql.password=****
+mysql.host=database
+mysql.port=3306
+mysql.database=employees
+mysql.max_open_connections=100
+mysql.max_idle_connections=10
+mysql.max_connection_lifetime=1h0m0s
+mysql.connect_timeout=1m0s
+tracing.exporter=none
+tracing.otlp_endpoint=http://localhost:4318
+log.level=info
+log.format=json
+jwt.issuer=
+jwt.audience=
+jwt.jwks_file=
+jwt.jwks_url=
+jwt.jwks_refresh=1h0m0s
+jwt.leeway=30s
+pii.profile=standard
+pii.fields=birth_date=omit,gender=keep
+export.key=****
+export.jitter_days=30
+openapi.validate_responses=false
+graphql.max_depth=8
+graphql.max_complexity=10000
+rate_limit=100
+rate_limit_burst=0
+rate_limit_routes=/reports/headcount=2/5,/reports/salaries=10
+rate_limit_store=redis
+redis.address=redis:6379
+redis.password=****
+redis.database=0
+default_page_size=50
+report_cache_ttl=5m0s`, cfg.Redacted())
+}
+
+func TestConfig_Quota(t *testing.T) {
+	cfg, err := Load([]string{"-rate-limit-routes", "/reports/headcount=2/5,/reports/salaries=10"}, mockEnv(withEnv("RATE_LIMIT_BURST", "150")))
+	assert.NoError(t, err)
+
+	assert.Equal(t, RateQuota{Rate: 2, Burst: 5}, cfg.Quota("/reports/headcount"))
+	assert.Equal(t, RateQuota{Rate: 10, Burst: 10}, cfg.Quota("/reports/salaries"))
+	assert.Equal(t, RateQuota{Rate: 100, Burst: 150}, cfg.Quota("/employees"))
+}
+
+func requiredEnv() map[string]string {
+	return map[string]string{
+		"MYSQL_USER":     "root",
+		"MYSQL_PASSWORD": "secret",
+		"MYSQL_HOST":     "database",
+		"DB_NAME":        "employees",
+	}
+}
+
+func withEnv(key, value string) map[string]string {
+	env := requiredEnv()
+	env[key] = value
+	return env
+}
+
+func mockEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("an error '%s' was not expected when writing the config file", err)
+	}
+	return path
+}
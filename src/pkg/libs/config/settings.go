@@ -0,0 +1,286 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type setting struct {
+	key    string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	apply  func(c *Config, value string) error
+	get    func(c *Config) string
+}
+
+var settings = []setting{
+	stringSetting("server.address", "SERVER_ADDRESS", "server-address", "address the HTTP server listens on",
+		func(c *Config) *string { return &c.Server.Address }),
+	durationSetting("server.read_timeout", "SERVER_READ_TIMEOUT", "server-read-timeout", "maximum duration for reading a request",
+		func(c *Config) *time.Duration { return &c.Server.ReadTimeout }),
+	durationSetting("server.write_timeout", "SERVER_WRITE_TIMEOUT", "server-write-timeout", "maximum duration before timing out writing a response",
+		func(c *Config) *time.Duration { return &c.Server.WriteTimeout }),
+	durationSetting("server.idle_timeout", "SERVER_IDLE_TIMEOUT", "server-idle-timeout", "how long keep-alive connections stay idle",
+		func(c *Config) *time.Duration { return &c.Server.IdleTimeout }),
+	durationSetting("server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT", "server-shutdown-timeout", "how long in-flight requests may take to drain on shutdown",
+		func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout }),
+	stringSetting("grpc.address", "GRPC_ADDRESS", "grpc-address", "address the gRPC server listens on, empty disables it",
+		func(c *Config) *string { return &c.GRPC.Address }),
+	stringSetting("mysql.user", "MYSQL_USER", "mysql-user", "MySQL user",
+		func(c *Config) *string { return &c.MySQL.User }),
+	secretSetting("mysql.password", "MYSQL_PASSWORD", "mysql-password", "MySQL password",
+		func(c *Config) *string { return &c.MySQL.Password }),
+	stringSetting("mysql.host", "MYSQL_HOST", "mysql-host", "MySQL host",
+		func(c *Config) *string { return &c.MySQL.Host }),
+	intSetting("mysql.port", "MYSQL_PORT", "mysql-port", "MySQL port",
+		func(c *Config) *int { return &c.MySQL.Port }),
+	stringSetting("mysql.database", "DB_NAME", "db-name", "MySQL database name",
+		func(c *Config) *string { return &c.MySQL.Database }),
+	intSetting("mysql.max_open_connections", "MYSQL_MAX_OPEN_CONNECTIONS", "mysql-max-open-connections", "maximum open connections in the pool",
+		func(c *Config) *int { return &c.MySQL.MaxOpenConnections }),
+	intSetting("mysql.max_idle_connections", "MYSQL_MAX_IDLE_CONNECTIONS", "mysql-max-idle-connections", "maximum idle connections in the pool",
+		func(c *Config) *int { return &c.MySQL.MaxIdleConnections }),
+	durationSetting("mysql.max_connection_lifetime", "MYSQL_MAX_CONNECTION_LIFETIME", "mysql-max-connection-lifetime", "maximum lifetime of a pooled connection",
+		func(c *Config) *time.Duration { return &c.MySQL.MaxConnectionLifetime }),
+	durationSetting("mysql.connect_timeout", "MYSQL_CONNECT_TIMEOUT", "mysql-connect-timeout", "how long startup waits for the database to answer",
+		func(c *Config) *time.Duration { return &c.MySQL.ConnectTimeout }),
+	stringSetting("tracing.exporter", "TRACING_EXPORTER", "tracing-exporter", "where spans are exported: none, stdout or otlp",
+		func(c *Config) *string { return &c.Tracing.Exporter }),
+	stringSetting("tracing.otlp_endpoint", "TRACING_OTLP_ENDPOINT", "tracing-otlp-endpoint", "OTLP/HTTP collector URL",
+		func(c *Config) *string { return &c.Tracing.OTLPEndpoint }),
+	stringSetting("log.level", "LOG_LEVEL", "log-level", "minimum log level: debug, info, warn or error",
+		func(c *Config) *string { return &c.Log.Level }),
+	stringSetting("log.format", "LOG_FORMAT", "log-format", "log output format: json or text",
+		func(c *Config) *string { return &c.Log.Format }),
+	stringSetting("jwt.issuer", "JWT_ISSUER", "jwt-issuer", "required iss claim of bearer tokens",
+		func(c *Config) *string { return &c.JWT.Issuer }),
+	stringSetting("jwt.audience", "JWT_AUDIENCE", "jwt-audience", "required aud claim of bearer tokens",
+		func(c *Config) *string { return &c.JWT.Audience }),
+	stringSetting("jwt.jwks_file", "JWT_JWKS_FILE", "jwt-jwks-file", "local JWKS file with the token signing keys",
+		func(c *Config) *string { return &c.JWT.JWKSFile }),
+	stringSetting("jwt.jwks_url", "JWT_JWKS_URL", "jwt-jwks-url", "URL of the JWKS with the token signing keys",
+		func(c *Config) *string { return &c.JWT.JWKSURL }),
+	durationSetting("jwt.jwks_refresh", "JWT_JWKS_REFRESH", "jwt-jwks-refresh", "how often the JWKS URL is downloaded again",
+		func(c *Config) *time.Duration { return &c.JWT.JWKSRefresh }),
+	durationSetting("jwt.leeway", "JWT_LEEWAY", "jwt-leeway", "clock skew tolerated on token expiry",
+		func(c *Config) *time.Duration { return &c.JWT.Leeway }),
+	stringSetting("pii.profile", "PII_PROFILE", "pii-profile", "personal data masking for callers without read:pii: none, standard or strict",
+		func(c *Config) *string { return &c.PII.Profile }),
+	stringMapSetting("pii.fields", "PII_FIELDS", "pii-fields", "per field masking as field=keep|omit|redact|generalize, comma separated",
+		func(c *Config) *map[string]string { return &c.PII.Fields }),
+	secretSetting("export.key", "EXPORT_KEY", "export-key", "secret the anonymized export derives pseudonyms from, unset disables it",
+		func(c *Config) *string { return &c.Export.Key }),
+	intSetting("export.jitter_days", "EXPORT_JITTER_DAYS", "export-jitter-days", "maximum days the anonymized export shifts dates by",
+		func(c *Config) *int { return &c.Export.JitterDays }),
+	boolSetting("openapi.validate_responses", "OPENAPI_VALIDATE_RESPONSES", "openapi-validate-responses", "validate responses against the OpenAPI document, for tests and staging",
+		func(c *Config) *bool { return &c.OpenAPI.ValidateResponses }),
+	intSetting("graphql.max_depth", "GRAPHQL_MAX_DEPTH", "graphql-max-depth", "deepest nesting of fields a GraphQL query may have, 0 disables the limit",
+		func(c *Config) *int { return &c.GraphQL.MaxDepth }),
+	intSetting("graphql.max_complexity", "GRAPHQL_MAX_COMPLEXITY", "graphql-max-complexity", "most fields a GraphQL query may resolve, 0 disables the limit",
+		func(c *Config) *int { return &c.GraphQL.MaxComplexity }),
+	intSetting("rate_limit", "RATE_LIMIT", "rate-limit", "requests per second",
+		func(c *Config) *int { return &c.RateLimit }),
+	intSetting("rate_limit_burst", "RATE_LIMIT_BURST", "rate-limit-burst", "requests a client may burst above the rate, 0 means one second worth",
+		func(c *Config) *int { return &c.RateLimitBurst }),
+	rateQuotasSetting("rate_limit_routes", "RATE_LIMIT_ROUTES", "rate-limit-routes", "per route quotas as route=rate[/burst], comma separated",
+		func(c *Config) *map[string]RateQuota { return &c.RateLimitRoutes }),
+	stringSetting("rate_limit_store", "RATE_LIMIT_STORE", "rate-limit-store", "where rate limit buckets live: memory or redis",
+		func(c *Config) *string { return &c.RateLimitStore }),
+	stringSetting("redis.address", "REDIS_ADDRESS", "redis-address", "Redis host:port for the redis rate limit store",
+		func(c *Config) *string { return &c.Redis.Address }),
+	secretSetting("redis.password", "REDIS_PASSWORD", "redis-password", "Redis password",
+		func(c *Config) *string { return &c.Redis.Password }),
+	intSetting("redis.database", "REDIS_DATABASE", "redis-database", "Redis database number",
+		func(c *Config) *int { return &c.Redis.Database }),
+	intSetting("default_page_size", "DEFAULT_PAGE_SIZE", "default-page-size", "page size when the limit parameter is missing",
+		func(c *Config) *int { return &c.DefaultPageSize }),
+	durationSetting("report_cache_ttl", "REPORT_CACHE_TTL", "report-cache-ttl", "how long report results are cached, 0 disables the cache",
+		func(c *Config) *time.Duration { return &c.ReportCacheTTL }),
+}
+
+var (
+	settingsByKey  = make(map[string]setting, len(settings))
+	settingsByFlag = make(map[string]setting, len(settings))
+)
+
+func init() {
+	for _, s := range settings {
+		settingsByKey[s.key] = s
+		settingsByFlag[s.flag] = s
+	}
+}
+
+func stringSetting(key, env, flag, usage string, field func(*Config) *string) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		flag:  flag,
+		usage: usage,
+		apply: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+		get: func(c *Config) string { return *field(c) },
+	}
+}
+
+func secretSetting(key, env, flag, usage string, field func(*Config) *string) setting {
+	s := stringSetting(key, env, flag, usage, field)
+	s.secret = true
+	return s
+}
+
+func intSetting(key, env, flag, usage string, field func(*Config) *int) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		flag:  flag,
+		usage: usage,
+		apply: func(c *Config, value string) error {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("must be an integer, got %q", value)
+			}
+			*field(c) = parsed
+			return nil
+		},
+		get: func(c *Config) string { return strconv.Itoa(*field(c)) },
+	}
+}
+
+func boolSetting(key, env, flag, usage string, field func(*Config) *bool) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		flag:  flag,
+		usage: usage,
+		apply: func(c *Config, value string) error {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("must be true or false, got %q", value)
+			}
+			*field(c) = parsed
+			return nil
+		},
+		get: func(c *Config) string { return strconv.FormatBool(*field(c)) },
+	}
+}
+
+func durationSetting(key, env, flag, usage string, field func(*Config) *time.Duration) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		flag:  flag,
+		usage: usage,
+		apply: func(c *Config, value string) error {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("must be a duration such as 30s or 1h, got %q", value)
+			}
+			*field(c) = parsed
+			return nil
+		},
+		get: func(c *Config) string { return field(c).String() },
+	}
+}
+
+func stringMapSetting(key, env, flag, usage string, field func(*Config) *map[string]string) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		flag:  flag,
+		usage: usage,
+		apply: func(c *Config, value string) error {
+			values := make(map[string]string)
+			for _, entry := range strings.Split(value, ",") {
+				entry = strings.TrimSpace(entry)
+				if entry == "" {
+					continue
+				}
+
+				name, entryValue, found := strings.Cut(entry, "=")
+				if !found {
+					return fmt.Errorf("must be name=value entries, got %q", entry)
+				}
+				values[strings.TrimSpace(name)] = strings.TrimSpace(entryValue)
+			}
+			*field(c) = values
+			return nil
+		},
+		get: func(c *Config) string {
+			values := *field(c)
+			entries := make([]string, 0, len(values))
+			for _, name := range sortedKeys(values) {
+				entries = append(entries, name+"="+values[name])
+			}
+			return strings.Join(entries, ",")
+		},
+	}
+}
+
+func rateQuotasSetting(key, env, flag, usage string, field func(*Config) *map[string]RateQuota) setting {
+	return setting{
+		key:   key,
+		env:   env,
+		flag:  flag,
+		usage: usage,
+		apply: func(c *Config, value string) error {
+			quotas, err := parseRateQuotas(value)
+			if err != nil {
+				return err
+			}
+			*field(c) = quotas
+			return nil
+		},
+		get: func(c *Config) string {
+			quotas := *field(c)
+			entries := make([]string, 0, len(quotas))
+			for _, route := range sortedQuotaRoutes(quotas) {
+				entries = append(entries, route+"="+formatRateQuota(quotas[route]))
+			}
+			return strings.Join(entries, ",")
+		},
+	}
+}
+
+func parseRateQuotas(value string) (map[string]RateQuota, error) {
+	quotas := make(map[string]RateQuota)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, limits, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("must be route=rate[/burst] entries, got %q", entry)
+		}
+
+		rate, burst, hasBurst := strings.Cut(limits, "/")
+		quota := RateQuota{}
+		var err error
+		if quota.Rate, err = strconv.Atoi(strings.TrimSpace(rate)); err != nil {
+			return nil, fmt.Errorf("rate of %s must be an integer, got %q", route, rate)
+		}
+		if hasBurst {
+			if quota.Burst, err = strconv.Atoi(strings.TrimSpace(burst)); err != nil {
+				return nil, fmt.Errorf("burst of %s must be an integer, got %q", route, burst)
+			}
+		}
+
+		quotas[strings.TrimSpace(route)] = quota
+	}
+
+	return quotas, nil
+}
+
+func formatRateQuota(quota RateQuota) string {
+	if quota.Burst == 0 {
+		return strconv.Itoa(quota.Rate)
+	}
+	return fmt.Sprintf("%d/%d", quota.Rate, quota.Burst)
+}
@@ -1,42 +1,76 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"employee_exercise/src/pkg/libs/config"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
-	"log"
+	"log/slog"
 	"os"
 	"sync"
 	"time"
 )
 
-var once sync.Once
+var (
+	once     sync.Once
+	database *sql.DB
+)
 
-func GetDbEngine() *sql.DB {
-	var database *sql.DB
+func GetDbEngine(cfg config.MySQLConfig) *sql.DB {
 	once.Do(func() {
 		var err error
-		db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8&parseTime=True",
-			os.Getenv("MYSQL_USER"), os.Getenv("MYSQL_PASSWORD"), os.Getenv("MYSQL_HOST"),
-			os.Getenv("MYSQL_PORT"), os.Getenv("DB_NAME")))
+		db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=True",
+			cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Database))
 		if err != nil {
-			log.Fatal(fmt.Sprintf("Could not connect to MySQL Instance: %v", err))
+			slog.Error("could not connect to MySQL instance", "error", err)
+			os.Exit(1)
 		}
 
-		maxOpenConnections := 100
-		maxIdleConnections := 10
-		maxConnectionLifetime := time.Hour
-
-		log.Println(fmt.Sprintf("mysql - settings max connection lifetime to: %v", maxConnectionLifetime))
-		log.Println(fmt.Sprintf("mysql - settings max open idle connections to: %v", maxIdleConnections))
-		log.Println(fmt.Sprintf("mysql - settings max open connections to: %v", maxOpenConnections))
+		slog.Info("mysql connection pool configured",
+			"max_connection_lifetime", cfg.MaxConnectionLifetime,
+			"max_idle_connections", cfg.MaxIdleConnections,
+			"max_open_connections", cfg.MaxOpenConnections,
+		)
 
-		db.SetConnMaxLifetime(maxConnectionLifetime)
-		db.SetMaxIdleConns(maxIdleConnections)
-		db.SetMaxOpenConns(maxOpenConnections)
+		db.SetConnMaxLifetime(cfg.MaxConnectionLifetime)
+		db.SetMaxIdleConns(cfg.MaxIdleConnections)
+		db.SetMaxOpenConns(cfg.MaxOpenConnections)
 
 		database = db
 	})
 
 	return database
 }
+
+const (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// WaitForDatabase pings the database with exponential backoff until it answers or the timeout expires.
+func WaitForDatabase(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		slog.WarnContext(ctx, "database not ready, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not ready after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
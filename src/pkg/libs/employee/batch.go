@@ -0,0 +1,228 @@
+package employee
+
+import (
+	"context"
+	"database/sql"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/tracing"
+	"employee_exercise/src/pkg/models"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// The batched queries take the keys of many rows at once, so a GraphQL query listing employees reads the
+// related rows of the whole page in one statement rather than one per employee.
+const (
+	employeesByNumberQuery = "SELECT e.emp_no, e.birth_date, e.first_name, e.last_name, e.gender, e.hire_date FROM employees e " +
+		"WHERE e.emp_no IN (%s)%s"
+	currentDepartmentsQuery = "SELECT de.emp_no, d.dept_no, d.dept_name FROM dept_emp de JOIN departments d ON de.dept_no = d.dept_no " +
+		"WHERE de.emp_no IN (%s) AND de.from_date <= ? AND de.to_date > ?%s ORDER BY de.from_date DESC"
+	employeeManagersQuery = "SELECT de.emp_no, e.emp_no, e.birth_date, e.first_name, e.last_name, e.gender, e.hire_date FROM dept_emp de " +
+		"JOIN dept_manager dm ON de.dept_no = dm.dept_no JOIN employees e ON dm.emp_no = e.emp_no " +
+		"WHERE de.emp_no IN (%s) AND de.from_date <= ? AND de.to_date > ? AND dm.from_date <= ? AND dm.to_date > ?%s ORDER BY dm.from_date DESC"
+	departmentManagersQuery = "SELECT dm.dept_no, e.emp_no, e.birth_date, e.first_name, e.last_name, e.gender, e.hire_date FROM dept_manager dm " +
+		"JOIN employees e ON dm.emp_no = e.emp_no WHERE dm.dept_no IN (%s) AND dm.from_date <= ? AND dm.to_date > ?%s ORDER BY dm.from_date DESC"
+	titlesQuery   = "SELECT t.emp_no, t.title, t.from_date, t.to_date FROM titles t WHERE t.emp_no IN (%s)%s ORDER BY t.emp_no, t.from_date"
+	salariesQuery = "SELECT s.emp_no, s.salary, s.from_date, s.to_date FROM salaries s WHERE s.emp_no IN (%s)%s ORDER BY s.emp_no, s.from_date"
+)
+
+// EmployeesByNumber returns the employees among numbers the caller may see, by number.
+func (e *EmployeeService) EmployeesByNumber(ctx context.Context, numbers []int) (map[int]models.Employee, error) {
+	employees := map[int]models.Employee{}
+	err := e.batch(ctx, "employeesByNumber", employeesByNumberQuery, "e.emp_no", batchKeys(numbers), nil, func(rows *sql.Rows) error {
+		var employee models.Employee
+		if err := scanEmployee(rows, &employee); err != nil {
+			return err
+		}
+		employees[employee.EmployeeNumber] = employee
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return employees, nil
+}
+
+// CurrentDepartments returns the department each of the employees the caller may see belongs to today, by
+// employee number. Employees outside any department are missing.
+func (e *EmployeeService) CurrentDepartments(ctx context.Context, numbers []int) (map[int]models.Department, error) {
+	departments := map[int]models.Department{}
+	today := e.today()
+	err := e.batch(ctx, "currentDepartments", currentDepartmentsQuery, "de.emp_no", batchKeys(numbers), []interface{}{today, today}, func(rows *sql.Rows) error {
+		var employeeNumber int
+		var department models.Department
+		if err := rows.Scan(&employeeNumber, &department.DepartmentNumber, &department.DepartmentName); err != nil {
+			return err
+		}
+		if _, ok := departments[employeeNumber]; !ok {
+			departments[employeeNumber] = department
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return departments, nil
+}
+
+// EmployeeManagers returns the manager of the department each employee belongs to today, by employee number.
+// Managers the caller may not see are missing.
+func (e *EmployeeService) EmployeeManagers(ctx context.Context, numbers []int) (map[int]models.Employee, error) {
+	managers := map[int]models.Employee{}
+	today := e.today()
+	arguments := []interface{}{today, today, today, today}
+	err := e.batch(ctx, "employeeManagers", employeeManagersQuery, "e.emp_no", batchKeys(numbers), arguments, func(rows *sql.Rows) error {
+		var employeeNumber int
+		var manager models.Employee
+		if err := scanEmployee(rows, &manager, &employeeNumber); err != nil {
+			return err
+		}
+		if _, ok := managers[employeeNumber]; !ok {
+			managers[employeeNumber] = manager
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return managers, nil
+}
+
+// DepartmentManagers returns the current manager of each department, by department number. Managers the
+// caller may not see are missing.
+func (e *EmployeeService) DepartmentManagers(ctx context.Context, departmentNumbers []string) (map[string]models.Employee, error) {
+	managers := map[string]models.Employee{}
+	today := e.today()
+	err := e.batch(ctx, "departmentManagers", departmentManagersQuery, "e.emp_no", batchKeys(departmentNumbers), []interface{}{today, today}, func(rows *sql.Rows) error {
+		var departmentNumber string
+		var manager models.Employee
+		if err := scanEmployee(rows, &manager, &departmentNumber); err != nil {
+			return err
+		}
+		if _, ok := managers[departmentNumber]; !ok {
+			managers[departmentNumber] = manager
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return managers, nil
+}
+
+// Titles returns the titles of the employees the caller may see, oldest first, by employee number.
+func (e *EmployeeService) Titles(ctx context.Context, numbers []int) (map[int][]models.Title, error) {
+	titles := map[int][]models.Title{}
+	err := e.batch(ctx, "titles", titlesQuery, "t.emp_no", batchKeys(numbers), nil, func(rows *sql.Rows) error {
+		var employeeNumber int
+		var title models.Title
+		if err := rows.Scan(&employeeNumber, &title.Title, &title.FromDate, &title.ToDate); err != nil {
+			return err
+		}
+		titles[employeeNumber] = append(titles[employeeNumber], title)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return titles, nil
+}
+
+// Salaries returns the salaries of the employees the caller may see, oldest first, by employee number. It is
+// forbidden without the read:salaries permission.
+func (e *EmployeeService) Salaries(ctx context.Context, numbers []int) (map[int][]models.Salary, error) {
+	if principal := auth.PrincipalFromContext(ctx); principal != nil && !principal.Can(auth.PermissionReadSalaries) {
+		slog.WarnContext(ctx, "salaries read forbidden", "principal", principal.Subject)
+		return nil, forbidden(ErrForbidden)
+	}
+
+	salaries := map[int][]models.Salary{}
+	err := e.batch(ctx, "salaries", salariesQuery, "s.emp_no", batchKeys(numbers), nil, func(rows *sql.Rows) error {
+		var employeeNumber int
+		var salary models.Salary
+		if err := rows.Scan(&employeeNumber, &salary.Salary, &salary.FromDate, &salary.ToDate); err != nil {
+			return err
+		}
+		salaries[employeeNumber] = append(salaries[employeeNumber], salary)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return salaries, nil
+}
+
+// batch runs query, whose placeholders are the keys, then the arguments, then the caller's scope applied to
+// the employee numbers in column, and calls scan for every row. Callers who may see nobody get no rows.
+func (e *EmployeeService) batch(ctx context.Context, name, query, column string, keys []interface{}, arguments []interface{}, scan func(*sql.Rows) error) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	scope, err := e.departmentScope(ctx)
+	if err != nil {
+		return internal("error getting managed departments", err)
+	}
+
+	if scope != nil && len(scope) == 0 {
+		return nil
+	}
+
+	restriction, scopeArguments := scope.restrict(column, e.today())
+	query = fmt.Sprintf(query, strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", "), restriction)
+	arguments = append(append(keys, arguments...), scopeArguments...)
+
+	ctx, span := tracing.StartSQL(ctx, name, query)
+	defer span.End()
+
+	rows, err := e.EmployeeManager.QueryContext(ctx, query, arguments...)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql batch query", "query", name, "error", err)
+		tracing.RecordError(span, err)
+		return internal("error executing sql batch query", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			slog.ErrorContext(ctx, "error scanning sql batch query", "query", name, "error", err)
+			tracing.RecordError(span, err)
+			return internal("error scanning sql batch query", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		tracing.RecordError(span, err)
+		return internal("error reading sql batch query", err)
+	}
+
+	return nil
+}
+
+// batchKeys turns the keys of a batch into query arguments.
+func batchKeys[K int | string](keys []K) []interface{} {
+	arguments := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		arguments = append(arguments, key)
+	}
+	return arguments
+}
+
+// scanEmployee scans the employee columns of a row, after the leading columns in keys.
+func scanEmployee(rows *sql.Rows, employee *models.Employee, keys ...interface{}) error {
+	return rows.Scan(append(keys,
+		&employee.EmployeeNumber,
+		&employee.BirthDate,
+		&employee.FirstName,
+		&employee.LastName,
+		&employee.Gender,
+		&employee.HireDate,
+	)...)
+}
@@ -0,0 +1,249 @@
+package employee
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestEmployeeService_EmployeesByNumber_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(employeesByNumberQuery, "?, ?", ""))+"$").
+		WithArgs(1, 2).
+		WillReturnRows(employeeRows(1))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	employees, err := employeeService.EmployeesByNumber(context.Background(), []int{1, 2})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	expected := mockEmployee()
+	expected.Department = ""
+	assert.Equal(t, map[int]models.Employee{1: expected}, employees)
+}
+
+func TestEmployeeService_EmployeesByNumber_Scopes_managers_to_their_departments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectManagedDepartments(mock, 110022, "d001", "d004")
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(employeesByNumberQuery, "?, ?", " AND e.emp_no IN (SELECT de.emp_no FROM dept_emp de"+scopedWhere+")"))).
+		WithArgs(1, 2, "d001", "d004", "2024-03-01", "2024-03-01").
+		WillReturnRows(employeeRows(1))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	employees, err := employeeService.EmployeesByNumber(withPrincipal(auth.RoleManager), []int{1, 2})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Len(t, employees, 1)
+}
+
+func TestEmployeeService_EmployeesByNumber_Returns_nobody_to_former_managers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectManagedDepartments(mock, 110022)
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	employees, err := employeeService.EmployeesByNumber(withPrincipal(auth.RoleManager), []int{1, 2})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Empty(t, employees)
+}
+
+func TestEmployeeService_CurrentDepartments_Keeps_the_latest_department(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(currentDepartmentsQuery, "?, ?", ""))).
+		WithArgs(1, 2, "2024-03-01", "2024-03-01").
+		WillReturnRows(sqlmock.NewRows([]string{"emp_no", "dept_no", "dept_name"}).
+			AddRow(1, "d005", "Development").
+			AddRow(1, "d004", "Production").
+			AddRow(2, "d007", "Sales"))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	departments, err := employeeService.CurrentDepartments(context.Background(), []int{1, 2})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, map[int]models.Department{
+		1: {DepartmentNumber: "d005", DepartmentName: "Development"},
+		2: {DepartmentNumber: "d007", DepartmentName: "Sales"},
+	}, departments)
+}
+
+func TestEmployeeService_EmployeeManagers_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(employeeManagersQuery, "?, ?", ""))).
+		WithArgs(10001, 10002, "2024-03-01", "2024-03-01", "2024-03-01", "2024-03-01").
+		WillReturnRows(managerRows().AddRow(10001, 1, birthDate, "Lucas", "Lissandrello", "M", hireDate))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	managers, err := employeeService.EmployeeManagers(context.Background(), []int{10001, 10002})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	expected := mockEmployee()
+	expected.Department = ""
+	assert.Equal(t, map[int]models.Employee{10001: expected}, managers)
+}
+
+func TestEmployeeService_DepartmentManagers_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(departmentManagersQuery, "?", ""))).
+		WithArgs("d005", "2024-03-01", "2024-03-01").
+		WillReturnRows(managerRows().AddRow("d005", 1, birthDate, "Lucas", "Lissandrello", "M", hireDate))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	managers, err := employeeService.DepartmentManagers(context.Background(), []string{"d005"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, 1, managers["d005"].EmployeeNumber)
+}
+
+func TestEmployeeService_Titles_Groups_by_employee(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(titlesQuery, "?, ?", ""))).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"emp_no", "title", "from_date", "to_date"}).
+			AddRow(1, "Engineer", day(1990, 1, 1), day(1995, 1, 1)).
+			AddRow(1, "Senior Engineer", day(1995, 1, 1), day(9999, 1, 1)).
+			AddRow(2, "Staff", day(1996, 1, 1), day(9999, 1, 1)))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	titles, err := employeeService.Titles(context.Background(), []int{1, 2})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, map[int][]models.Title{
+		1: {{Title: "Engineer", FromDate: day(1990, 1, 1), ToDate: day(1995, 1, 1)}, {Title: "Senior Engineer", FromDate: day(1995, 1, 1), ToDate: day(9999, 1, 1)}},
+		2: {{Title: "Staff", FromDate: day(1996, 1, 1), ToDate: day(9999, 1, 1)}},
+	}, titles)
+}
+
+func TestEmployeeService_Salaries_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(salariesQuery, "?", ""))).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"emp_no", "salary", "from_date", "to_date"}).AddRow(1, 60117, day(1986, 6, 26), day(1987, 6, 26)))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	salaries, err := employeeService.Salaries(withPrincipal(auth.RoleHRAdmin), []int{1})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, map[int][]models.Salary{1: {{Salary: 60117, FromDate: day(1986, 6, 26), ToDate: day(1987, 6, 26)}}}, salaries)
+}
+
+func TestEmployeeService_Salaries_Fails_without_permission(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	salaries, err := employeeService.Salaries(withPrincipal(auth.RoleHR), []int{1})
+	assert.Nil(t, salaries)
+	assertEmployeeError(t, err, KindForbidden, "forbidden")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEmployeeService_Titles_Skips_the_query_without_keys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	titles, err := employeeService.Titles(withPrincipal(auth.RoleManager), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Empty(t, titles)
+}
+
+func TestEmployeeService_Titles_Fails_doing_batch_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(titlesQuery, "?", ""))).
+		WithArgs(1).
+		WillReturnError(errors.New("error executing query in database"))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	titles, err := employeeService.Titles(context.Background(), []int{1})
+	assert.Nil(t, titles)
+	assertEmployeeError(t, err, KindInternal, "error executing sql batch query")
+}
+
+var (
+	birthDate = time.Date(1994, 11, 8, 7, 30, 00, 0, time.UTC)
+	hireDate  = time.Date(2022, 06, 20, 15, 00, 00, 0, time.UTC)
+)
+
+func managerRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"key", "emp_no", "birth_date", "first_name", "last_name", "gender", "hire_date"})
+}
@@ -3,39 +3,158 @@ package employee
 import (
 	"context"
 	"database/sql"
+	"employee_exercise/src/pkg/libs/audit"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/tracing"
+	"employee_exercise/src/pkg/libs/validation"
 	"employee_exercise/src/pkg/models"
+	"errors"
 	"fmt"
-	"github.com/google/logger"
-	"net/http"
+	"log/slog"
+	"time"
 )
 
 type EmployeeService struct {
 	EmployeeManager *sql.DB
+	now             func() time.Time
 }
 
-type EmployeeError struct {
-	Error              error
-	ResponseStatusCode int
-	ErrorMessage       string
-}
+var ErrForbidden = errors.New("forbidden")
+
+const (
+	employeeQuery = "SELECT e.emp_no, e.birth_date, e.first_name, e.last_name, e.gender, e.hire_date, COALESCE(d.dept_name, '') " +
+		"FROM employees e LEFT JOIN dept_emp de ON e.emp_no = de.emp_no LEFT JOIN departments d ON de.dept_no = d.dept_no " +
+		"WHERE e.emp_no = ? ORDER BY de.to_date DESC LIMIT 1"
+	departmentsQuery = "SELECT dept_no, dept_name FROM departments ORDER BY dept_no"
+
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// orderColumns maps the order_by_column parameter to the column the listing is sorted by, and orders the
+// order parameter to its direction. Nothing else reaches the ORDER BY clause.
+var (
+	orderColumns = map[string]string{
+		"emp_no": "e.emp_no", "birth_date": "e.birth_date", "first_name": "e.first_name",
+		"last_name": "e.last_name", "gender": "e.gender", "hire_date": "e.hire_date",
+	}
+	orders = map[string]string{"asc": "asc", "desc": "desc"}
+)
 
 func (e *EmployeeService) GetEmployees(ctx context.Context, parameters map[string]string) (*models.EmployeeResponse, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "EmployeeService.GetEmployees")
+	defer span.End()
+
+	scope, err := e.departmentScope(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	if scope != nil && len(scope) == 0 {
+		return &models.EmployeeResponse{}, nil
+	}
+
+	employees, err := e.getEmployeesPage(ctx, parameters, scope)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	total, totalError := e.getTotalEmployees(ctx, scope)
+	if totalError != nil {
+		slog.ErrorContext(ctx, "error getting total quantity from employees table", "error", totalError)
+		tracing.RecordError(span, totalError)
+		return nil, totalError
+	}
+
+	employeesResponse := models.EmployeeResponse{
+		Total:     total,
+		Employees: employees,
+	}
+
+	return &employeesResponse, nil
+}
+
+func (e *EmployeeService) getEmployeesPage(ctx context.Context, parameters map[string]string, scope departmentScope) ([]models.Employee, error) {
 	var employees []models.Employee
+	err := e.eachEmployee(ctx, "getEmployeesPage", parameters, scope, func(employee models.Employee) error {
+		employees = append(employees, employee)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return employees, nil
+}
+
+// StreamEmployees passes the employees the caller may see to send one at a time, in the order of the
+// order_by_column and order parameters, without holding the listing in memory. All of them are sent when
+// the limit parameter is empty. An error from send stops the listing and is returned.
+func (e *EmployeeService) StreamEmployees(ctx context.Context, parameters map[string]string, send func(models.Employee) error) error {
+	ctx, span := tracing.Tracer().Start(ctx, "EmployeeService.StreamEmployees")
+	defer span.End()
+
+	scope, err := e.departmentScope(ctx)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return internal("error getting managed departments", err)
+	}
+
+	if scope != nil && len(scope) == 0 {
+		return nil
+	}
+
+	if err = e.eachEmployee(ctx, "streamEmployees", parameters, scope, send); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// eachEmployee runs the employees listing and calls fn for every row as it is read.
+func (e *EmployeeService) eachEmployee(ctx context.Context, name string, parameters map[string]string, scope departmentScope, fn func(models.Employee) error) error {
+	column, columnOk := orderColumns[parameters["order_by_column"]]
+	order, orderOk := orders[parameters["order"]]
+	if !columnOk || !orderOk {
+		slog.WarnContext(ctx, "unknown employees order", "order_by_column", parameters["order_by_column"], "order", parameters["order"])
+		return invalid(problem.FieldError{Field: "order_by_column", Code: validation.CodeInvalid, Message: "must be one of emp_no, birth_date, first_name, last_name, gender, hire_date, ordered asc or desc"})
+	}
+
+	var where string
+	var arguments []interface{}
+	if scope != nil {
+		where, arguments = scope.where(e.today())
+	}
 	query := fmt.Sprintf("SELECT e.emp_no, e.birth_date, e.first_name, e.last_name, e.gender, e.hire_date, d.dept_name "+
-		"FROM employees e JOIN dept_emp de ON e.emp_no= de.emp_no JOIN departments d on de.dept_no = d.dept_no"+
-		" ORDER BY %s %s LIMIT %s OFFSET %s", parameters["order_by_column"], parameters["order"], parameters["limit"], parameters["offset"])
+		"FROM employees e JOIN dept_emp de ON e.emp_no= de.emp_no JOIN departments d on de.dept_no = d.dept_no%s"+
+		" ORDER BY %s %s", where, column, order)
+	if limit := parameters["limit"]; limit != "" {
+		query += " LIMIT " + limit
+		if offset := parameters["offset"]; offset != "" {
+			query += " OFFSET " + offset
+		}
+	}
+	ctx, span := tracing.StartSQL(ctx, name, query)
+	defer span.End()
+
 	stmt, err := e.EmployeeManager.PrepareContext(ctx, query)
 	if err != nil {
-		logger.Errorf("error preparing sql select query: %v", err)
-		return nil, err
+		slog.ErrorContext(ctx, "error preparing sql select query", "error", err)
+		tracing.RecordError(span, err)
+		return err
 	}
 
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx)
+	rows, err := stmt.QueryContext(ctx, arguments...)
 	if err != nil {
-		logger.Errorf("error executing sql select query: %v", err)
-		return nil, err
+		slog.ErrorContext(ctx, "error executing sql select query", "error", err)
+		tracing.RecordError(span, err)
+		return err
 	}
 
 	defer rows.Close()
@@ -52,74 +171,187 @@ func (e *EmployeeService) GetEmployees(ctx context.Context, parameters map[strin
 			&employee.Department,
 		)
 		if err != nil {
-			logger.Errorf("error scanning sql select query: %v", err)
-			return nil, err
+			slog.ErrorContext(ctx, "error scanning sql select query", "error", err)
+			tracing.RecordError(span, err)
+			return err
 		}
 
-		employees = append(employees, employee)
+		if err = fn(employee); err != nil {
+			return err
+		}
 	}
 
-	total, totalError := e.getTotalEmployees(ctx)
-	if totalError != nil {
-		logger.Errorf("error getting total quantity from employees table: %v", err)
-		return nil, totalError
+	return rows.Err()
+}
+
+// GetEmployee returns an employee with the name of their latest department, empty when they never had one.
+func (e *EmployeeService) GetEmployee(ctx context.Context, employeeID int) (*models.Employee, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "EmployeeService.GetEmployee")
+	defer span.End()
+
+	if visibleError := e.checkEmployeeVisible(ctx, employeeID); visibleError != nil {
+		return nil, visibleError
 	}
 
-	employeesResponse := models.EmployeeResponse{
-		Total:     total,
-		Employees: employees,
+	ctx, sqlSpan := tracing.StartSQL(ctx, "getEmployee", employeeQuery)
+	defer sqlSpan.End()
+
+	stmt, err := e.EmployeeManager.PrepareContext(ctx, employeeQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql select query for employee", "emp_no", employeeID, "error", err)
+		tracing.RecordError(sqlSpan, err)
+		return nil, internal("error preparing sql select query", err)
 	}
 
-	return &employeesResponse, nil
+	defer stmt.Close()
+
+	var employee models.Employee
+	err = stmt.QueryRowContext(ctx, employeeID).Scan(
+		&employee.EmployeeNumber,
+		&employee.BirthDate,
+		&employee.FirstName,
+		&employee.LastName,
+		&employee.Gender,
+		&employee.HireDate,
+		&employee.Department,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			slog.InfoContext(ctx, "employee not found", "emp_no", employeeID)
+			return nil, notFound(CodeEmployeeNotFound, "employee not found", err)
+		}
+		slog.ErrorContext(ctx, "error scanning sql select query for employee", "emp_no", employeeID, "error", err)
+		tracing.RecordError(sqlSpan, err)
+		return nil, internal("error scanning sql select query", err)
+	}
+
+	return &employee, nil
+}
+
+// ListDepartments returns every department ordered by number.
+func (e *EmployeeService) ListDepartments(ctx context.Context) ([]models.Department, error) {
+	ctx, span := tracing.StartSQL(ctx, "listDepartments", departmentsQuery)
+	defer span.End()
+
+	rows, err := e.EmployeeManager.QueryContext(ctx, departmentsQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql select query for departments", "error", err)
+		tracing.RecordError(span, err)
+		return nil, internal("error executing sql select query for departments", err)
+	}
+
+	defer rows.Close()
+
+	departments := []models.Department{}
+	for rows.Next() {
+		var department models.Department
+		if err = rows.Scan(&department.DepartmentNumber, &department.DepartmentName); err != nil {
+			slog.ErrorContext(ctx, "error scanning sql select query for departments", "error", err)
+			tracing.RecordError(span, err)
+			return nil, internal("error scanning sql select query for departments", err)
+		}
+		departments = append(departments, department)
+	}
+	if err = rows.Err(); err != nil {
+		tracing.RecordError(span, err)
+		return nil, internal("error reading departments", err)
+	}
+
+	return departments, nil
 }
 
-func (e *EmployeeService) UpdateEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) EmployeeError {
+func (e *EmployeeService) UpdateEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) error {
+	ctx, span := tracing.Tracer().Start(ctx, "EmployeeService.UpdateEmployeeDepartment")
+	defer span.End()
+
+	if principal := auth.PrincipalFromContext(ctx); principal != nil && !principal.Can(auth.PermissionWriteDepartments) {
+		slog.WarnContext(ctx, "employee department update forbidden", "emp_no", employeeDepartment.EmployeeNumber, "principal", principal.Subject)
+		return forbidden(ErrForbidden)
+	}
+
+	if validationError := validateEmployeeDepartment(employeeDepartment); validationError != nil {
+		return validationError
+	}
+
 	_, getError := e.getEmployeeByID(ctx, employeeDepartment.EmployeeNumber)
-	if getError.Error != nil {
+	if getError != nil {
 		return getError
 	}
 
 	_, departmentError := e.getDepartmentByID(ctx, employeeDepartment.Department)
-	if departmentError.Error != nil {
+	if departmentError != nil {
 		return departmentError
 	}
 
-	_, employeeDepartmentError := e.getEmployeeDepartment(ctx, employeeDepartment.EmployeeNumber)
-	if employeeDepartmentError.Error != nil {
-		if employeeDepartmentError.Error == sql.ErrNoRows {
-			createError := e.createEmployeeDepartment(ctx, employeeDepartment)
-			if createError.Error != nil {
-				return createError
-			}
-			return EmployeeError{}
-		}
-		return employeeDepartmentError
+	tx, err := e.EmployeeManager.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "error starting transaction for employee department", "emp_no", employeeDepartment.EmployeeNumber, "error", err)
+		return internal("error starting transaction", err)
 	}
 
-	updateError := e.updateEmployeeDepartment(ctx, employeeDepartment)
-	if updateError.Error != nil {
+	defer tx.Rollback()
+
+	action := audit.ActionEmployeeDepartmentUpdate
+	previous, employeeDepartmentError := e.getEmployeeDepartment(ctx, tx, employeeDepartment.EmployeeNumber)
+	if employeeDepartmentError != nil {
+		if !errors.Is(employeeDepartmentError, sql.ErrNoRows) {
+			return employeeDepartmentError
+		}
+
+		action = audit.ActionEmployeeDepartmentCreate
+		if createError := e.createEmployeeDepartment(ctx, tx, employeeDepartment); createError != nil {
+			return createError
+		}
+	} else if updateError := e.updateEmployeeDepartment(ctx, tx, *previous, employeeDepartment); updateError != nil {
 		return updateError
 	}
 
-	return EmployeeError{}
+	if err = audit.Record(ctx, tx, action, employeeDepartment.EmployeeNumber, previous, employeeDepartment); err != nil {
+		return internal("error writing audit log", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, "error committing transaction for employee department", "emp_no", employeeDepartment.EmployeeNumber, "error", err)
+		return internal("error committing transaction", err)
+	}
+
+	return nil
 }
 
-func (e *EmployeeService) getEmployeeByID(ctx context.Context, employeeID int) (*models.Employee, EmployeeError) {
+func validateEmployeeDepartment(employeeDepartment models.EmployeeDepartment) error {
+	var fields []problem.FieldError
+	if employeeDepartment.EmployeeNumber < 1 {
+		fields = append(fields, problem.FieldError{Field: "emp_no", Code: validation.CodeMin, Message: "must be at least 1"})
+	}
+	if employeeDepartment.Department == "" {
+		fields = append(fields, problem.FieldError{Field: "dept_no", Code: validation.CodeRequired, Message: "is required"})
+	}
+	if employeeDepartment.ToDate.Before(employeeDepartment.FromDate) {
+		fields = append(fields, problem.FieldError{Field: "to_date", Code: validation.CodeNotBefore, Message: "must not be before from_date"})
+	}
+
+	if len(fields) != 0 {
+		return invalid(fields...)
+	}
+	return nil
+}
+
+func (e *EmployeeService) getEmployeeByID(ctx context.Context, employeeID int) (*models.Employee, error) {
 	var employee models.Employee
-	query := fmt.Sprintf("SELECT * FROM employees WHERE emp_no=%d", employeeID)
+	query := "SELECT * FROM employees WHERE emp_no = ?"
+	ctx, span := tracing.StartSQL(ctx, "getEmployeeByID", query)
+	defer span.End()
+
 	stmt, err := e.EmployeeManager.PrepareContext(ctx, query)
 	if err != nil {
-		logger.Errorf("error preparing sql select query for employee: %d, %v", employeeID, err)
-		return nil, EmployeeError{
-			Error:              err,
-			ResponseStatusCode: http.StatusInternalServerError,
-			ErrorMessage:       "error preparing sql select query",
-		}
+		slog.ErrorContext(ctx, "error preparing sql select query for employee", "emp_no", employeeID, "error", err)
+		tracing.RecordError(span, err)
+		return nil, internal("error preparing sql select query", err)
 	}
 
 	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx)
+	row := stmt.QueryRowContext(ctx, employeeID)
 	err = row.Scan(
 		&employee.EmployeeNumber,
 		&employee.BirthDate,
@@ -130,82 +362,70 @@ func (e *EmployeeService) getEmployeeByID(ctx context.Context, employeeID int) (
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			logger.Infof("employee not found: %d", employeeID)
-			return nil, EmployeeError{
-				Error:              err,
-				ResponseStatusCode: http.StatusNotFound,
-				ErrorMessage:       "employee not found",
-			}
+			slog.InfoContext(ctx, "employee not found", "emp_no", employeeID)
+			return nil, notFound(CodeEmployeeNotFound, "employee not found", err)
 		} else {
-			logger.Errorf("error scanning sql select query for employee: %d, %v", employeeID, err)
-			return nil, EmployeeError{
-				Error:              err,
-				ResponseStatusCode: http.StatusInternalServerError,
-				ErrorMessage:       "error scanning sql select query",
-			}
+			slog.ErrorContext(ctx, "error scanning sql select query for employee", "emp_no", employeeID, "error", err)
+			tracing.RecordError(span, err)
+			return nil, internal("error scanning sql select query", err)
 		}
 	}
 
-	return &employee, EmployeeError{}
+	return &employee, nil
 }
 
-func (e *EmployeeService) getDepartmentByID(ctx context.Context, departmentID string) (*models.Department, EmployeeError) {
+func (e *EmployeeService) getDepartmentByID(ctx context.Context, departmentID string) (*models.Department, error) {
 	var department models.Department
-	query := fmt.Sprintf("SELECT * FROM departments WHERE dept_no='%s'", departmentID)
+	query := "SELECT * FROM departments WHERE dept_no = ?"
+	ctx, span := tracing.StartSQL(ctx, "getDepartmentByID", query)
+	defer span.End()
+
 	stmt, err := e.EmployeeManager.PrepareContext(ctx, query)
 	if err != nil {
-		logger.Errorf("error preparing sql select query for department: %s, %v", departmentID, err)
-		return nil, EmployeeError{
-			Error:              err,
-			ResponseStatusCode: http.StatusInternalServerError,
-			ErrorMessage:       "error preparing sql select query",
-		}
+		slog.ErrorContext(ctx, "error preparing sql select query for department", "dept_no", departmentID, "error", err)
+		tracing.RecordError(span, err)
+		return nil, internal("error preparing sql select query", err)
 	}
 
 	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx)
+	row := stmt.QueryRowContext(ctx, departmentID)
 	err = row.Scan(
 		&department.DepartmentNumber,
 		&department.DepartmentName,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			logger.Infof("department not found: %s", departmentID)
-			return nil, EmployeeError{
-				Error:              err,
-				ResponseStatusCode: http.StatusNotFound,
-				ErrorMessage:       "department not found",
-			}
+			slog.InfoContext(ctx, "department not found", "dept_no", departmentID)
+			return nil, notFound(CodeDepartmentNotFound, "department not found", err)
 		} else {
-			logger.Errorf("error scanning sql select query for department: %s, %v", departmentID, err)
-			return nil, EmployeeError{
-				Error:              err,
-				ResponseStatusCode: http.StatusInternalServerError,
-				ErrorMessage:       "error scanning sql select query",
-			}
+			slog.ErrorContext(ctx, "error scanning sql select query for department", "dept_no", departmentID, "error", err)
+			tracing.RecordError(span, err)
+			return nil, internal("error scanning sql select query", err)
 		}
 	}
 
-	return &department, EmployeeError{}
+	return &department, nil
 }
 
-func (e *EmployeeService) getEmployeeDepartment(ctx context.Context, employeeID int) (*models.EmployeeDepartment, EmployeeError) {
+// getEmployeeDepartment locks the latest department assignment of the employee, the one row that
+// updateEmployeeDepartment rewrites and the audit log records as the previous state.
+func (e *EmployeeService) getEmployeeDepartment(ctx context.Context, tx *sql.Tx, employeeID int) (*models.EmployeeDepartment, error) {
 	var employeeDepartment models.EmployeeDepartment
-	query := fmt.Sprintf("SELECT * FROM dept_emp WHERE emp_no=%d", employeeID)
-	stmt, err := e.EmployeeManager.PrepareContext(ctx, query)
+	query := "SELECT * FROM dept_emp WHERE emp_no = ? ORDER BY to_date DESC, from_date DESC LIMIT 1 FOR UPDATE"
+	ctx, span := tracing.StartSQL(ctx, "getEmployeeDepartment", query)
+	defer span.End()
+
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
-		logger.Errorf("error preparing sql select query employee department for employee: %d, %v", employeeID, err)
-		return nil, EmployeeError{
-			Error:              err,
-			ResponseStatusCode: http.StatusInternalServerError,
-			ErrorMessage:       "error preparing sql select query for employee department",
-		}
+		slog.ErrorContext(ctx, "error preparing sql select query for employee department", "emp_no", employeeID, "error", err)
+		tracing.RecordError(span, err)
+		return nil, internal("error preparing sql select query for employee department", err)
 	}
 
 	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx)
+	row := stmt.QueryRowContext(ctx, employeeID)
 	err = row.Scan(
 		&employeeDepartment.EmployeeNumber,
 		&employeeDepartment.Department,
@@ -214,96 +434,94 @@ func (e *EmployeeService) getEmployeeDepartment(ctx context.Context, employeeID
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			logger.Infof("employee department not found: %d", employeeID)
-			return nil, EmployeeError{
-				Error:        err,
-				ErrorMessage: "employee department not found",
-			}
+			slog.InfoContext(ctx, "employee department not found", "emp_no", employeeID)
+			return nil, notFound(CodeEmployeeDepartmentNotFound, "employee department not found", err)
 		} else {
-			logger.Errorf("error scanning sql select query employee department for employee: %d, %v", employeeID, err)
-			return nil, EmployeeError{
-				Error:              err,
-				ResponseStatusCode: http.StatusInternalServerError,
-				ErrorMessage:       "error scanning sql select query for employee department",
-			}
+			slog.ErrorContext(ctx, "error scanning sql select query for employee department", "emp_no", employeeID, "error", err)
+			tracing.RecordError(span, err)
+			return nil, internal("error scanning sql select query for employee department", err)
 		}
 	}
 
-	return &employeeDepartment, EmployeeError{}
+	return &employeeDepartment, nil
 }
 
-func (e *EmployeeService) updateEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) EmployeeError {
-	query := fmt.Sprintf("UPDATE dept_emp SET dept_no='%s', from_date='%s', to_date='%s' WHERE emp_no=%d",
-		employeeDepartment.Department, employeeDepartment.FromDate.Format("2006-01-02 15:04:05"),
-		employeeDepartment.ToDate.Format("2006-01-02 15:04:05"), employeeDepartment.EmployeeNumber)
-	stmt, err := e.EmployeeManager.PrepareContext(ctx, query)
+func (e *EmployeeService) updateEmployeeDepartment(ctx context.Context, tx *sql.Tx, previous, employeeDepartment models.EmployeeDepartment) error {
+	query := "UPDATE dept_emp SET dept_no = ?, from_date = ?, to_date = ? WHERE emp_no = ? AND dept_no = ?"
+	ctx, span := tracing.StartSQL(ctx, "updateEmployeeDepartment", query)
+	defer span.End()
+
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
-		logger.Errorf("error preparing sql update query for employee department: %d, %v", employeeDepartment.EmployeeNumber, err)
-		return EmployeeError{
-			Error:              err,
-			ResponseStatusCode: http.StatusInternalServerError,
-			ErrorMessage:       "error preparing sql update query for employee department",
-		}
+		slog.ErrorContext(ctx, "error preparing sql update query for employee department", "emp_no", employeeDepartment.EmployeeNumber, "error", err)
+		tracing.RecordError(span, err)
+		return internal("error preparing sql update query for employee department", err)
 	}
 
 	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx)
+	result, err := stmt.ExecContext(ctx, employeeDepartment.Department, employeeDepartment.FromDate.Format(dateTimeLayout),
+		employeeDepartment.ToDate.Format(dateTimeLayout), employeeDepartment.EmployeeNumber, previous.Department)
 	if err != nil {
-		logger.Errorf("error executing sql update query for employee department: %d, %v", employeeDepartment.EmployeeNumber, err)
-		return EmployeeError{
-			Error:              err,
-			ResponseStatusCode: http.StatusInternalServerError,
-			ErrorMessage:       "error executing sql update query for employee department",
-		}
+		slog.ErrorContext(ctx, "error executing sql update query for employee department", "emp_no", employeeDepartment.EmployeeNumber, "error", err)
+		tracing.RecordError(span, err)
+		return writeFailure("error executing sql update query for employee department", err)
 	}
 	rowsAffected, _ := result.RowsAffected()
-	logger.Infof("employee department updated, rows affected: %d", rowsAffected)
-	return EmployeeError{}
+	slog.InfoContext(ctx, "employee department updated", "emp_no", employeeDepartment.EmployeeNumber, "rows_affected", rowsAffected)
+	return nil
 }
 
-func (e *EmployeeService) createEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) EmployeeError {
-	query := fmt.Sprintf("INSERT INTO dept_emp (emp_no, dept_no, from_date, to_date) VALUES (%d, '%s', '%s', '%s')",
-		employeeDepartment.EmployeeNumber, employeeDepartment.Department, employeeDepartment.FromDate.Format("2006-01-02 15:04:05"), employeeDepartment.ToDate.Format("2006-01-02 15:04:05"))
-	stmt, err := e.EmployeeManager.PrepareContext(ctx, query)
+func (e *EmployeeService) createEmployeeDepartment(ctx context.Context, tx *sql.Tx, employeeDepartment models.EmployeeDepartment) error {
+	query := "INSERT INTO dept_emp (emp_no, dept_no, from_date, to_date) VALUES (?, ?, ?, ?)"
+	ctx, span := tracing.StartSQL(ctx, "createEmployeeDepartment", query)
+	defer span.End()
+
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
-		logger.Errorf("error preparing sql insert query for employee department: %d, %v", employeeDepartment.EmployeeNumber, err)
-		return EmployeeError{
-			Error:              err,
-			ResponseStatusCode: http.StatusInternalServerError,
-			ErrorMessage:       "error preparing sql insert query for employee department",
-		}
+		slog.ErrorContext(ctx, "error preparing sql insert query for employee department", "emp_no", employeeDepartment.EmployeeNumber, "error", err)
+		tracing.RecordError(span, err)
+		return internal("error preparing sql insert query for employee department", err)
 	}
 
 	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx)
+	result, err := stmt.ExecContext(ctx, employeeDepartment.EmployeeNumber, employeeDepartment.Department,
+		employeeDepartment.FromDate.Format(dateTimeLayout), employeeDepartment.ToDate.Format(dateTimeLayout))
 	if err != nil {
-		logger.Errorf("error executing sql insert query for employee department: %d, %v", employeeDepartment.EmployeeNumber, err)
-		return EmployeeError{
-			Error:              err,
-			ResponseStatusCode: http.StatusInternalServerError,
-			ErrorMessage:       "error executing sql insert query for employee department",
-		}
+		slog.ErrorContext(ctx, "error executing sql insert query for employee department", "emp_no", employeeDepartment.EmployeeNumber, "error", err)
+		tracing.RecordError(span, err)
+		return writeFailure("error executing sql insert query for employee department", err)
 	}
 	rowsAffected, _ := result.RowsAffected()
-	logger.Infof("employee department created, rows affected: %d", rowsAffected)
-	return EmployeeError{}
+	slog.InfoContext(ctx, "employee department created", "emp_no", employeeDepartment.EmployeeNumber, "rows_affected", rowsAffected)
+	return nil
 }
 
-func (e *EmployeeService) getTotalEmployees(ctx context.Context) (int, error) {
+func (e *EmployeeService) getTotalEmployees(ctx context.Context, scope departmentScope) (int, error) {
 	total := 0
 	query := "SELECT COUNT(*) FROM employees"
+	var arguments []interface{}
+	if scope != nil {
+		var where string
+		where, arguments = scope.where(e.today())
+		query = "SELECT COUNT(DISTINCT de.emp_no) FROM dept_emp de" + where
+	}
+	ctx, span := tracing.StartSQL(ctx, "getTotalEmployees", query)
+	defer span.End()
+
 	stmt, err := e.EmployeeManager.PrepareContext(ctx, query)
 	if err != nil {
-		logger.Errorf("error preparing sql select count query: %v", err)
+		slog.ErrorContext(ctx, "error preparing sql select count query", "error", err)
+		tracing.RecordError(span, err)
 		return 0, err
 	}
 
-	row := stmt.QueryRowContext(ctx)
+	row := stmt.QueryRowContext(ctx, arguments...)
 	err = row.Scan(&total)
 	if err != nil {
-		logger.Errorf("error scanning sql count query: %v", err)
+		slog.ErrorContext(ctx, "error scanning sql count query", "error", err)
+		tracing.RecordError(span, err)
 		return 0, err
 	}
 
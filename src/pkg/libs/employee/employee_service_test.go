@@ -4,12 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"employee_exercise/src/pkg/libs/audit"
+	"employee_exercise/src/pkg/libs/problem"
 	"employee_exercise/src/pkg/models"
 	"errors"
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
-	"net/http"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"regexp"
 	"testing"
 	"time"
@@ -23,7 +29,7 @@ func TestEmployeeService_GetEmployees_Succeeds(t *testing.T) {
 	defer func() { _ = db.Close() }()
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("emp_no", "asc", "1", "1"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("e.emp_no", "asc", "1", "1"))).
 		ExpectQuery().
 		WithArgs().
 		WillReturnRows(employeeRowsWithDepartment(1))
@@ -44,6 +50,26 @@ func TestEmployeeService_GetEmployees_Succeeds(t *testing.T) {
 	assert.Equal(t, expectedEmployeeResponse(), *employeesResponse)
 }
 
+func TestEmployeeService_GetEmployees_Rejects_unknown_order_columns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	parameters := mockParameters()
+	parameters["order_by_column"] = "emp_no DESC, (SELECT 1)"
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	employeesResponse, err := employeeService.GetEmployees(context.Background(), parameters)
+
+	var employeeError *Error
+	assert.ErrorAs(t, err, &employeeError)
+	assert.Equal(t, KindValidation, employeeError.Kind)
+	assert.Nil(t, employeesResponse)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestEmployeeService_GetEmployees_Fails_doing_select_query(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -52,7 +78,7 @@ func TestEmployeeService_GetEmployees_Fails_doing_select_query(t *testing.T) {
 	defer func() { _ = db.Close() }()
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("emp_no", "asc", "1", "1"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("e.emp_no", "asc", "1", "1"))).
 		ExpectQuery().
 		WithArgs().
 		WillReturnError(errors.New("error executing query in database"))
@@ -75,7 +101,7 @@ func TestEmployeeService_GetEmployees_Fails_with_wrong_data_from_db(t *testing.T
 	defer func() { _ = db.Close() }()
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("emp_no", "asc", "1", "1"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("e.emp_no", "asc", "1", "1"))).
 		ExpectQuery().
 		WithArgs().
 		WillReturnRows(employeeRowsWithDepartmentAndWrongData(1))
@@ -97,7 +123,7 @@ func TestEmployeeService_GetEmployees_Fails_preparing_select_query(t *testing.T)
 	defer func() { _ = db.Close() }()
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("emp_no", "asc", "1", "1"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("e.emp_no", "asc", "1", "1"))).
 		WillReturnError(errors.New("error preparing query in database"))
 
 	employeeService := &EmployeeService{EmployeeManager: db}
@@ -118,7 +144,7 @@ func TestEmployeeService_GetEmployees_Fails_doing_count_query(t *testing.T) {
 	defer func() { _ = db.Close() }()
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("emp_no", "asc", "1", "1"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("e.emp_no", "asc", "1", "1"))).
 		ExpectQuery().
 		WithArgs().
 		WillReturnRows(employeeRowsWithDepartment(1))
@@ -147,7 +173,7 @@ func TestEmployeeService_GetEmployees_Fails_preparing_count_query(t *testing.T)
 	defer func() { _ = db.Close() }()
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("emp_no", "asc", "1", "1"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("e.emp_no", "asc", "1", "1"))).
 		ExpectQuery().
 		WithArgs().
 		WillReturnRows(employeeRowsWithDepartment(1))
@@ -181,32 +207,37 @@ func TestEmployeeService_UpdateEmployeeDepartment_Succeeds_Updating(t *testing.T
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnRows(departmentRows(1))
 
+	mock.ExpectBegin()
+
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeDepartmentRows(1))
 
-	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlUpdateEmployeeDepartmentQuery(employeeDepartmentUpdate))).
-		ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlUpdateEmployeeDepartmentQuery())).
+		ExpectExec().WithArgs(updateEmployeeDepartmentArgs(employeeDepartmentUpdate, "d006")...).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	expectAudit(mock, audit.ActionEmployeeDepartmentUpdate, `{"emp_no":10002,"dept_no":"d006","from_date":"1994-11-08T07:30:00Z","to_date":"1994-11-08T07:30:00Z"}`, `{"emp_no":10002,"dept_no":"d005","from_date":"1994-11-09T07:30:00Z","to_date":"1994-11-10T07:30:00Z"}`)
+	mock.ExpectCommit()
 
 	employeeService := &EmployeeService{EmployeeManager: db}
 
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.Nil(t, updateError.Error)
+	assert.NoError(t, updateError)
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Succeeds_Creating_new_record(t *testing.T) {
@@ -224,32 +255,89 @@ func TestEmployeeService_UpdateEmployeeDepartment_Succeeds_Creating_new_record(t
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnRows(departmentRows(1))
 
+	mock.ExpectBegin()
+
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnError(sql.ErrNoRows)
 
-	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlInsertEmployeeDepartmentQuery(employeeDepartmentUpdate))).
-		ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlInsertEmployeeDepartmentQuery())).
+		ExpectExec().WithArgs(insertEmployeeDepartmentArgs(employeeDepartmentUpdate)...).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	expectAudit(mock, audit.ActionEmployeeDepartmentCreate, nil, `{"emp_no":10002,"dept_no":"d005","from_date":"1994-11-09T07:30:00Z","to_date":"1994-11-10T07:30:00Z"}`)
+	mock.ExpectCommit()
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, updateError)
+}
+
+func TestEmployeeService_UpdateEmployeeDepartment_Fails_writing_audit_log(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	employeeDepartmentUpdate := models.EmployeeDepartment{
+		EmployeeNumber: 10002,
+		Department:     "d005",
+		FromDate:       time.Date(1994, 11, 9, 7, 30, 00, 0, time.UTC),
+		ToDate:         time.Date(1994, 11, 10, 7, 30, 00, 0, time.UTC),
+	}
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
+		ExpectQuery().
+		WithArgs(10002).
+		WillReturnRows(employeeRows(1))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
+		ExpectQuery().
+		WithArgs("d005").
+		WillReturnRows(departmentRows(1))
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
+		ExpectQuery().
+		WithArgs(10002).
+		WillReturnRows(employeeDepartmentRows(1))
+
+	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlUpdateEmployeeDepartmentQuery())).
+		ExpectExec().WithArgs(updateEmployeeDepartmentArgs(employeeDepartmentUpdate, "d006")...).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta("INSERT INTO audit_log")).
+		ExpectExec().
+		WillReturnError(errors.New("error executing sql query"))
+
+	mock.ExpectRollback()
 
 	employeeService := &EmployeeService{EmployeeManager: db}
 
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.Nil(t, updateError.Error)
+	assertEmployeeError(t, updateError, KindInternal, "error writing audit log")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_When_Employee_not_exists(t *testing.T) {
@@ -267,9 +355,9 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_When_Employee_not_exists
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnError(sql.ErrNoRows)
 
 	employeeService := &EmployeeService{EmployeeManager: db}
@@ -277,12 +365,7 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_When_Employee_not_exists
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, updateError, EmployeeError{
-		Error:              sql.ErrNoRows,
-		ResponseStatusCode: http.StatusNotFound,
-		ErrorMessage:       "employee not found",
-	})
+	assert.Equal(t, notFound(CodeEmployeeNotFound, "employee not found", sql.ErrNoRows), updateError)
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_preparing_employee_query(t *testing.T) {
@@ -300,7 +383,7 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_preparing_employee_query
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		WillReturnError(errors.New("error preparing sql query"))
 
 	employeeService := &EmployeeService{EmployeeManager: db}
@@ -308,12 +391,7 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_preparing_employee_query
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, updateError, EmployeeError{
-		Error:              errors.New("error preparing sql query"),
-		ResponseStatusCode: http.StatusInternalServerError,
-		ErrorMessage:       "error preparing sql select query",
-	})
+	assert.Equal(t, internal("error preparing sql select query", errors.New("error preparing sql query")), updateError)
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_when_employee_has_wrong_data(t *testing.T) {
@@ -331,9 +409,9 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_when_employee_has_wrong_
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRowsWithWrongData(1))
 
 	employeeService := &EmployeeService{EmployeeManager: db}
@@ -341,9 +419,7 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_when_employee_has_wrong_
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, updateError.ResponseStatusCode, http.StatusInternalServerError)
-	assert.Equal(t, "error scanning sql select query", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error scanning sql select query")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_When_Department_not_exists(t *testing.T) {
@@ -361,15 +437,15 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_When_Department_not_exis
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnError(sql.ErrNoRows)
 
 	employeeService := &EmployeeService{EmployeeManager: db}
@@ -377,12 +453,7 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_When_Department_not_exis
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, updateError, EmployeeError{
-		Error:              sql.ErrNoRows,
-		ResponseStatusCode: http.StatusNotFound,
-		ErrorMessage:       "department not found",
-	})
+	assert.Equal(t, notFound(CodeDepartmentNotFound, "department not found", sql.ErrNoRows), updateError)
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_preparing_department_query(t *testing.T) {
@@ -400,13 +471,13 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_preparing_department_que
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		WillReturnError(errors.New("error preparing sql query"))
 
 	employeeService := &EmployeeService{EmployeeManager: db}
@@ -414,9 +485,7 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_preparing_department_que
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, http.StatusInternalServerError, updateError.ResponseStatusCode)
-	assert.Equal(t, "error preparing sql select query", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error preparing sql select query")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_scanning_department_query(t *testing.T) {
@@ -434,15 +503,15 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_scanning_department_quer
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnError(errors.New("error executing sql query"))
 
 	employeeService := &EmployeeService{EmployeeManager: db}
@@ -450,9 +519,7 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_scanning_department_quer
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, http.StatusInternalServerError, updateError.ResponseStatusCode)
-	assert.Equal(t, "error scanning sql select query", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error scanning sql select query")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_wrong_department_data(t *testing.T) {
@@ -470,15 +537,15 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_wrong_department_data(t
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnRows(employeeRows(1))
 
 	employeeService := &EmployeeService{EmployeeManager: db}
@@ -486,9 +553,7 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_wrong_department_data(t
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, http.StatusInternalServerError, updateError.ResponseStatusCode)
-	assert.Equal(t, "error scanning sql select query", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error scanning sql select query")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_preparing_employee_department_query(t *testing.T) {
@@ -506,29 +571,31 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_preparing_employee_depar
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnRows(departmentRows(1))
 
+	mock.ExpectBegin()
+
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
 		WillReturnError(errors.New("error preparing sql query"))
 
+	mock.ExpectRollback()
+
 	employeeService := &EmployeeService{EmployeeManager: db}
 
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, http.StatusInternalServerError, updateError.ResponseStatusCode)
-	assert.Equal(t, "error preparing sql select query for employee department", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error preparing sql select query for employee department")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_getting_employee_department_data(t *testing.T) {
@@ -546,31 +613,33 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_getting_employee_departm
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnRows(departmentRows(1))
 
+	mock.ExpectBegin()
+
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnError(errors.New("error executing sql query"))
 
+	mock.ExpectRollback()
+
 	employeeService := &EmployeeService{EmployeeManager: db}
 
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, http.StatusInternalServerError, updateError.ResponseStatusCode)
-	assert.Equal(t, "error scanning sql select query for employee department", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error scanning sql select query for employee department")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_Creating_new_record_preparing_query(t *testing.T) {
@@ -588,34 +657,36 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_Creating_new_record_prep
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnRows(departmentRows(1))
 
+	mock.ExpectBegin()
+
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnError(sql.ErrNoRows)
 
-	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlInsertEmployeeDepartmentQuery(employeeDepartmentUpdate))).
+	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlInsertEmployeeDepartmentQuery())).
 		WillReturnError(errors.New("error preparing insert sql query"))
 
+	mock.ExpectRollback()
+
 	employeeService := &EmployeeService{EmployeeManager: db}
 
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, http.StatusInternalServerError, updateError.ResponseStatusCode)
-	assert.Equal(t, "error preparing sql insert query for employee department", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error preparing sql insert query for employee department")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_Creating_new_record_executing_query(t *testing.T) {
@@ -633,35 +704,37 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_Creating_new_record_exec
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnRows(departmentRows(1))
 
+	mock.ExpectBegin()
+
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnError(sql.ErrNoRows)
 
-	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlInsertEmployeeDepartmentQuery(employeeDepartmentUpdate))).
-		ExpectExec().WithArgs().
+	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlInsertEmployeeDepartmentQuery())).
+		ExpectExec().WithArgs(insertEmployeeDepartmentArgs(employeeDepartmentUpdate)...).
 		WillReturnError(errors.New("error preparing insert sql query"))
 
+	mock.ExpectRollback()
+
 	employeeService := &EmployeeService{EmployeeManager: db}
 
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, http.StatusInternalServerError, updateError.ResponseStatusCode)
-	assert.Equal(t, "error executing sql insert query for employee department", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error executing sql insert query for employee department")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_Updating_preparing_query(t *testing.T) {
@@ -679,34 +752,36 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_Updating_preparing_query
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnRows(departmentRows(1))
 
+	mock.ExpectBegin()
+
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeDepartmentRows(1))
 
-	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlUpdateEmployeeDepartmentQuery(employeeDepartmentUpdate))).
+	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlUpdateEmployeeDepartmentQuery())).
 		WillReturnError(errors.New("error preparing update query"))
 
+	mock.ExpectRollback()
+
 	employeeService := &EmployeeService{EmployeeManager: db}
 
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, http.StatusInternalServerError, updateError.ResponseStatusCode)
-	assert.Equal(t, "error preparing sql update query for employee department", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error preparing sql update query for employee department")
 }
 
 func TestEmployeeService_UpdateEmployeeDepartment_Fails_Updating_executing_query(t *testing.T) {
@@ -724,34 +799,248 @@ func TestEmployeeService_UpdateEmployeeDepartment_Fails_Updating_executing_query
 	}
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeRows(1))
 
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery("d005"))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs("d005").
 		WillReturnRows(departmentRows(1))
 
+	mock.ExpectBegin()
+
 	mock.
-		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery(10002))).
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
 		ExpectQuery().
-		WithArgs().
+		WithArgs(10002).
 		WillReturnRows(employeeDepartmentRows(1))
 
-	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlUpdateEmployeeDepartmentQuery(employeeDepartmentUpdate))).
-		ExpectExec().WithArgs().WillReturnError(errors.New("error executing update query"))
+	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlUpdateEmployeeDepartmentQuery())).
+		ExpectExec().WithArgs(updateEmployeeDepartmentArgs(employeeDepartmentUpdate, "d006")...).WillReturnError(errors.New("error executing update query"))
+
+	mock.ExpectRollback()
 
 	employeeService := &EmployeeService{EmployeeManager: db}
 
 	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-	assert.NotNil(t, updateError.Error)
-	assert.Equal(t, http.StatusInternalServerError, updateError.ResponseStatusCode)
-	assert.Equal(t, "error executing sql update query for employee department", updateError.ErrorMessage)
+	assertEmployeeError(t, updateError, KindInternal, "error executing sql update query for employee department")
+}
+
+func TestEmployeeService_UpdateEmployeeDepartment_Conflicts_with_existing_department(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	employeeDepartmentUpdate := models.EmployeeDepartment{
+		EmployeeNumber: 10002,
+		Department:     "d005",
+		FromDate:       time.Date(1994, 11, 9, 7, 30, 00, 0, time.UTC),
+		ToDate:         time.Date(1994, 11, 10, 7, 30, 00, 0, time.UTC),
+	}
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
+		ExpectQuery().
+		WithArgs(10002).
+		WillReturnRows(employeeRows(1))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectDepartmentQuery())).
+		ExpectQuery().
+		WithArgs("d005").
+		WillReturnRows(departmentRows(1))
+
+	mock.ExpectBegin()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeDepartmentQuery())).
+		ExpectQuery().
+		WithArgs(10002).
+		WillReturnRows(employeeDepartmentRows(1))
+
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '10002-d005' for key 'PRIMARY'"}
+	mock.ExpectPrepare(regexp.QuoteMeta(mockSqlUpdateEmployeeDepartmentQuery())).
+		ExpectExec().WithArgs(updateEmployeeDepartmentArgs(employeeDepartmentUpdate, "d006")...).WillReturnError(duplicate)
+
+	mock.ExpectRollback()
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), employeeDepartmentUpdate)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, &Error{
+		Kind:   KindConflict,
+		Code:   CodeEmployeeDepartmentConflict,
+		Detail: "the employee already belongs to the department for these dates",
+		Err:    duplicate,
+	}, updateError)
+}
+
+func TestEmployeeService_UpdateEmployeeDepartment_Rejects_invalid_fields(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	updateError := employeeService.UpdateEmployeeDepartment(context.Background(), models.EmployeeDepartment{
+		FromDate: time.Date(1994, 11, 10, 0, 0, 0, 0, time.UTC),
+		ToDate:   time.Date(1994, 11, 9, 0, 0, 0, 0, time.UTC),
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, &Error{
+		Kind:   KindValidation,
+		Code:   problem.CodeValidationFailed,
+		Detail: "the request has invalid fields",
+		Fields: []problem.FieldError{
+			{Field: "emp_no", Code: "min", Message: "must be at least 1"},
+			{Field: "dept_no", Code: "required", Message: "is required"},
+			{Field: "to_date", Code: "not_before", Message: "must not be before from_date"},
+		},
+	}, updateError)
+}
+
+func TestEmployeeService_StreamEmployees_Sends_every_employee(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectAllQuery("e.emp_no", "asc")) + "$").
+		ExpectQuery().
+		WillReturnRows(employeeRowsWithDepartment(3))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	var sent []models.Employee
+	err = employeeService.StreamEmployees(context.Background(), map[string]string{"order_by_column": "emp_no", "order": "asc"}, func(employee models.Employee) error {
+		sent = append(sent, employee)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, []models.Employee{mockEmployee(), mockEmployee(), mockEmployee()}, sent)
+}
+
+func TestEmployeeService_StreamEmployees_Stops_when_send_fails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectAllQuery("e.emp_no", "asc")) + " LIMIT 2$").
+		ExpectQuery().
+		WillReturnRows(employeeRowsWithDepartment(2))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	sendError := errors.New("client went away")
+	calls := 0
+	err = employeeService.StreamEmployees(context.Background(), map[string]string{"order_by_column": "emp_no", "order": "asc", "limit": "2"}, func(models.Employee) error {
+		calls++
+		return sendError
+	})
+	assert.ErrorIs(t, err, sendError)
+	assert.Equal(t, 1, calls)
+}
+
+func TestEmployeeService_GetEmployee_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(employeeQuery)).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(employeeRowsWithDepartment(1))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	employee, err := employeeService.GetEmployee(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, mockEmployee(), *employee)
+}
+
+func TestEmployeeService_GetEmployee_Fails_When_Employee_not_exists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(employeeQuery)).
+		ExpectQuery().
+		WithArgs(10002).
+		WillReturnError(sql.ErrNoRows)
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	employee, err := employeeService.GetEmployee(context.Background(), 10002)
+	assert.Nil(t, employee)
+	assertEmployeeError(t, err, KindNotFound, "employee not found")
+}
+
+func TestEmployeeService_ListDepartments_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(departmentsQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name"}).AddRow("d005", "Development").AddRow("d006", "Quality Management"))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	departments, err := employeeService.ListDepartments(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, []models.Department{
+		{DepartmentNumber: "d005", DepartmentName: "Development"},
+		{DepartmentNumber: "d006", DepartmentName: "Quality Management"},
+	}, departments)
+}
+
+func TestEmployeeService_ListDepartments_Fails_doing_select_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectQuery(regexp.QuoteMeta(departmentsQuery)).
+		WillReturnError(errors.New("error executing query in database"))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	departments, err := employeeService.ListDepartments(context.Background())
+	assert.Nil(t, departments)
+	assertEmployeeError(t, err, KindInternal, "error executing sql select query for departments")
 }
 
 func employeeRows(rowCount int) *sqlmock.Rows {
@@ -988,32 +1277,38 @@ func mockSqlSelectQuery(columnName, order, limit, offset string) string {
 	return sqlSelectQueryExpected
 }
 
-func mockSqlSelectEmployeeQuery(employeeID int) string {
-	sqlSelectQueryExpected := fmt.Sprintf("SELECT * FROM employees WHERE emp_no=%d", employeeID)
-	return sqlSelectQueryExpected
+func mockSqlSelectAllQuery(columnName, order string) string {
+	return "SELECT e.emp_no, e.birth_date, e.first_name, e.last_name, e.gender, e.hire_date, d.dept_name FROM employees e JOIN dept_emp de ON e.emp_no= de.emp_no JOIN departments d on de.dept_no = d.dept_no ORDER BY " + fmt.Sprintf("%s %s", columnName, order)
 }
 
-func mockSqlSelectDepartmentQuery(department string) string {
-	sqlSelectQueryExpected := fmt.Sprintf("SELECT * FROM departments WHERE dept_no='%s'", department)
-	return sqlSelectQueryExpected
+func mockSqlSelectEmployeeQuery() string {
+	return "SELECT * FROM employees WHERE emp_no = ?"
 }
 
-func mockSqlSelectEmployeeDepartmentQuery(employeeID int) string {
-	sqlSelectQueryExpected := fmt.Sprintf("SELECT * FROM dept_emp WHERE emp_no=%d", employeeID)
-	return sqlSelectQueryExpected
+func mockSqlSelectDepartmentQuery() string {
+	return "SELECT * FROM departments WHERE dept_no = ?"
 }
 
-func mockSqlUpdateEmployeeDepartmentQuery(employeeDepartment models.EmployeeDepartment) string {
-	sqlSelectQueryExpected := fmt.Sprintf("UPDATE dept_emp SET dept_no='%s', from_date='%s', to_date='%s' WHERE emp_no=%d",
-		employeeDepartment.Department, employeeDepartment.FromDate.Format("2006-01-02 15:04:05"),
-		employeeDepartment.ToDate.Format("2006-01-02 15:04:05"), employeeDepartment.EmployeeNumber)
-	return sqlSelectQueryExpected
+func mockSqlSelectEmployeeDepartmentQuery() string {
+	return "SELECT * FROM dept_emp WHERE emp_no = ? ORDER BY to_date DESC, from_date DESC LIMIT 1 FOR UPDATE"
 }
 
-func mockSqlInsertEmployeeDepartmentQuery(employeeDepartment models.EmployeeDepartment) string {
-	sqlSelectQueryExpected := fmt.Sprintf("INSERT INTO dept_emp (emp_no, dept_no, from_date, to_date) VALUES (%d, '%s', '%s', '%s')",
-		employeeDepartment.EmployeeNumber, employeeDepartment.Department, employeeDepartment.FromDate.Format("2006-01-02 15:04:05"), employeeDepartment.ToDate.Format("2006-01-02 15:04:05"))
-	return sqlSelectQueryExpected
+func mockSqlUpdateEmployeeDepartmentQuery() string {
+	return "UPDATE dept_emp SET dept_no = ?, from_date = ?, to_date = ? WHERE emp_no = ? AND dept_no = ?"
+}
+
+func updateEmployeeDepartmentArgs(employeeDepartment models.EmployeeDepartment, previousDepartment string) []driver.Value {
+	return []driver.Value{employeeDepartment.Department, employeeDepartment.FromDate.Format("2006-01-02 15:04:05"),
+		employeeDepartment.ToDate.Format("2006-01-02 15:04:05"), employeeDepartment.EmployeeNumber, previousDepartment}
+}
+
+func mockSqlInsertEmployeeDepartmentQuery() string {
+	return "INSERT INTO dept_emp (emp_no, dept_no, from_date, to_date) VALUES (?, ?, ?, ?)"
+}
+
+func insertEmployeeDepartmentArgs(employeeDepartment models.EmployeeDepartment) []driver.Value {
+	return []driver.Value{employeeDepartment.EmployeeNumber, employeeDepartment.Department,
+		employeeDepartment.FromDate.Format("2006-01-02 15:04:05"), employeeDepartment.ToDate.Format("2006-01-02 15:04:05")}
 }
 
 func mockCountQuery() string {
@@ -1030,3 +1325,57 @@ func mockParameters() map[string]string {
 
 	return parameters
 }
+
+func TestEmployeeService_GetEmployees_Records_spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("e.emp_no", "asc", "1", "1"))).
+		ExpectQuery().
+		WillReturnRows(employeeRowsWithDepartment(1))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockCountQuery())).
+		ExpectQuery().
+		WillReturnError(errors.New("error executing query in database"))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	_, err = employeeService.GetEmployees(context.Background(), mockParameters())
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "sql getEmployeesPage", spans[0].Name())
+	assert.Equal(t, "sql getTotalEmployees", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "EmployeeService.GetEmployees", spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+	assert.Equal(t, spans[2].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, spans[2].SpanContext().SpanID(), spans[1].Parent().SpanID())
+}
+
+func expectAudit(mock sqlmock.Sqlmock, action string, before, after driver.Value) {
+	mock.
+		ExpectPrepare(regexp.QuoteMeta("INSERT INTO audit_log")).
+		ExpectExec().
+		WithArgs(audit.SystemActor, "", action, 10002, before, after).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func assertEmployeeError(t *testing.T, err error, kind ErrorKind, detail string) {
+	var employeeError *Error
+	if assert.ErrorAs(t, err, &employeeError) {
+		assert.Equal(t, kind, employeeError.Kind)
+		assert.Equal(t, detail, employeeError.Detail)
+	}
+}
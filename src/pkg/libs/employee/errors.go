@@ -0,0 +1,80 @@
+package employee
+
+import (
+	"employee_exercise/src/pkg/libs/problem"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+)
+
+type ErrorKind string
+
+const (
+	KindNotFound   ErrorKind = "not_found"
+	KindConflict   ErrorKind = "conflict"
+	KindValidation ErrorKind = "validation"
+	KindForbidden  ErrorKind = "forbidden"
+	KindInternal   ErrorKind = "internal"
+)
+
+// Stable codes of the errors EmployeeService returns.
+const (
+	CodeEmployeeNotFound           = "employee_not_found"
+	CodeDepartmentNotFound         = "department_not_found"
+	CodeEmployeeDepartmentNotFound = "employee_department_not_found"
+	CodeEmployeeDepartmentConflict = "employee_department_conflict"
+)
+
+// mysqlDuplicateEntry is the MySQL error number of a primary or unique key violation.
+const mysqlDuplicateEntry = 1062
+
+// Error is the error EmployeeService returns. Code and Detail are safe to show to clients, Err is the
+// cause and is only logged.
+type Error struct {
+	Kind   ErrorKind
+	Code   string
+	Detail string
+	Fields []problem.FieldError
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func notFound(code, detail string, err error) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Detail: detail, Err: err}
+}
+
+func forbidden(err error) *Error {
+	return &Error{Kind: KindForbidden, Code: problem.CodeForbidden, Detail: "forbidden", Err: err}
+}
+
+func invalid(fields ...problem.FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: problem.CodeValidationFailed, Detail: "the request has invalid fields", Fields: fields}
+}
+
+// internal wraps a failure the client cannot do anything about. Detail describes it for the logs.
+func internal(detail string, err error) *Error {
+	return &Error{Kind: KindInternal, Code: problem.CodeInternal, Detail: detail, Err: err}
+}
+
+// writeFailure is internal, except for a duplicate key, which is a conflict with an existing row.
+func writeFailure(detail string, err error) *Error {
+	var mysqlError *mysql.MySQLError
+	if errors.As(err, &mysqlError) && mysqlError.Number == mysqlDuplicateEntry {
+		return &Error{
+			Kind:   KindConflict,
+			Code:   CodeEmployeeDepartmentConflict,
+			Detail: "the employee already belongs to the department for these dates",
+			Err:    err,
+		}
+	}
+	return internal(detail, err)
+}
@@ -0,0 +1,155 @@
+package employee
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/tracing"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const managedDepartmentsQuery = "SELECT dept_no FROM dept_manager WHERE emp_no = ? AND from_date <= ? AND to_date > ?"
+
+// departmentScope is the set of departments whose current members a caller may see. A nil scope is not
+// restricted, an empty one sees nobody.
+type departmentScope []string
+
+// departmentScope restricts callers that may only read the employees of their department to the departments
+// they currently manage. Calls without a principal, such as from commands, are not restricted.
+func (e *EmployeeService) departmentScope(ctx context.Context) (departmentScope, error) {
+	principal := auth.PrincipalFromContext(ctx)
+	if principal == nil || principal.Can(auth.PermissionReadEmployees) {
+		return nil, nil
+	}
+
+	scope := departmentScope{}
+	if !principal.Can(auth.PermissionReadDepartmentEmployees) || principal.EmployeeNumber == 0 {
+		return scope, nil
+	}
+
+	ctx, span := tracing.StartSQL(ctx, "getManagedDepartments", managedDepartmentsQuery)
+	defer span.End()
+
+	stmt, err := e.EmployeeManager.PrepareContext(ctx, managedDepartmentsQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql select query for managed departments", "emp_no", principal.EmployeeNumber, "error", err)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	today := e.today()
+	rows, err := stmt.QueryContext(ctx, principal.EmployeeNumber, today, today)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql select query for managed departments", "emp_no", principal.EmployeeNumber, "error", err)
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var department string
+		if err = rows.Scan(&department); err != nil {
+			slog.ErrorContext(ctx, "error scanning sql select query for managed departments", "emp_no", principal.EmployeeNumber, "error", err)
+			tracing.RecordError(span, err)
+			return nil, err
+		}
+		scope = append(scope, department)
+	}
+
+	return scope, rows.Err()
+}
+
+// checkEmployeeVisible answers not found, as if the employee did not exist, when the employee is out of the caller's scope.
+func (e *EmployeeService) checkEmployeeVisible(ctx context.Context, employeeID int) error {
+	scope, err := e.departmentScope(ctx)
+	if err != nil {
+		return internal("error getting managed departments", err)
+	}
+
+	if scope == nil {
+		return nil
+	}
+
+	members := 0
+	if len(scope) != 0 {
+		where, arguments := scope.where(e.today())
+		query := "SELECT COUNT(*) FROM dept_emp de" + where + " AND de.emp_no = ?"
+		ctx, span := tracing.StartSQL(ctx, "checkEmployeeVisible", query)
+		defer span.End()
+
+		stmt, err := e.EmployeeManager.PrepareContext(ctx, query)
+		if err != nil {
+			slog.ErrorContext(ctx, "error preparing sql employee department scope query", "emp_no", employeeID, "error", err)
+			tracing.RecordError(span, err)
+			return internal("error preparing sql employee department scope query", err)
+		}
+
+		defer stmt.Close()
+
+		err = stmt.QueryRowContext(ctx, append(arguments, employeeID)...).Scan(&members)
+		if err != nil {
+			slog.ErrorContext(ctx, "error checking employee department scope", "emp_no", employeeID, "error", err)
+			tracing.RecordError(span, err)
+			return internal("error checking employee department", err)
+		}
+	}
+
+	if members == 0 {
+		slog.InfoContext(ctx, "employee out of department scope", "emp_no", employeeID)
+		return notFound(CodeEmployeeNotFound, "employee not found", ErrForbidden)
+	}
+
+	return nil
+}
+
+// where returns the condition limiting the dept_emp rows aliased de to current members of the scope and its arguments.
+func (s departmentScope) where(today string) (string, []interface{}) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(s)), ", ")
+	arguments := make([]interface{}, 0, len(s)+2)
+	for _, department := range s {
+		arguments = append(arguments, department)
+	}
+	arguments = append(arguments, today, today)
+
+	return " WHERE de.dept_no IN (" + placeholders + ") AND de.from_date <= ? AND de.to_date > ?", arguments
+}
+
+// restrict returns the condition keeping the rows whose employee number in column is a current member of the
+// scope and its arguments, empty for an unrestricted scope.
+func (s departmentScope) restrict(column, today string) (string, []interface{}) {
+	if s == nil {
+		return "", nil
+	}
+
+	where, arguments := s.where(today)
+	return " AND " + column + " IN (SELECT de.emp_no FROM dept_emp de" + where + ")", arguments
+}
+
+// VisibleCondition returns the condition keeping the rows whose employee number in column the caller may see at
+// asOf and its arguments, for readers outside this service such as the org chart. Managers see the members at
+// asOf of the departments they manage today. visible is false when the caller may see no employee at all.
+func (e *EmployeeService) VisibleCondition(ctx context.Context, column string, asOf time.Time) (condition string, arguments []interface{}, visible bool, err error) {
+	scope, err := e.departmentScope(ctx)
+	if err != nil {
+		return "", nil, false, internal("error getting managed departments", err)
+	}
+
+	if scope != nil && len(scope) == 0 {
+		return "", nil, false, nil
+	}
+
+	condition, arguments = scope.restrict(column, asOf.Format(dateLayout))
+	return condition, arguments, true, nil
+}
+
+func (e *EmployeeService) today() string {
+	now := time.Now
+	if e.now != nil {
+		now = e.now
+	}
+	return now().Format(dateLayout)
+}
@@ -0,0 +1,189 @@
+package employee
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/models"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+const scopedWhere = " WHERE de.dept_no IN (?, ?) AND de.from_date <= ? AND de.to_date > ?"
+
+func TestEmployeeService_GetEmployees_Scopes_managers_to_their_departments(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectManagedDepartments(mock, 110022, "d001", "d004")
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta("SELECT e.emp_no, e.birth_date, e.first_name, e.last_name, e.gender, e.hire_date, d.dept_name "+
+			"FROM employees e JOIN dept_emp de ON e.emp_no= de.emp_no JOIN departments d on de.dept_no = d.dept_no"+scopedWhere+
+			" ORDER BY e.emp_no asc LIMIT 1 OFFSET 1")).
+		ExpectQuery().
+		WithArgs("d001", "d004", "2024-03-01", "2024-03-01").
+		WillReturnRows(employeeRowsWithDepartment(1))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta("SELECT COUNT(DISTINCT de.emp_no) FROM dept_emp de"+scopedWhere)).
+		ExpectQuery().
+		WithArgs("d001", "d004", "2024-03-01", "2024-03-01").
+		WillReturnRows(countRows(1))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	employeesResponse, err := employeeService.GetEmployees(withPrincipal(auth.RoleManager), mockParameters())
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, expectedEmployeeResponse(), *employeesResponse)
+}
+
+func TestEmployeeService_GetEmployees_Returns_nobody_to_former_managers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectManagedDepartments(mock, 110022)
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	employeesResponse, err := employeeService.GetEmployees(withPrincipal(auth.RoleManager), mockParameters())
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, models.EmployeeResponse{}, *employeesResponse)
+}
+
+func TestEmployeeService_GetEmployees_Does_not_scope_hr(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectQuery("e.emp_no", "asc", "1", "1"))).
+		ExpectQuery().
+		WithArgs().
+		WillReturnRows(employeeRowsWithDepartment(1))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockCountQuery())).
+		ExpectQuery().
+		WithArgs().
+		WillReturnRows(countRows(1))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	employeesResponse, err := employeeService.GetEmployees(withPrincipal(auth.RoleManager, auth.RoleHR), mockParameters())
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, expectedEmployeeResponse(), *employeesResponse)
+}
+
+func TestEmployeeService_GetEmployeeTimeline_Hides_employees_out_of_scope(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	expectManagedDepartments(mock, 110022, "d001", "d004")
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta("SELECT COUNT(*) FROM dept_emp de"+scopedWhere+" AND de.emp_no = ?")).
+		ExpectQuery().
+		WithArgs("d001", "d004", "2024-03-01", "2024-03-01", 1).
+		WillReturnRows(countRows(0))
+
+	employeeService := &EmployeeService{EmployeeManager: db, now: fixedNow}
+
+	timeline, timelineError := employeeService.GetEmployeeTimeline(withPrincipal(auth.RoleManager), 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, timeline)
+	assert.Equal(t, notFound(CodeEmployeeNotFound, "employee not found", ErrForbidden), timelineError)
+}
+
+func TestEmployeeService_GetEmployeeTimeline_Omits_salaries_without_permission(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(employeeRows(1))
+
+	for _, query := range []string{timelineDepartmentsQuery, timelineManagersQuery} {
+		mock.
+			ExpectPrepare(regexp.QuoteMeta(query)).
+			ExpectQuery().
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name", "from_date", "to_date"}))
+	}
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(timelineTitlesQuery)).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "from_date", "to_date"}).
+			AddRow("Engineer", day(2022, 6, 20), day(9999, 1, 1)))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	timeline, timelineError := employeeService.GetEmployeeTimeline(withPrincipal(auth.RoleHR), 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, timelineError)
+	assert.Equal(t, []models.TimelineEvent{
+		{Date: day(2022, 6, 20), Type: EventTitleChange, Title: "Engineer"},
+		{Date: time.Date(2022, 06, 20, 15, 00, 00, 0, time.UTC), Type: EventHired},
+	}, timeline.Events)
+}
+
+func TestEmployeeService_UpdateEmployeeDepartment_Fails_without_permission(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	updateError := employeeService.UpdateEmployeeDepartment(withPrincipal(auth.RoleManager), models.EmployeeDepartment{EmployeeNumber: 10002, Department: "d005"})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, forbidden(ErrForbidden), updateError)
+}
+
+func expectManagedDepartments(mock sqlmock.Sqlmock, employeeID int, departments ...string) {
+	rows := sqlmock.NewRows([]string{"dept_no"})
+	for _, department := range departments {
+		rows.AddRow(department)
+	}
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(managedDepartmentsQuery)).
+		ExpectQuery().
+		WithArgs(employeeID, "2024-03-01", "2024-03-01").
+		WillReturnRows(rows)
+}
+
+func withPrincipal(roles ...string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "jwt:bob", Roles: roles, EmployeeNumber: 110022})
+}
+
+func fixedNow() time.Time {
+	return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,223 @@
+package employee
+
+import (
+	"context"
+	"database/sql"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/tracing"
+	"employee_exercise/src/pkg/models"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+const (
+	EventHired           = "hired"
+	EventDepartmentJoin  = "department_joined"
+	EventDepartmentLeave = "department_left"
+	EventTitleChange     = "title_changed"
+	EventSalaryChange    = "salary_changed"
+	EventManagerStart    = "manager_started"
+	EventManagerEnd      = "manager_ended"
+
+	timelineDepartmentsQuery = "SELECT de.dept_no, d.dept_name, de.from_date, de.to_date FROM dept_emp de " +
+		"JOIN departments d ON de.dept_no = d.dept_no WHERE de.emp_no = ? ORDER BY de.from_date"
+	timelineManagersQuery = "SELECT dm.dept_no, d.dept_name, dm.from_date, dm.to_date FROM dept_manager dm " +
+		"JOIN departments d ON dm.dept_no = d.dept_no WHERE dm.emp_no = ? ORDER BY dm.from_date"
+	timelineTitlesQuery   = "SELECT title, from_date, to_date FROM titles WHERE emp_no = ? ORDER BY from_date"
+	timelineSalariesQuery = "SELECT salary, from_date, to_date FROM salaries WHERE emp_no = ? ORDER BY from_date"
+
+	openEndedYear = 9999
+)
+
+var eventOrder = map[string]int{
+	EventHired:           0,
+	EventManagerEnd:      1,
+	EventDepartmentLeave: 2,
+	EventDepartmentJoin:  3,
+	EventManagerStart:    4,
+	EventTitleChange:     5,
+	EventSalaryChange:    6,
+}
+
+type TimelineRecord struct {
+	Department     string
+	DepartmentName string
+	Title          string
+	Salary         int
+	FromDate       time.Time
+	ToDate         time.Time
+}
+
+func (e *EmployeeService) GetEmployeeTimeline(ctx context.Context, employeeID int) (*models.EmployeeTimeline, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "EmployeeService.GetEmployeeTimeline")
+	defer span.End()
+
+	if visibleError := e.checkEmployeeVisible(ctx, employeeID); visibleError != nil {
+		return nil, visibleError
+	}
+
+	employee, getError := e.getEmployeeByID(ctx, employeeID)
+	if getError != nil {
+		return nil, getError
+	}
+
+	departments, departmentsError := e.getTimelineRecords(ctx, timelineDepartmentsQuery, employeeID, func(rows *sql.Rows, record *TimelineRecord) error {
+		return rows.Scan(&record.Department, &record.DepartmentName, &record.FromDate, &record.ToDate)
+	})
+	if departmentsError != nil {
+		return nil, departmentsError
+	}
+
+	managers, managersError := e.getTimelineRecords(ctx, timelineManagersQuery, employeeID, func(rows *sql.Rows, record *TimelineRecord) error {
+		return rows.Scan(&record.Department, &record.DepartmentName, &record.FromDate, &record.ToDate)
+	})
+	if managersError != nil {
+		return nil, managersError
+	}
+
+	titles, titlesError := e.getTimelineRecords(ctx, timelineTitlesQuery, employeeID, func(rows *sql.Rows, record *TimelineRecord) error {
+		return rows.Scan(&record.Title, &record.FromDate, &record.ToDate)
+	})
+	if titlesError != nil {
+		return nil, titlesError
+	}
+
+	var salaries []TimelineRecord
+	if principal := auth.PrincipalFromContext(ctx); principal == nil || principal.Can(auth.PermissionReadSalaries) {
+		var salariesError error
+		salaries, salariesError = e.getTimelineRecords(ctx, timelineSalariesQuery, employeeID, func(rows *sql.Rows, record *TimelineRecord) error {
+			return rows.Scan(&record.Salary, &record.FromDate, &record.ToDate)
+		})
+		if salariesError != nil {
+			return nil, salariesError
+		}
+	}
+
+	return &models.EmployeeTimeline{
+		EmployeeNumber: employee.EmployeeNumber,
+		FirstName:      employee.FirstName,
+		LastName:       employee.LastName,
+		Events:         BuildTimeline(employee.HireDate, departments, managers, titles, salaries),
+	}, nil
+}
+
+func (e *EmployeeService) getTimelineRecords(ctx context.Context, query string, employeeID int, scan func(*sql.Rows, *TimelineRecord) error) ([]TimelineRecord, error) {
+	ctx, span := tracing.StartSQL(ctx, "getTimelineRecords", query)
+	defer span.End()
+
+	stmt, err := e.EmployeeManager.PrepareContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql timeline query for employee", "emp_no", employeeID, "error", err)
+		tracing.RecordError(span, err)
+		return nil, internal("error preparing sql timeline query", err)
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, employeeID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql timeline query for employee", "emp_no", employeeID, "error", err)
+		tracing.RecordError(span, err)
+		return nil, internal("error executing sql timeline query", err)
+	}
+
+	defer rows.Close()
+
+	var records []TimelineRecord
+	for rows.Next() {
+		record := TimelineRecord{}
+		if err = scan(rows, &record); err != nil {
+			slog.ErrorContext(ctx, "error scanning sql timeline query for employee", "emp_no", employeeID, "error", err)
+			tracing.RecordError(span, err)
+			return nil, internal("error scanning sql timeline query", err)
+		}
+
+		records = append(records, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "error reading sql timeline query for employee", "emp_no", employeeID, "error", err)
+		tracing.RecordError(span, err)
+		return nil, internal("error reading sql timeline query", err)
+	}
+
+	return records, nil
+}
+
+// BuildTimeline merges the employee history tables into a single chronological list of typed events.
+// Open ended ranges (to_date 9999-01-01) do not produce an end event.
+func BuildTimeline(hireDate time.Time, departments, managers, titles, salaries []TimelineRecord) []models.TimelineEvent {
+	events := []models.TimelineEvent{{Date: hireDate, Type: EventHired}}
+
+	for _, department := range departments {
+		events = append(events, models.TimelineEvent{
+			Date:           department.FromDate,
+			Type:           EventDepartmentJoin,
+			Department:     department.Department,
+			DepartmentName: department.DepartmentName,
+		})
+		if department.ToDate.Year() < openEndedYear {
+			events = append(events, models.TimelineEvent{
+				Date:           department.ToDate,
+				Type:           EventDepartmentLeave,
+				Department:     department.Department,
+				DepartmentName: department.DepartmentName,
+			})
+		}
+	}
+
+	for _, manager := range managers {
+		events = append(events, models.TimelineEvent{
+			Date:           manager.FromDate,
+			Type:           EventManagerStart,
+			Department:     manager.Department,
+			DepartmentName: manager.DepartmentName,
+		})
+		if manager.ToDate.Year() < openEndedYear {
+			events = append(events, models.TimelineEvent{
+				Date:           manager.ToDate,
+				Type:           EventManagerEnd,
+				Department:     manager.Department,
+				DepartmentName: manager.DepartmentName,
+			})
+		}
+	}
+
+	previousTitle := ""
+	for _, title := range titles {
+		if title.Title == previousTitle {
+			continue
+		}
+		events = append(events, models.TimelineEvent{
+			Date:          title.FromDate,
+			Type:          EventTitleChange,
+			Title:         title.Title,
+			PreviousTitle: previousTitle,
+		})
+		previousTitle = title.Title
+	}
+
+	previousSalary := 0
+	for _, salary := range salaries {
+		if salary.Salary == previousSalary {
+			continue
+		}
+		events = append(events, models.TimelineEvent{
+			Date:           salary.FromDate,
+			Type:           EventSalaryChange,
+			Salary:         salary.Salary,
+			PreviousSalary: previousSalary,
+		})
+		previousSalary = salary.Salary
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Date.Equal(events[j].Date) {
+			return events[i].Date.Before(events[j].Date)
+		}
+		return eventOrder[events[i].Type] < eventOrder[events[j].Type]
+	})
+
+	return events
+}
@@ -0,0 +1,189 @@
+package employee
+
+import (
+	"context"
+	"database/sql"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	departments := []TimelineRecord{
+		{Department: "d004", DepartmentName: "Production", FromDate: day(1990, 1, 1), ToDate: day(1995, 6, 1)},
+		{Department: "d005", DepartmentName: "Development", FromDate: day(1995, 6, 1), ToDate: day(9999, 1, 1)},
+	}
+	managers := []TimelineRecord{
+		{Department: "d005", DepartmentName: "Development", FromDate: day(1998, 1, 1), ToDate: day(9999, 1, 1)},
+	}
+	titles := []TimelineRecord{
+		{Title: "Engineer", FromDate: day(1990, 1, 1), ToDate: day(1995, 6, 1)},
+		{Title: "Senior Engineer", FromDate: day(1995, 6, 1), ToDate: day(9999, 1, 1)},
+	}
+	salaries := []TimelineRecord{
+		{Salary: 40000, FromDate: day(1990, 1, 1), ToDate: day(1991, 1, 1)},
+		{Salary: 40000, FromDate: day(1991, 1, 1), ToDate: day(1992, 1, 1)},
+		{Salary: 45000, FromDate: day(1992, 1, 1), ToDate: day(9999, 1, 1)},
+	}
+
+	events := BuildTimeline(day(1990, 1, 1), departments, managers, titles, salaries)
+
+	assert.Equal(t, []models.TimelineEvent{
+		{Date: day(1990, 1, 1), Type: EventHired},
+		{Date: day(1990, 1, 1), Type: EventDepartmentJoin, Department: "d004", DepartmentName: "Production"},
+		{Date: day(1990, 1, 1), Type: EventTitleChange, Title: "Engineer"},
+		{Date: day(1990, 1, 1), Type: EventSalaryChange, Salary: 40000},
+		{Date: day(1992, 1, 1), Type: EventSalaryChange, Salary: 45000, PreviousSalary: 40000},
+		{Date: day(1995, 6, 1), Type: EventDepartmentLeave, Department: "d004", DepartmentName: "Production"},
+		{Date: day(1995, 6, 1), Type: EventDepartmentJoin, Department: "d005", DepartmentName: "Development"},
+		{Date: day(1995, 6, 1), Type: EventTitleChange, Title: "Senior Engineer", PreviousTitle: "Engineer"},
+		{Date: day(1998, 1, 1), Type: EventManagerStart, Department: "d005", DepartmentName: "Development"},
+	}, events)
+}
+
+func TestEmployeeService_GetEmployeeTimeline_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(employeeRows(1))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(timelineDepartmentsQuery)).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name", "from_date", "to_date"}).
+			AddRow("d005", "Development", day(2022, 6, 20), day(9999, 1, 1)))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(timelineManagersQuery)).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name", "from_date", "to_date"}))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(timelineTitlesQuery)).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "from_date", "to_date"}).
+			AddRow("Engineer", day(2022, 6, 20), day(9999, 1, 1)))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(timelineSalariesQuery)).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"salary", "from_date", "to_date"}).
+			AddRow(50000, day(2022, 6, 20), day(9999, 1, 1)))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	timeline, timelineError := employeeService.GetEmployeeTimeline(context.Background(), 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, timelineError)
+	assert.Equal(t, &models.EmployeeTimeline{
+		EmployeeNumber: 1,
+		FirstName:      "Lucas",
+		LastName:       "Lissandrello",
+		Events: []models.TimelineEvent{
+			{Date: day(2022, 6, 20), Type: EventDepartmentJoin, Department: "d005", DepartmentName: "Development"},
+			{Date: day(2022, 6, 20), Type: EventTitleChange, Title: "Engineer"},
+			{Date: day(2022, 6, 20), Type: EventSalaryChange, Salary: 50000},
+			{Date: time.Date(2022, 06, 20, 15, 00, 00, 0, time.UTC), Type: EventHired},
+		},
+	}, timeline)
+}
+
+func TestEmployeeService_GetEmployeeTimeline_Fails_When_Employee_not_exists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	timeline, timelineError := employeeService.GetEmployeeTimeline(context.Background(), 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, timeline)
+	assert.Equal(t, notFound(CodeEmployeeNotFound, "employee not found", sql.ErrNoRows), timelineError)
+}
+
+func TestEmployeeService_GetEmployeeTimeline_Fails_executing_timeline_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(employeeRows(1))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(timelineDepartmentsQuery)).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnError(errors.New("error executing query in database"))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	timeline, timelineError := employeeService.GetEmployeeTimeline(context.Background(), 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, timeline)
+	assert.Equal(t, internal("error executing sql timeline query", errors.New("error executing query in database")), timelineError)
+}
+
+func TestEmployeeService_GetEmployeeTimeline_Fails_reading_timeline_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(mockSqlSelectEmployeeQuery())).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(employeeRows(1))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(timelineDepartmentsQuery)).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name", "from_date", "to_date"}).
+			AddRow("d005", "Development", day(2022, 6, 20), day(9999, 1, 1)).
+			RowError(0, errors.New("connection reset")))
+
+	employeeService := &EmployeeService{EmployeeManager: db}
+
+	timeline, timelineError := employeeService.GetEmployeeTimeline(context.Background(), 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, timeline)
+	assert.Equal(t, internal("error reading sql timeline query", errors.New("connection reset")), timelineError)
+}
+
+func day(year int, month time.Month, dayOfMonth int) time.Time {
+	return time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC)
+}
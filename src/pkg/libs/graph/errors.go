@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/libs/problem"
+	"errors"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"log/slog"
+)
+
+// Codes of the errors of a response, besides the ones shared with the REST API.
+const (
+	CodeInvalidQuery    = "invalid_query"
+	CodeQueryTooDeep    = "query_too_deep"
+	CodeQueryTooComplex = "query_too_complex"
+)
+
+// Error is an error of a response, with the stable code of its cause in its extensions.
+type Error struct {
+	Message    string          `json:"message"`
+	Locations  []Location      `json:"locations,omitempty"`
+	Path       []interface{}   `json:"path,omitempty"`
+	Extensions ErrorExtensions `json:"extensions"`
+}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type ErrorExtensions struct {
+	Code   string               `json:"code"`
+	Errors []problem.FieldError `json:"errors,omitempty"`
+}
+
+// queryError is an error of a field the client can act on.
+type queryError struct {
+	code    string
+	message string
+	fields  []problem.FieldError
+}
+
+func (e *queryError) Error() string {
+	return e.message
+}
+
+func invalidArguments(fields []problem.FieldError) error {
+	return &queryError{code: problem.CodeValidationFailed, message: "the query has invalid arguments", fields: fields}
+}
+
+var clientErrorKinds = map[employee.ErrorKind]bool{
+	employee.KindNotFound:   true,
+	employee.KindConflict:   true,
+	employee.KindValidation: true,
+	employee.KindForbidden:  true,
+}
+
+// responseErrors maps the errors of an execution to response errors. Errors of a field are answered with the
+// code and detail of their cause, and causes that are not known client errors are logged and answered with
+// a bare internal error, so they never reach the client. Errors outside fields, such as invalid variables,
+// are the client's.
+func responseErrors(ctx context.Context, formatted []gqlerrors.FormattedError) []Error {
+	var responses []Error
+	for _, err := range formatted {
+		response := Error{Message: err.Message, Path: err.Path, Extensions: ErrorExtensions{Code: CodeInvalidQuery}}
+		for _, location := range err.Locations {
+			response.Locations = append(response.Locations, Location{Line: location.Line, Column: location.Column})
+		}
+
+		if len(err.Path) != 0 {
+			cause := causeOf(err)
+			var fieldError *queryError
+			var employeeError *employee.Error
+			switch {
+			case errors.As(cause, &fieldError):
+				response.Message = fieldError.message
+				response.Extensions = ErrorExtensions{Code: fieldError.code, Errors: fieldError.fields}
+			case errors.As(cause, &employeeError) && clientErrorKinds[employeeError.Kind]:
+				response.Message = employeeError.Detail
+				response.Extensions = ErrorExtensions{Code: employeeError.Code, Errors: employeeError.Fields}
+			default:
+				slog.ErrorContext(ctx, "graphql field failed", "path", err.Path, "error", cause)
+				response.Message = "internal server error"
+				response.Extensions = ErrorExtensions{Code: problem.CodeInternal}
+			}
+		}
+
+		responses = append(responses, response)
+	}
+
+	return responses
+}
+
+// causeOf unwraps the errors the executor wraps the error of a resolver in.
+func causeOf(err error) error {
+	for {
+		var next error
+		switch wrapper := err.(type) {
+		case gqlerrors.FormattedError:
+			next = wrapper.OriginalError()
+		case *gqlerrors.Error:
+			next = wrapper.OriginalError
+		}
+
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
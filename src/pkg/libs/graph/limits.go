@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"strconv"
+	"strings"
+)
+
+// defaultListSize is the number of items a list field without a limit argument is expected to answer, such as
+// the titles of an employee.
+const defaultListSize = 10
+
+// measurer measures the operation of a document before it runs.
+type measurer struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// measure returns the depth and complexity of the operation of document named operationName, or of its only
+// operation. The depth is the deepest nesting of fields, the top level ones being at depth 1. The complexity
+// is the number of fields the query may resolve: every field costs 1, and the fields below a list are counted
+// once per item it may hold, its limit argument or defaultListSize. Introspection fields are free, so tools
+// can always load the schema.
+func measure(schema *graphql.Schema, document *ast.Document, operationName string, variables map[string]interface{}) (int, int) {
+	m := measurer{schema: schema, fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			m.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+
+	if operation == nil || operation.Operation != ast.OperationTypeQuery {
+		return 0, 0
+	}
+	return m.selectionSet(schema.QueryType(), operation.SelectionSet, 1)
+}
+
+// selectionSet returns the depth and complexity of the fields of set, selected on parent at depth.
+func (m measurer) selectionSet(parent *graphql.Object, set *ast.SelectionSet, depth int) (int, int) {
+	if parent == nil || set == nil {
+		return 0, 0
+	}
+
+	maxDepth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		selectionDepth, selectionComplexity := 0, 0
+		switch selection := selection.(type) {
+		case *ast.Field:
+			selectionDepth, selectionComplexity = m.field(parent, selection, depth)
+		case *ast.InlineFragment:
+			selectionDepth, selectionComplexity = m.selectionSet(m.fragmentType(parent, selection.TypeCondition), selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			if fragment, ok := m.fragments[selection.Name.Value]; ok {
+				selectionDepth, selectionComplexity = m.selectionSet(m.fragmentType(parent, fragment.TypeCondition), fragment.SelectionSet, depth)
+			}
+		}
+
+		maxDepth = max(maxDepth, selectionDepth)
+		complexity += selectionComplexity
+	}
+
+	return maxDepth, complexity
+}
+
+func (m measurer) field(parent *graphql.Object, field *ast.Field, depth int) (int, int) {
+	definition, ok := parent.Fields()[field.Name.Value]
+	if !ok || strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+
+	items := 1
+	fieldType := definition.Type
+	if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+		fieldType = nonNull.OfType
+	}
+	if list, ok := fieldType.(*graphql.List); ok {
+		items = m.listSize(field, definition)
+		fieldType = list.OfType
+		if nonNull, ok := fieldType.(*graphql.NonNull); ok {
+			fieldType = nonNull.OfType
+		}
+	}
+
+	object, _ := fieldType.(*graphql.Object)
+	childDepth, childComplexity := m.selectionSet(object, field.SelectionSet, depth+1)
+	return max(depth, childDepth), 1 + items*childComplexity
+}
+
+// listSize is the number of items a list field may answer: its limit argument, as given or by default, or
+// defaultListSize for lists without one. The size is kept within 1 and maxPageSize, so an out of range limit,
+// which the resolver rejects anyway, cannot lower the complexity of its sibling fields.
+func (m measurer) listSize(field *ast.Field, definition *graphql.FieldDefinition) int {
+	return min(max(m.limit(field, definition), 1), maxPageSize)
+}
+
+func (m measurer) limit(field *ast.Field, definition *graphql.FieldDefinition) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if limit, err := strconv.Atoi(value.Value); err == nil {
+				return limit
+			}
+		case *ast.Variable:
+			switch limit := m.variables[value.Name.Value].(type) {
+			case int:
+				return limit
+			case float64:
+				return int(limit)
+			}
+		}
+	}
+
+	for _, argument := range definition.Args {
+		if limit, ok := argument.DefaultValue.(int); ok && argument.Name() == "limit" {
+			return limit
+		}
+	}
+	return defaultListSize
+}
+
+// fragmentType is the type a fragment selects on, parent when it has no type condition.
+func (m measurer) fragmentType(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	object, _ := m.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
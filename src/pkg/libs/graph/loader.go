@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"context"
+	"employee_exercise/src/pkg/models"
+	"sync"
+)
+
+// loader batches the loads of one kind of row made while a query is resolved. Each load queues its key and
+// returns a thunk, the executor calls the thunks once every field of a level is resolved, and the first one
+// called fetches all the queued keys at once, so a level costs one query whatever the number of objects in it.
+type loader[K comparable, V any] struct {
+	ctx     context.Context
+	fetch   func(context.Context, []K) (map[K]V, error)
+	mutex   sync.Mutex
+	pending []K
+	results map[K]*result[V]
+}
+
+type result[V any] struct {
+	value   V
+	found   bool
+	err     error
+	fetched bool
+}
+
+func newLoader[K comparable, V any](ctx context.Context, fetch func(context.Context, []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{ctx: ctx, fetch: fetch, results: map[K]*result[V]{}}
+}
+
+// load queues key and returns a thunk answering its value, and false when there is none.
+func (l *loader[K, V]) load(key K) func() (V, bool, error) {
+	l.mutex.Lock()
+	if _, ok := l.results[key]; !ok {
+		l.results[key] = &result[V]{}
+		l.pending = append(l.pending, key)
+	}
+	l.mutex.Unlock()
+
+	return func() (V, bool, error) {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+
+		loaded := l.results[key]
+		if !loaded.fetched {
+			l.flush()
+		}
+		return loaded.value, loaded.found, loaded.err
+	}
+}
+
+// flush fetches every queued key.
+func (l *loader[K, V]) flush() {
+	keys := l.pending
+	l.pending = nil
+
+	values, err := l.fetch(l.ctx, keys)
+	for _, key := range keys {
+		loaded := l.results[key]
+		loaded.value, loaded.found = values[key]
+		loaded.err = err
+		loaded.fetched = true
+	}
+}
+
+// loaders are the loaders of one request, so rows are only shared between the fields of a single query.
+type loaders struct {
+	employees          *loader[int, models.Employee]
+	departments        *loader[int, models.Department]
+	employeeManagers   *loader[int, models.Employee]
+	departmentManagers *loader[string, models.Employee]
+	titles             *loader[int, []models.Title]
+	salaries           *loader[int, []models.Salary]
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, employees EmployeeManager) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		employees:          newLoader(ctx, employees.EmployeesByNumber),
+		departments:        newLoader(ctx, employees.CurrentDepartments),
+		employeeManagers:   newLoader(ctx, employees.EmployeeManagers),
+		departmentManagers: newLoader(ctx, employees.DepartmentManagers),
+		titles:             newLoader(ctx, employees.Titles),
+		salaries:           newLoader(ctx, employees.Salaries),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,361 @@
+package graph
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/privacy"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/validation"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"github.com/graphql-go/graphql"
+	"strconv"
+)
+
+type EmployeeManager interface {
+	StreamEmployees(ctx context.Context, parameters map[string]string, send func(models.Employee) error) error
+	ListDepartments(ctx context.Context) ([]models.Department, error)
+	EmployeesByNumber(ctx context.Context, numbers []int) (map[int]models.Employee, error)
+	CurrentDepartments(ctx context.Context, numbers []int) (map[int]models.Department, error)
+	EmployeeManagers(ctx context.Context, numbers []int) (map[int]models.Employee, error)
+	DepartmentManagers(ctx context.Context, departmentNumbers []string) (map[string]models.Employee, error)
+	Titles(ctx context.Context, numbers []int) (map[int][]models.Title, error)
+	Salaries(ctx context.Context, numbers []int) (map[int][]models.Salary, error)
+}
+
+// maxPageSize is the largest limit of the employees listing, as on GET /employees.
+const maxPageSize = 1000
+
+var readEmployees = []auth.Permission{auth.PermissionReadEmployees, auth.PermissionReadDepartmentEmployees}
+
+// object is a model in its masked JSON form, with the key its nested fields are loaded by.
+type object struct {
+	key    interface{}
+	fields map[string]interface{}
+}
+
+type resolvers struct {
+	employees EmployeeManager
+	masker    *privacy.Masker
+}
+
+// newSchema builds the schema over employees and departments. Fields are named as in the REST API, and
+// those the privacy policy may mask are nullable.
+func newSchema(r *resolvers, defaultPageSize int) (graphql.Schema, error) {
+	title := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Title",
+		Fields: graphql.Fields{
+			"title":     {Type: graphql.String},
+			"from_date": {Type: graphql.String},
+			"to_date":   {Type: graphql.String},
+		},
+	})
+
+	salary := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Salary",
+		Fields: graphql.Fields{
+			"salary":    {Type: graphql.Int},
+			"from_date": {Type: graphql.String},
+			"to_date":   {Type: graphql.String},
+		},
+	})
+
+	var employee, department *graphql.Object
+	employee = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Employee",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"emp_no":     {Type: graphql.NewNonNull(graphql.Int), Resolve: key},
+				"birth_date": {Type: graphql.String, Resolve: value("birth_date")},
+				"first_name": {Type: graphql.String, Resolve: value("first_name")},
+				"last_name":  {Type: graphql.String, Resolve: value("last_name")},
+				"gender":     {Type: graphql.String, Resolve: value("gender")},
+				"hire_date":  {Type: graphql.String, Resolve: value("hire_date")},
+				"department": {
+					Type:        department,
+					Description: "The department the employee belongs to today.",
+					Resolve:     r.employeeDepartment,
+				},
+				"manager": {
+					Type:        employee,
+					Description: "The manager of the department the employee belongs to today.",
+					Resolve:     r.employeeManager,
+				},
+				"titles": {
+					Type:    graphql.NewList(graphql.NewNonNull(title)),
+					Resolve: r.titles,
+				},
+				"salaries": {
+					Type:        graphql.NewList(graphql.NewNonNull(salary)),
+					Description: "Requires the read:salaries permission.",
+					Resolve:     r.salaries,
+				},
+			}
+		}),
+	})
+
+	department = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Department",
+		Fields: graphql.Fields{
+			"dept_no":   {Type: graphql.NewNonNull(graphql.String), Resolve: key},
+			"dept_name": {Type: graphql.String, Resolve: value("dept_name")},
+			"manager": {
+				Type:        employee,
+				Description: "The current manager of the department.",
+				Resolve:     r.departmentManager,
+			},
+		},
+	})
+
+	orderBy := graphql.NewEnum(graphql.EnumConfig{
+		Name: "EmployeeOrderBy",
+		Values: graphql.EnumValueConfigMap{
+			"emp_no":     {Value: "emp_no"},
+			"birth_date": {Value: "birth_date"},
+			"first_name": {Value: "first_name"},
+			"last_name":  {Value: "last_name"},
+			"gender":     {Value: "gender"},
+			"hire_date":  {Value: "hire_date"},
+		},
+	})
+
+	order := graphql.NewEnum(graphql.EnumConfig{
+		Name: "Order",
+		Values: graphql.EnumValueConfigMap{
+			"asc":  {Value: "asc"},
+			"desc": {Value: "desc"},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"employees": {
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(employee))),
+				Args: graphql.FieldConfigArgument{
+					"limit":    {Type: graphql.Int, DefaultValue: defaultPageSize},
+					"page":     {Type: graphql.Int, DefaultValue: 1},
+					"order_by": {Type: orderBy, DefaultValue: "first_name"},
+					"order":    {Type: order, DefaultValue: "asc"},
+				},
+				Resolve: r.listEmployees,
+			},
+			"employee": {
+				Type:        employee,
+				Description: "Null when there is no such employee, or the caller may not see them.",
+				Args: graphql.FieldConfigArgument{
+					"emp_no": {Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: r.employee,
+			},
+			"departments": {
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(department))),
+				Resolve: r.departments,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+func (r *resolvers) listEmployees(p graphql.ResolveParams) (interface{}, error) {
+	if err := authorize(p.Context, readEmployees...); err != nil {
+		return nil, err
+	}
+
+	limit, _ := p.Args["limit"].(int)
+	page, _ := p.Args["page"].(int)
+	var violations []problem.FieldError
+	if limit < 1 {
+		violations = append(violations, problem.FieldError{Field: "limit", Code: validation.CodeMin, Message: "must be at least 1"})
+	} else if limit > maxPageSize {
+		violations = append(violations, problem.FieldError{Field: "limit", Code: validation.CodeMax, Message: "must be at most " + strconv.Itoa(maxPageSize)})
+	}
+	if page < 1 {
+		violations = append(violations, problem.FieldError{Field: "page", Code: validation.CodeMin, Message: "must be at least 1"})
+	}
+	if len(violations) != 0 {
+		return nil, invalidArguments(violations)
+	}
+
+	parameters := map[string]string{
+		"order_by_column": p.Args["order_by"].(string),
+		"order":           p.Args["order"].(string),
+		"limit":           strconv.Itoa(limit),
+		"offset":          strconv.Itoa(limit * (page - 1)),
+	}
+	employees := []models.Employee{}
+	err := r.employees.StreamEmployees(p.Context, parameters, func(employee models.Employee) error {
+		employees = append(employees, employee)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []map[string]interface{}
+	if err = r.mask(p.Context, employees, &fields); err != nil {
+		return nil, err
+	}
+
+	objects := make([]interface{}, 0, len(employees))
+	for i, employee := range employees {
+		objects = append(objects, object{key: employee.EmployeeNumber, fields: fields[i]})
+	}
+	return objects, nil
+}
+
+func (r *resolvers) employee(p graphql.ResolveParams) (interface{}, error) {
+	if err := authorize(p.Context, readEmployees...); err != nil {
+		return nil, err
+	}
+
+	employeeNumber := p.Args["emp_no"].(int)
+	if employeeNumber < 1 {
+		return nil, invalidArguments([]problem.FieldError{{Field: "emp_no", Code: validation.CodeMin, Message: "must be at least 1"}})
+	}
+
+	return one(p.Context, loadersFrom(p.Context).employees.load(employeeNumber), r.employeeObject), nil
+}
+
+func (r *resolvers) departments(p graphql.ResolveParams) (interface{}, error) {
+	if err := authorize(p.Context, auth.PermissionReadDepartments); err != nil {
+		return nil, err
+	}
+
+	departments, err := r.employees.ListDepartments(p.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]interface{}, 0, len(departments))
+	for _, department := range departments {
+		departmentObject, err := r.departmentObject(p.Context, department)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, departmentObject)
+	}
+	return objects, nil
+}
+
+func (r *resolvers) employeeDepartment(p graphql.ResolveParams) (interface{}, error) {
+	employeeNumber := p.Source.(object).key.(int)
+	return one(p.Context, loadersFrom(p.Context).departments.load(employeeNumber), r.departmentObject), nil
+}
+
+func (r *resolvers) employeeManager(p graphql.ResolveParams) (interface{}, error) {
+	employeeNumber := p.Source.(object).key.(int)
+	return one(p.Context, loadersFrom(p.Context).employeeManagers.load(employeeNumber), r.employeeObject), nil
+}
+
+func (r *resolvers) departmentManager(p graphql.ResolveParams) (interface{}, error) {
+	if err := authorize(p.Context, readEmployees...); err != nil {
+		return nil, err
+	}
+
+	departmentNumber := p.Source.(object).key.(string)
+	return one(p.Context, loadersFrom(p.Context).departmentManagers.load(departmentNumber), r.employeeObject), nil
+}
+
+func (r *resolvers) titles(p graphql.ResolveParams) (interface{}, error) {
+	employeeNumber := p.Source.(object).key.(int)
+	return many(p.Context, loadersFrom(p.Context).titles.load(employeeNumber), r.maskList), nil
+}
+
+func (r *resolvers) salaries(p graphql.ResolveParams) (interface{}, error) {
+	employeeNumber := p.Source.(object).key.(int)
+	return many(p.Context, loadersFrom(p.Context).salaries.load(employeeNumber), r.maskList), nil
+}
+
+func (r *resolvers) employeeObject(ctx context.Context, employee models.Employee) (interface{}, error) {
+	var fields map[string]interface{}
+	if err := r.mask(ctx, employee, &fields); err != nil {
+		return nil, err
+	}
+	return object{key: employee.EmployeeNumber, fields: fields}, nil
+}
+
+func (r *resolvers) departmentObject(ctx context.Context, department models.Department) (interface{}, error) {
+	var fields map[string]interface{}
+	if err := r.mask(ctx, department, &fields); err != nil {
+		return nil, err
+	}
+	return object{key: department.DepartmentNumber, fields: fields}, nil
+}
+
+// maskList masks a list of rows, answering an empty list for none.
+func (r *resolvers) maskList(ctx context.Context, rows interface{}) (interface{}, error) {
+	items := []map[string]interface{}{}
+	if err := r.mask(ctx, rows, &items); err != nil {
+		return nil, err
+	}
+	if items == nil {
+		return []map[string]interface{}{}, nil
+	}
+	return items, nil
+}
+
+// mask decodes the JSON form of v, with the caller's personal data masked, into fields. So the values are
+// the ones the REST API answers: RFC 3339 dates, generalized or redacted fields, and omitted fields missing.
+func (r *resolvers) mask(ctx context.Context, v interface{}, fields interface{}) error {
+	masked, err := r.masker.Mask(ctx, v)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(masked)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, fields)
+}
+
+// one adapts a loader thunk to the executor, which calls it once the level of the query is resolved. It
+// answers null when the loader found nothing.
+func one[V any](ctx context.Context, load func() (V, bool, error), convert func(context.Context, V) (interface{}, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		loaded, found, err := load()
+		if err != nil || !found {
+			return nil, err
+		}
+		return convert(ctx, loaded)
+	}
+}
+
+// many is one for list fields, which answer an empty list when the loader found nothing.
+func many[V any](ctx context.Context, load func() ([]V, bool, error), convert func(context.Context, interface{}) (interface{}, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		loaded, _, err := load()
+		if err != nil {
+			return nil, err
+		}
+		return convert(ctx, loaded)
+	}
+}
+
+func key(p graphql.ResolveParams) (interface{}, error) {
+	return p.Source.(object).key, nil
+}
+
+// value resolves a field of an object from its masked JSON form.
+func value(name string) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return p.Source.(object).fields[name], nil
+	}
+}
+
+// authorize lets principals holding one of permissions through. Calls without a principal are not restricted.
+func authorize(ctx context.Context, permissions ...auth.Permission) error {
+	principal := auth.PrincipalFromContext(ctx)
+	if principal == nil {
+		return nil
+	}
+
+	for _, permission := range permissions {
+		if principal.Can(permission) {
+			return nil
+		}
+	}
+	return &queryError{code: problem.CodeForbidden, message: "forbidden"}
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"employee_exercise/src/pkg/libs/privacy"
+	"employee_exercise/src/pkg/libs/tracing"
+	"employee_exercise/src/pkg/models"
+	"fmt"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"log/slog"
+)
+
+// Response is the result of a query. Data is missing when the query was rejected before it ran.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// Server runs GraphQL queries over the employee service, with the same permissions, department scope and
+// masking as the REST API.
+type Server struct {
+	schema    graphql.Schema
+	employees EmployeeManager
+	limits    config.GraphQLConfig
+}
+
+// NewServer returns a server whose employees listing answers defaultPageSize employees when the limit
+// argument is missing, and which rejects queries over limits.
+func NewServer(employees EmployeeManager, masker *privacy.Masker, defaultPageSize int, limits config.GraphQLConfig) (*Server, error) {
+	schema, err := newSchema(&resolvers{employees: employees, masker: masker}, defaultPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{schema: schema, employees: employees, limits: limits}, nil
+}
+
+// Execute runs a query. Queries that do not parse, are invalid against the schema or go over the limits are
+// answered with errors only, without reading anything.
+func (s *Server) Execute(ctx context.Context, request models.GraphQLRequest) *Response {
+	ctx, span := tracing.Tracer().Start(ctx, "graph.Execute")
+	defer span.End()
+
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(request.Query), Name: "GraphQL request"})})
+	if err != nil {
+		return &Response{Errors: responseErrors(ctx, gqlerrors.FormatErrors(err))}
+	}
+
+	if result := graphql.ValidateDocument(&s.schema, document, nil); !result.IsValid {
+		return &Response{Errors: responseErrors(ctx, result.Errors)}
+	}
+
+	depth, complexity := measure(&s.schema, document, request.OperationName, request.Variables)
+	if s.limits.MaxDepth > 0 && depth > s.limits.MaxDepth {
+		slog.InfoContext(ctx, "graphql query rejected", "depth", depth, "max_depth", s.limits.MaxDepth)
+		return rejected(CodeQueryTooDeep, fmt.Sprintf("the query is %d fields deep, more than the limit of %d", depth, s.limits.MaxDepth))
+	}
+	if s.limits.MaxComplexity > 0 && complexity > s.limits.MaxComplexity {
+		slog.InfoContext(ctx, "graphql query rejected", "complexity", complexity, "max_complexity", s.limits.MaxComplexity)
+		return rejected(CodeQueryTooComplex, fmt.Sprintf("the query may resolve %d fields, more than the limit of %d", complexity, s.limits.MaxComplexity))
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           document,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       withLoaders(ctx, s.employees),
+	})
+
+	return &Response{Data: result.Data, Errors: responseErrors(ctx, result.Errors)}
+}
+
+func rejected(code, message string) *Response {
+	return &Response{Errors: []Error{{Message: message, Extensions: ErrorExtensions{Code: code}}}}
+}
@@ -0,0 +1,406 @@
+package graph
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
+	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/libs/privacy"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type EmployeeManagerMock struct {
+	employees   map[int]models.Employee
+	departments map[int]models.Department
+	managers    map[string]int
+	titles      map[int][]models.Title
+	salaries    map[int][]models.Salary
+	errs        map[string]error
+	parameters  map[string]string
+	calls       map[string][]interface{}
+}
+
+func newEmployeeManagerMock() *EmployeeManagerMock {
+	date := func(year int) time.Time { return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return &EmployeeManagerMock{
+		employees: map[int]models.Employee{
+			10001:  {EmployeeNumber: 10001, BirthDate: date(1953), FirstName: "Georgi", LastName: "Facello", Gender: "M", HireDate: date(1986)},
+			10002:  {EmployeeNumber: 10002, BirthDate: date(1964), FirstName: "Bezalel", LastName: "Simmel", Gender: "F", HireDate: date(1985)},
+			110022: {EmployeeNumber: 110022, BirthDate: date(1956), FirstName: "Margareta", LastName: "Markovitch", Gender: "M", HireDate: date(1985)},
+		},
+		departments: map[int]models.Department{
+			10001:  {DepartmentNumber: "d005", DepartmentName: "Development"},
+			10002:  {DepartmentNumber: "d007", DepartmentName: "Sales"},
+			110022: {DepartmentNumber: "d005", DepartmentName: "Development"},
+		},
+		managers: map[string]int{"d005": 110022},
+		titles: map[int][]models.Title{
+			10001: {{Title: "Senior Engineer", FromDate: date(1986), ToDate: date(9999)}},
+			10002: {{Title: "Staff", FromDate: date(1996), ToDate: date(9999)}},
+		},
+		salaries: map[int][]models.Salary{
+			10001: {{Salary: 60117, FromDate: date(1986), ToDate: date(1987)}, {Salary: 62102, FromDate: date(1987), ToDate: date(9999)}},
+		},
+		errs:  map[string]error{},
+		calls: map[string][]interface{}{},
+	}
+}
+
+func (e *EmployeeManagerMock) StreamEmployees(ctx context.Context, parameters map[string]string, send func(models.Employee) error) error {
+	e.parameters = parameters
+	e.calls["StreamEmployees"] = append(e.calls["StreamEmployees"], parameters)
+	if err := e.errs["StreamEmployees"]; err != nil {
+		return err
+	}
+	for _, employeeNumber := range []int{10001, 10002} {
+		if err := send(e.employees[employeeNumber]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *EmployeeManagerMock) ListDepartments(ctx context.Context) ([]models.Department, error) {
+	e.calls["ListDepartments"] = append(e.calls["ListDepartments"], nil)
+	return []models.Department{{DepartmentNumber: "d005", DepartmentName: "Development"}, {DepartmentNumber: "d007", DepartmentName: "Sales"}}, e.errs["ListDepartments"]
+}
+
+func (e *EmployeeManagerMock) EmployeesByNumber(ctx context.Context, numbers []int) (map[int]models.Employee, error) {
+	return loadMock(e, "EmployeesByNumber", numbers, func(number int) (models.Employee, bool) {
+		employee, ok := e.employees[number]
+		return employee, ok
+	})
+}
+
+func (e *EmployeeManagerMock) CurrentDepartments(ctx context.Context, numbers []int) (map[int]models.Department, error) {
+	return loadMock(e, "CurrentDepartments", numbers, func(number int) (models.Department, bool) {
+		department, ok := e.departments[number]
+		return department, ok
+	})
+}
+
+func (e *EmployeeManagerMock) EmployeeManagers(ctx context.Context, numbers []int) (map[int]models.Employee, error) {
+	return loadMock(e, "EmployeeManagers", numbers, func(number int) (models.Employee, bool) {
+		manager, ok := e.employees[e.managers[e.departments[number].DepartmentNumber]]
+		return manager, ok
+	})
+}
+
+func (e *EmployeeManagerMock) DepartmentManagers(ctx context.Context, departmentNumbers []string) (map[string]models.Employee, error) {
+	return loadMock(e, "DepartmentManagers", departmentNumbers, func(departmentNumber string) (models.Employee, bool) {
+		manager, ok := e.employees[e.managers[departmentNumber]]
+		return manager, ok
+	})
+}
+
+func (e *EmployeeManagerMock) Titles(ctx context.Context, numbers []int) (map[int][]models.Title, error) {
+	return loadMock(e, "Titles", numbers, func(number int) ([]models.Title, bool) {
+		titles, ok := e.titles[number]
+		return titles, ok
+	})
+}
+
+func (e *EmployeeManagerMock) Salaries(ctx context.Context, numbers []int) (map[int][]models.Salary, error) {
+	return loadMock(e, "Salaries", numbers, func(number int) ([]models.Salary, bool) {
+		salaries, ok := e.salaries[number]
+		return salaries, ok
+	})
+}
+
+// loadMock records a batched call with its keys and answers the values get finds.
+func loadMock[K comparable, V any](e *EmployeeManagerMock, method string, keys []K, get func(K) (V, bool)) (map[K]V, error) {
+	e.calls[method] = append(e.calls[method], keys)
+	if err := e.errs[method]; err != nil {
+		return nil, err
+	}
+
+	values := map[K]V{}
+	for _, key := range keys {
+		if value, ok := get(key); ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func newTestServer(t *testing.T, manager *EmployeeManagerMock, limits config.GraphQLConfig) *Server {
+	server, err := NewServer(manager, privacy.NewMasker(config.PIIConfig{Profile: "standard"}), 50, limits)
+	require.NoError(t, err)
+	return server
+}
+
+func withRoles(roles ...string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "jwt:alice", Roles: roles})
+}
+
+// execute runs query and returns the response in its JSON form.
+func execute(t *testing.T, server *Server, ctx context.Context, query string, variables map[string]interface{}) map[string]interface{} {
+	response := server.Execute(ctx, models.GraphQLRequest{Query: query, Variables: variables})
+	encoded, err := json.Marshal(response)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	return decoded
+}
+
+func TestServer_Execute_Batches_nested_fields(t *testing.T) {
+	manager := newEmployeeManagerMock()
+	server := newTestServer(t, manager, config.GraphQLConfig{})
+
+	response := execute(t, server, withRoles(auth.RoleHRAdmin), `{
+		employees(limit: 2, order_by: hire_date, order: desc) {
+			emp_no
+			first_name
+			department { dept_no dept_name }
+			manager { emp_no last_name department { dept_no } }
+			titles { title }
+			salaries { salary from_date }
+		}
+	}`, nil)
+
+	assert.Nil(t, response["errors"])
+	assert.Equal(t, map[string]string{"order_by_column": "hire_date", "order": "desc", "limit": "2", "offset": "0"}, manager.parameters)
+	assert.Equal(t, map[string]interface{}{"employees": []interface{}{
+		map[string]interface{}{
+			"emp_no":     float64(10001),
+			"first_name": "Georgi",
+			"department": map[string]interface{}{"dept_no": "d005", "dept_name": "Development"},
+			"manager":    map[string]interface{}{"emp_no": float64(110022), "last_name": "Markovitch", "department": map[string]interface{}{"dept_no": "d005"}},
+			"titles":     []interface{}{map[string]interface{}{"title": "Senior Engineer"}},
+			"salaries": []interface{}{
+				map[string]interface{}{"salary": float64(60117), "from_date": "1986-01-01T00:00:00Z"},
+				map[string]interface{}{"salary": float64(62102), "from_date": "1987-01-01T00:00:00Z"},
+			},
+		},
+		map[string]interface{}{
+			"emp_no":     float64(10002),
+			"first_name": "Bezalel",
+			"department": map[string]interface{}{"dept_no": "d007", "dept_name": "Sales"},
+			"manager":    nil,
+			"titles":     []interface{}{map[string]interface{}{"title": "Staff"}},
+			"salaries":   []interface{}{},
+		},
+	}}, response["data"])
+
+	// Every level reads its rows in one batch, however many employees the page holds.
+	assert.Equal(t, []interface{}{[]int{10001, 10002}}, manager.calls["Titles"])
+	assert.Equal(t, []interface{}{[]int{10001, 10002}}, manager.calls["Salaries"])
+	assert.Equal(t, []interface{}{[]int{10001, 10002}}, manager.calls["EmployeeManagers"])
+
+	// The departments of the managers are a level below, read with the others when the managers resolve first.
+	var departmentKeys []int
+	for _, keys := range manager.calls["CurrentDepartments"] {
+		departmentKeys = append(departmentKeys, keys.([]int)...)
+	}
+	assert.LessOrEqual(t, len(manager.calls["CurrentDepartments"]), 2)
+	assert.ElementsMatch(t, []int{10001, 10002, 110022}, departmentKeys)
+}
+
+func TestServer_Execute_Masks_personal_data(t *testing.T) {
+	manager := newEmployeeManagerMock()
+	server := newTestServer(t, manager, config.GraphQLConfig{})
+
+	response := execute(t, server, auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "jwt:alice", Roles: []string{auth.RoleHR}, EmployeeNumber: 1}),
+		`query ($emp_no: Int!) { employee(emp_no: $emp_no) { emp_no birth_date gender hire_date } }`, map[string]interface{}{"emp_no": 10001})
+
+	assert.Nil(t, response["errors"])
+	assert.Equal(t, map[string]interface{}{"employee": map[string]interface{}{
+		"emp_no":     float64(10001),
+		"birth_date": "1953",
+		"gender":     "****",
+		"hire_date":  "1986-01-01T00:00:00Z",
+	}}, response["data"])
+	assert.Equal(t, []interface{}{[]int{10001}}, manager.calls["EmployeesByNumber"])
+}
+
+func TestServer_Execute_Answers_null_for_unknown_employee(t *testing.T) {
+	server := newTestServer(t, newEmployeeManagerMock(), config.GraphQLConfig{})
+
+	response := execute(t, server, context.Background(), `{ employee(emp_no: 1) { emp_no } }`, nil)
+
+	assert.Nil(t, response["errors"])
+	assert.Equal(t, map[string]interface{}{"employee": nil}, response["data"])
+}
+
+func TestServer_Execute_Field_errors(t *testing.T) {
+	forbidden := &employee.Error{Kind: employee.KindForbidden, Code: problem.CodeForbidden, Detail: "forbidden", Err: employee.ErrForbidden}
+
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		query          string
+		errs           map[string]error
+		expectedData   interface{}
+		expectedErrors []interface{}
+	}{
+		{
+			name:         "salaries without read:salaries",
+			ctx:          withRoles(auth.RoleHR),
+			query:        `{ employee(emp_no: 10001) { emp_no salaries { salary } } }`,
+			errs:         map[string]error{"Salaries": forbidden},
+			expectedData: map[string]interface{}{"employee": map[string]interface{}{"emp_no": float64(10001), "salaries": nil}},
+			expectedErrors: []interface{}{map[string]interface{}{
+				"message":    "forbidden",
+				"locations":  []interface{}{map[string]interface{}{"line": float64(1), "column": float64(36)}},
+				"path":       []interface{}{"employee", "salaries"},
+				"extensions": map[string]interface{}{"code": "forbidden"},
+			}},
+		},
+		{
+			name:         "employees without a read permission",
+			ctx:          withRoles(auth.RoleAuditor),
+			query:        `{ employees { emp_no } }`,
+			expectedData: nil,
+			expectedErrors: []interface{}{map[string]interface{}{
+				"message":    "forbidden",
+				"locations":  []interface{}{map[string]interface{}{"line": float64(1), "column": float64(3)}},
+				"path":       []interface{}{"employees"},
+				"extensions": map[string]interface{}{"code": "forbidden"},
+			}},
+		},
+		{
+			name:         "departments without read:departments",
+			ctx:          withRoles(auth.RoleAuditor),
+			query:        `{ departments { dept_no } }`,
+			expectedData: nil,
+			expectedErrors: []interface{}{map[string]interface{}{
+				"message":    "forbidden",
+				"locations":  []interface{}{map[string]interface{}{"line": float64(1), "column": float64(3)}},
+				"path":       []interface{}{"departments"},
+				"extensions": map[string]interface{}{"code": "forbidden"},
+			}},
+		},
+		{
+			name:         "invalid limit and page",
+			ctx:          context.Background(),
+			query:        `{ employees(limit: 5000, page: 0) { emp_no } }`,
+			expectedData: nil,
+			expectedErrors: []interface{}{map[string]interface{}{
+				"message":   "the query has invalid arguments",
+				"locations": []interface{}{map[string]interface{}{"line": float64(1), "column": float64(3)}},
+				"path":      []interface{}{"employees"},
+				"extensions": map[string]interface{}{"code": "validation_failed", "errors": []interface{}{
+					map[string]interface{}{"field": "limit", "code": "max", "message": "must be at most 1000"},
+					map[string]interface{}{"field": "page", "code": "min", "message": "must be at least 1"},
+				}},
+			}},
+		},
+		{
+			name:         "failing batch",
+			ctx:          context.Background(),
+			query:        `{ employee(emp_no: 10001) { titles { title } } }`,
+			errs:         map[string]error{"Titles": errors.New("connection reset")},
+			expectedData: map[string]interface{}{"employee": map[string]interface{}{"titles": nil}},
+			expectedErrors: []interface{}{map[string]interface{}{
+				"message":    "internal server error",
+				"locations":  []interface{}{map[string]interface{}{"line": float64(1), "column": float64(29)}},
+				"path":       []interface{}{"employee", "titles"},
+				"extensions": map[string]interface{}{"code": "internal_error"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newEmployeeManagerMock()
+			for method, err := range tt.errs {
+				manager.errs[method] = err
+			}
+			server := newTestServer(t, manager, config.GraphQLConfig{})
+
+			response := execute(t, server, tt.ctx, tt.query, nil)
+
+			assert.Equal(t, tt.expectedData, response["data"])
+			if tt.expectedErrors == nil {
+				assert.Nil(t, response["errors"])
+			} else {
+				assert.Equal(t, tt.expectedErrors, response["errors"])
+			}
+		})
+	}
+}
+
+func TestServer_Execute_Rejects_queries(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		variables       map[string]interface{}
+		expectedCode    string
+		expectedMessage string
+	}{
+		{
+			name:            "syntax error",
+			query:           `{ employees { emp_no }`,
+			expectedCode:    CodeInvalidQuery,
+			expectedMessage: "Syntax Error GraphQL request (1:23) Expected Name, found EOF\n\n1: { employees { emp_no }\n                         ^\n",
+		},
+		{
+			name:            "unknown field",
+			query:           `{ employees { salary } }`,
+			expectedCode:    CodeInvalidQuery,
+			expectedMessage: `Cannot query field "salary" on type "Employee". Did you mean "salaries"?`,
+		},
+		{
+			name:            "too deep",
+			query:           `{ employee(emp_no: 1) { manager { manager { manager { department { manager { emp_no } } } } } } }`,
+			expectedCode:    CodeQueryTooDeep,
+			expectedMessage: "the query is 7 fields deep, more than the limit of 6",
+		},
+		{
+			name:            "too deep through fragments",
+			query:           `{ employee(emp_no: 1) { ...boss } } fragment boss on Employee { manager { manager { ... on Employee { manager { department { manager { emp_no } } } } } } }`,
+			expectedCode:    CodeQueryTooDeep,
+			expectedMessage: "the query is 7 fields deep, more than the limit of 6",
+		},
+		{
+			name:            "too complex by its limit",
+			query:           `query ($limit: Int) { employees(limit: $limit) { emp_no first_name last_name } }`,
+			variables:       map[string]interface{}{"limit": float64(400)},
+			expectedCode:    CodeQueryTooComplex,
+			expectedMessage: "the query may resolve 1201 fields, more than the limit of 1000",
+		},
+		{
+			name:            "too complex despite a negative limit on an alias",
+			query:           `{ cheap: employees(limit: -1000000) { emp_no } all: employees(limit: 400) { emp_no first_name last_name } }`,
+			expectedCode:    CodeQueryTooComplex,
+			expectedMessage: "the query may resolve 1203 fields, more than the limit of 1000",
+		},
+		{
+			name:            "too complex by the default page size",
+			query:           `{ employees { emp_no titles { title from_date to_date } } }`,
+			expectedCode:    CodeQueryTooComplex,
+			expectedMessage: "the query may resolve 1601 fields, more than the limit of 1000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newEmployeeManagerMock()
+			server := newTestServer(t, manager, config.GraphQLConfig{MaxDepth: 6, MaxComplexity: 1000})
+
+			response := execute(t, server, context.Background(), tt.query, tt.variables)
+
+			assert.NotContains(t, response, "data")
+			require.Len(t, response["errors"], 1)
+			queryError := response["errors"].([]interface{})[0].(map[string]interface{})
+			assert.Equal(t, tt.expectedMessage, queryError["message"])
+			assert.Equal(t, map[string]interface{}{"code": tt.expectedCode}, queryError["extensions"])
+			assert.Empty(t, manager.calls, "rejected queries read nothing")
+		})
+	}
+}
+
+func TestMeasure_Ignores_introspection(t *testing.T) {
+	server := newTestServer(t, newEmployeeManagerMock(), config.GraphQLConfig{MaxDepth: 2, MaxComplexity: 10})
+
+	response := execute(t, server, context.Background(), `{ __schema { types { name fields { name type { name ofType { name } } } } } }`, nil)
+
+	assert.Nil(t, response["errors"])
+	assert.NotNil(t, response["data"])
+}
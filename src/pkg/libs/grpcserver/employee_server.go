@@ -0,0 +1,167 @@
+package grpcserver
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/grpcserver/employeespb"
+	"employee_exercise/src/pkg/libs/privacy"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/validation"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"google.golang.org/protobuf/encoding/protojson"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EmployeeManager interface {
+	StreamEmployees(ctx context.Context, parameters map[string]string, send func(models.Employee) error) error
+	GetEmployee(ctx context.Context, employeeID int) (*models.Employee, error)
+	UpdateEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) error
+	ListDepartments(ctx context.Context) ([]models.Department, error)
+}
+
+// orderColumns are the columns ListEmployees can order by.
+var orderColumns = map[string]bool{
+	"emp_no": true, "birth_date": true, "first_name": true, "last_name": true, "gender": true, "hire_date": true,
+}
+
+// EmployeeServer serves employeespb.EmployeeService with the same service, permissions and masking as the
+// REST API.
+type EmployeeServer struct {
+	employeespb.UnimplementedEmployeeServiceServer
+	EmployeeService EmployeeManager
+	Masker          *privacy.Masker
+}
+
+func (s *EmployeeServer) ListEmployees(request *employeespb.ListEmployeesRequest, stream employeespb.EmployeeService_ListEmployeesServer) error {
+	parameters, violations := listParameters(request)
+	if len(violations) != 0 {
+		return invalidArgument(violations)
+	}
+
+	ctx := stream.Context()
+	err := s.EmployeeService.StreamEmployees(ctx, parameters, func(employee models.Employee) error {
+		message, err := s.employeeMessage(ctx, employee)
+		if err != nil {
+			return err
+		}
+		return stream.Send(message)
+	})
+	if err != nil {
+		return statusError(ctx, err)
+	}
+
+	return nil
+}
+
+// listParameters turns a listing request into the parameters of EmployeeService.StreamEmployees.
+func listParameters(request *employeespb.ListEmployeesRequest) (map[string]string, []problem.FieldError) {
+	var violations []problem.FieldError
+
+	orderBy := strings.ToLower(request.GetOrderBy())
+	if orderBy == "" {
+		orderBy = "first_name"
+	}
+	if !orderColumns[orderBy] {
+		violations = append(violations, problem.FieldError{Field: "order_by", Code: validation.CodeInvalid, Message: "must be one of emp_no, birth_date, first_name, last_name, gender, hire_date"})
+	}
+
+	order := strings.ToLower(request.GetOrder())
+	if order == "" {
+		order = "asc"
+	}
+	if order != "asc" && order != "desc" {
+		violations = append(violations, problem.FieldError{Field: "order", Code: validation.CodeInvalid, Message: "must be one of asc, desc"})
+	}
+
+	if request.GetLimit() < 0 {
+		violations = append(violations, problem.FieldError{Field: "limit", Code: validation.CodeMin, Message: "must be at least 0"})
+	}
+
+	parameters := map[string]string{"order_by_column": orderBy, "order": order}
+	if request.GetLimit() > 0 {
+		parameters["limit"] = strconv.Itoa(int(request.GetLimit()))
+	}
+	return parameters, violations
+}
+
+func (s *EmployeeServer) GetEmployee(ctx context.Context, request *employeespb.GetEmployeeRequest) (*employeespb.Employee, error) {
+	if request.GetEmpNo() < 1 {
+		return nil, invalidArgument([]problem.FieldError{{Field: "emp_no", Code: validation.CodeMin, Message: "must be at least 1"}})
+	}
+
+	employee, err := s.EmployeeService.GetEmployee(ctx, int(request.GetEmpNo()))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	message, err := s.employeeMessage(ctx, *employee)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return message, nil
+}
+
+func (s *EmployeeServer) TransferEmployee(ctx context.Context, request *employeespb.TransferEmployeeRequest) (*employeespb.TransferEmployeeResponse, error) {
+	employeeDepartmentRequest := models.EmployeeDepartmentRequest{
+		EmployeeNumber: int(request.GetEmpNo()),
+		Department:     request.GetDeptNo(),
+		FromDate:       request.GetFromDate(),
+		ToDate:         request.GetToDate(),
+	}
+	if violations := validation.Struct(employeeDepartmentRequest); len(violations) != 0 {
+		return nil, invalidArgument(violations)
+	}
+
+	fromDate, _ := time.Parse(validation.DateLayout, employeeDepartmentRequest.FromDate)
+	toDate, _ := time.Parse(validation.DateLayout, employeeDepartmentRequest.ToDate)
+	err := s.EmployeeService.UpdateEmployeeDepartment(ctx, models.EmployeeDepartment{
+		EmployeeNumber: employeeDepartmentRequest.EmployeeNumber,
+		Department:     employeeDepartmentRequest.Department,
+		FromDate:       fromDate,
+		ToDate:         toDate,
+	})
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	return &employeespb.TransferEmployeeResponse{}, nil
+}
+
+func (s *EmployeeServer) ListDepartments(ctx context.Context, _ *employeespb.ListDepartmentsRequest) (*employeespb.ListDepartmentsResponse, error) {
+	departments, err := s.EmployeeService.ListDepartments(ctx)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	response := &employeespb.ListDepartmentsResponse{}
+	for _, department := range departments {
+		response.Departments = append(response.Departments, &employeespb.Department{
+			DeptNo:   department.DepartmentNumber,
+			DeptName: department.DepartmentName,
+		})
+	}
+	return response, nil
+}
+
+// employeeMessage masks an employee for the caller and converts it through its JSON form, so the message
+// carries the same values as the REST API: RFC 3339 dates, generalized or redacted fields, and omitted
+// fields left empty.
+func (s *EmployeeServer) employeeMessage(ctx context.Context, employee models.Employee) (*employeespb.Employee, error) {
+	masked, err := s.Masker.Mask(ctx, employee)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(masked)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &employeespb.Employee{}
+	if err = (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(encoded, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
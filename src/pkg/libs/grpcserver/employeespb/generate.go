@@ -0,0 +1,4 @@
+// Package employeespb holds the gRPC API of the employees service, generated from employees.proto.
+package employeespb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative employees.proto
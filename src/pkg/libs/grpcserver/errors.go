@@ -0,0 +1,72 @@
+package grpcserver
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/libs/problem"
+	"errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+)
+
+// errorDomain is the domain of the ErrorInfo detail carrying the stable error code.
+const errorDomain = "employees.v1"
+
+var employeeErrorCodes = map[employee.ErrorKind]codes.Code{
+	employee.KindNotFound:   codes.NotFound,
+	employee.KindConflict:   codes.AlreadyExists,
+	employee.KindValidation: codes.InvalidArgument,
+	employee.KindForbidden:  codes.PermissionDenied,
+}
+
+// statusError maps a service error to its status, with the same code and detail as the REST problem in
+// an ErrorInfo. Anything that is not a known client error is logged and answered with a bare internal
+// error, so causes never reach the client.
+func statusError(ctx context.Context, err error) error {
+	var employeeError *employee.Error
+	if errors.As(err, &employeeError) {
+		if code, ok := employeeErrorCodes[employeeError.Kind]; ok {
+			return newStatus(code, employeeError.Code, employeeError.Detail, employeeError.Fields)
+		}
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	slog.ErrorContext(ctx, "rpc failed", "error", err)
+	return newStatus(codes.Internal, problem.CodeInternal, "internal server error", nil)
+}
+
+func invalidArgument(fields []problem.FieldError) error {
+	return newStatus(codes.InvalidArgument, problem.CodeValidationFailed, "the request has invalid fields", fields)
+}
+
+// newStatus builds a status whose details hold the error code and, for invalid requests, a BadRequest
+// listing every invalid field.
+func newStatus(code codes.Code, errorCode, message string, fields []problem.FieldError) error {
+	result, err := status.New(code, message).WithDetails(&errdetails.ErrorInfo{Reason: errorCode, Domain: errorDomain})
+	if err != nil {
+		return status.Error(code, message)
+	}
+
+	if len(fields) != 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Field,
+				Description: field.Message,
+			})
+		}
+		if withFields, err := result.WithDetails(badRequest); err == nil {
+			result = withFields
+		}
+	}
+
+	return result.Err()
+}
@@ -0,0 +1,128 @@
+package grpcserver
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/grpcserver/employeespb"
+	"employee_exercise/src/pkg/libs/logging"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/tracing"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Metadata keys, gRPC lower cases them.
+const (
+	RequestIDMetadata     = "x-request-id"
+	APIKeyMetadata        = "x-api-key"
+	AuthorizationMetadata = "authorization"
+)
+
+// methodPermissions grants each method to principals holding one of its permissions, as on the matching
+// REST endpoints. Methods missing from it are denied.
+var methodPermissions = map[string][]auth.Permission{
+	employeespb.EmployeeService_ListEmployees_FullMethodName:    {auth.PermissionReadEmployees, auth.PermissionReadDepartmentEmployees},
+	employeespb.EmployeeService_GetEmployee_FullMethodName:      {auth.PermissionReadEmployees, auth.PermissionReadDepartmentEmployees},
+	employeespb.EmployeeService_TransferEmployee_FullMethodName: {auth.PermissionWriteDepartments},
+	employeespb.EmployeeService_ListDepartments_FullMethodName:  {auth.PermissionReadDepartments},
+}
+
+type interceptors struct {
+	authenticator *auth.Authenticator
+}
+
+func (i interceptors) unary(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, finish := start(ctx, info.FullMethod)
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, logging.RequestID(ctx)))
+
+	ctx, err := i.authorize(ctx, info.FullMethod)
+	var response interface{}
+	if err == nil {
+		response, err = handler(ctx, request)
+	}
+
+	finish(err)
+	return response, err
+}
+
+func (i interceptors) stream(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, finish := start(stream.Context(), info.FullMethod)
+	stream.SetHeader(metadata.Pairs(RequestIDMetadata, logging.RequestID(ctx)))
+
+	ctx, err := i.authorize(ctx, info.FullMethod)
+	if err == nil {
+		err = handler(server, contextStream{ServerStream: stream, ctx: ctx})
+	}
+
+	finish(err)
+	return err
+}
+
+// start stores the caller's x-request-id, or a new one, in the context and starts a span. finish ends the
+// span and logs the completed call.
+func start(ctx context.Context, method string) (context.Context, func(error)) {
+	ctx = logging.WithRequestID(ctx, logging.ValidRequestID(firstMetadata(ctx, RequestIDMetadata)))
+	ctx, span := tracing.StartRPC(ctx, method)
+	begin := time.Now()
+
+	return ctx, func(err error) {
+		code := status.Code(err)
+		if code == codes.Internal || code == codes.Unknown {
+			tracing.RecordError(span, err)
+		}
+		span.End()
+
+		slog.InfoContext(ctx, "rpc completed",
+			"method", method,
+			"code", code.String(),
+			"duration_ms", time.Since(begin).Milliseconds(),
+		)
+	}
+}
+
+// authorize rejects calls that carry neither a valid bearer token in the authorization metadata nor a valid
+// API key in the x-api-key metadata, or whose principal lacks the permissions of the method.
+func (i interceptors) authorize(ctx context.Context, method string) (context.Context, error) {
+	principal, err := i.authenticator.Authenticate(ctx, firstMetadata(ctx, AuthorizationMetadata), firstMetadata(ctx, APIKeyMetadata))
+	if err != nil {
+		var unauthenticated *auth.Unauthenticated
+		if errors.As(err, &unauthenticated) {
+			return ctx, newStatus(codes.Unauthenticated, problem.CodeUnauthorized, unauthenticated.Message, nil)
+		}
+		return ctx, statusError(ctx, err)
+	}
+
+	ctx = auth.WithPrincipal(ctx, principal)
+	for _, permission := range methodPermissions[method] {
+		if principal.Can(permission) {
+			return ctx, nil
+		}
+	}
+
+	slog.WarnContext(ctx, "rpc forbidden", "method", method, "principal", principal.Subject)
+	return ctx, newStatus(codes.PermissionDenied, problem.CodeForbidden, "forbidden", nil)
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[0])
+}
+
+// contextStream is a server stream carrying the context the interceptor built.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context {
+	return s.ctx
+}
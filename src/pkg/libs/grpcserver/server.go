@@ -0,0 +1,72 @@
+package grpcserver
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/grpcserver/employeespb"
+	"errors"
+	"google.golang.org/grpc"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// New returns a gRPC server for the employee service, whose calls are authenticated and authorized by
+// authenticator.
+func New(authenticator *auth.Authenticator, employeeServer employeespb.EmployeeServiceServer) *grpc.Server {
+	chain := interceptors{authenticator: authenticator}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(chain.unary),
+		grpc.StreamInterceptor(chain.stream),
+	)
+	employeespb.RegisterEmployeeServiceServer(server, employeeServer)
+	return server
+}
+
+func Run(ctx context.Context, server *grpc.Server, address string, shutdownTimeout time.Duration) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return Serve(ctx, server, listener, shutdownTimeout)
+}
+
+// Serve handles calls on listener until ctx is done, then stops accepting calls and waits up to
+// shutdownTimeout for in-flight ones, streams included, to finish before cancelling the remaining ones.
+func Serve(ctx context.Context, server *grpc.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	serveErrors := make(chan error, 1)
+	go func() {
+		slog.InfoContext(ctx, "listening", "address", listener.Addr(), "protocol", "grpc")
+		serveErrors <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErrors:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.InfoContext(ctx, "shutting down, draining in-flight calls", "timeout", shutdownTimeout)
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	var shutdownError error
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		shutdownError = context.DeadlineExceeded
+		slog.ErrorContext(ctx, "could not drain in-flight calls", "error", shutdownError)
+		server.Stop()
+		<-stopped
+	}
+
+	if err := <-serveErrors; err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+
+	return shutdownError
+}
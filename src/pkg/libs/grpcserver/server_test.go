@@ -0,0 +1,455 @@
+package grpcserver
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
+	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/libs/grpcserver/employeespb"
+	"employee_exercise/src/pkg/libs/privacy"
+	"employee_exercise/src/pkg/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "employees"
+	adminKey     = "admin-key"
+)
+
+var hmacSecret = []byte("0123456789abcdef0123456789abcdef")
+
+type EmployeeManagerMock struct {
+	employees   []models.Employee
+	employee    *models.Employee
+	departments []models.Department
+	err         error
+	parameters  map[string]string
+	transfer    models.EmployeeDepartment
+	started     chan struct{}
+	release     chan struct{}
+}
+
+func (e *EmployeeManagerMock) StreamEmployees(ctx context.Context, parameters map[string]string, send func(models.Employee) error) error {
+	e.parameters = parameters
+	if e.started != nil {
+		close(e.started)
+		<-e.release
+	}
+	for _, employee := range e.employees {
+		if err := send(employee); err != nil {
+			return err
+		}
+	}
+	return e.err
+}
+
+func (e *EmployeeManagerMock) GetEmployee(ctx context.Context, employeeID int) (*models.Employee, error) {
+	return e.employee, e.err
+}
+
+func (e *EmployeeManagerMock) UpdateEmployeeDepartment(ctx context.Context, employeeDepartment models.EmployeeDepartment) error {
+	e.transfer = employeeDepartment
+	return e.err
+}
+
+func (e *EmployeeManagerMock) ListDepartments(ctx context.Context) ([]models.Department, error) {
+	return e.departments, e.err
+}
+
+type KeyAuthenticatorMock struct{}
+
+func (KeyAuthenticatorMock) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if key != adminKey {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	return &models.APIKey{ID: 1, Owner: "ops", Admin: true}, nil
+}
+
+func TestEmployeeServer_ListEmployees_Streams_masked_employees(t *testing.T) {
+	manager := &EmployeeManagerMock{employees: []models.Employee{mockEmployee(10001), mockEmployee(10002)}}
+	client := dial(t, manager)
+
+	stream, err := client.ListEmployees(withToken(t, "hr"), &employeespb.ListEmployeesRequest{OrderBy: "EMP_NO", Order: "desc", Limit: 10})
+	require.NoError(t, err)
+
+	var received []*employeespb.Employee
+	for {
+		message, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		received = append(received, message)
+	}
+
+	assert.Equal(t, map[string]string{"order_by_column": "emp_no", "order": "desc", "limit": "10"}, manager.parameters)
+	require.Len(t, received, 2)
+	assert.Equal(t, int64(10002), received[1].GetEmpNo())
+	assert.Equal(t, "1994", received[1].GetBirthDate())
+	assert.Equal(t, privacy.Redacted, received[1].GetGender())
+	assert.Equal(t, "Lucas", received[1].GetFirstName())
+	assert.Equal(t, "2022-06-20T15:00:00Z", received[1].GetHireDate())
+	assert.Equal(t, "Development", received[1].GetDepartment())
+}
+
+func TestEmployeeServer_ListEmployees_Streams_all_employees_without_limit(t *testing.T) {
+	manager := &EmployeeManagerMock{}
+	client := dial(t, manager)
+
+	stream, err := client.ListEmployees(withAPIKey(adminKey), &employeespb.ListEmployeesRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.EOF)
+
+	assert.Equal(t, map[string]string{"order_by_column": "first_name", "order": "asc"}, manager.parameters)
+}
+
+func TestEmployeeServer_Errors(t *testing.T) {
+	tests := []struct {
+		name               string
+		manager            *EmployeeManagerMock
+		call               func(ctx context.Context, client employeespb.EmployeeServiceClient) error
+		expectedCode       codes.Code
+		expectedMessage    string
+		expectedReason     string
+		expectedViolations map[string]string
+	}{
+		{
+			name:    "invalid listing",
+			manager: &EmployeeManagerMock{},
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				stream, err := client.ListEmployees(ctx, &employeespb.ListEmployeesRequest{OrderBy: "salary", Order: "up", Limit: -1})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: "the request has invalid fields",
+			expectedReason:  "validation_failed",
+			expectedViolations: map[string]string{
+				"order_by": "must be one of emp_no, birth_date, first_name, last_name, gender, hire_date",
+				"order":    "must be one of asc, desc",
+				"limit":    "must be at least 0",
+			},
+		},
+		{
+			name:    "employee not found",
+			manager: &EmployeeManagerMock{err: &employee.Error{Kind: employee.KindNotFound, Code: employee.CodeEmployeeNotFound, Detail: "employee not found"}},
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				_, err := client.GetEmployee(ctx, &employeespb.GetEmployeeRequest{EmpNo: 10002})
+				return err
+			},
+			expectedCode:    codes.NotFound,
+			expectedMessage: "employee not found",
+			expectedReason:  employee.CodeEmployeeNotFound,
+		},
+		{
+			name:    "invalid transfer",
+			manager: &EmployeeManagerMock{},
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				_, err := client.TransferEmployee(ctx, &employeespb.TransferEmployeeRequest{EmpNo: 10002, DeptNo: "d0006", FromDate: "1996-08-04", ToDate: "1996-08-01"})
+				return err
+			},
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: "the request has invalid fields",
+			expectedReason:  "validation_failed",
+			expectedViolations: map[string]string{
+				"dept_no": `must match ^d\d{3}$`,
+				"to_date": "must not be before from_date",
+			},
+		},
+		{
+			name: "conflicting transfer",
+			manager: &EmployeeManagerMock{err: &employee.Error{
+				Kind: employee.KindConflict, Code: employee.CodeEmployeeDepartmentConflict, Detail: "the employee already belongs to the department for these dates",
+			}},
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				_, err := client.TransferEmployee(ctx, &employeespb.TransferEmployeeRequest{EmpNo: 10002, DeptNo: "d006", FromDate: "1996-08-04", ToDate: "1996-08-09"})
+				return err
+			},
+			expectedCode:    codes.AlreadyExists,
+			expectedMessage: "the employee already belongs to the department for these dates",
+			expectedReason:  employee.CodeEmployeeDepartmentConflict,
+		},
+		{
+			name:    "internal error",
+			manager: &EmployeeManagerMock{err: errors.New("connection refused")},
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				_, err := client.ListDepartments(ctx, &employeespb.ListDepartmentsRequest{})
+				return err
+			},
+			expectedCode:    codes.Internal,
+			expectedMessage: "internal server error",
+			expectedReason:  "internal_error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(withAPIKey(adminKey), dial(t, tt.manager))
+
+			result := status.Convert(err)
+			assert.Equal(t, tt.expectedCode, result.Code())
+			assert.Equal(t, tt.expectedMessage, result.Message())
+
+			violations := map[string]string{}
+			var reason string
+			for _, detail := range result.Details() {
+				switch typed := detail.(type) {
+				case *errdetails.ErrorInfo:
+					reason = typed.GetReason()
+				case *errdetails.BadRequest:
+					for _, violation := range typed.GetFieldViolations() {
+						violations[violation.GetField()] = violation.GetDescription()
+					}
+				}
+			}
+			assert.Equal(t, tt.expectedReason, reason)
+			if tt.expectedViolations == nil {
+				tt.expectedViolations = map[string]string{}
+			}
+			assert.Equal(t, tt.expectedViolations, violations)
+		})
+	}
+}
+
+func TestEmployeeServer_TransferEmployee_Succeeds(t *testing.T) {
+	manager := &EmployeeManagerMock{}
+	client := dial(t, manager)
+
+	_, err := client.TransferEmployee(withAPIKey(adminKey), &employeespb.TransferEmployeeRequest{EmpNo: 10002, DeptNo: "d006", FromDate: "1996-08-04", ToDate: "1996-08-09"})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.EmployeeDepartment{
+		EmployeeNumber: 10002,
+		Department:     "d006",
+		FromDate:       time.Date(1996, 8, 4, 0, 0, 0, 0, time.UTC),
+		ToDate:         time.Date(1996, 8, 9, 0, 0, 0, 0, time.UTC),
+	}, manager.transfer)
+}
+
+func TestInterceptors_Authorize(t *testing.T) {
+	tests := []struct {
+		name            string
+		ctx             func(t *testing.T) context.Context
+		call            func(ctx context.Context, client employeespb.EmployeeServiceClient) error
+		expectedCode    codes.Code
+		expectedMessage string
+	}{
+		{
+			name: "missing credentials",
+			ctx:  func(t *testing.T) context.Context { return context.Background() },
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				_, err := client.ListDepartments(ctx, &employeespb.ListDepartmentsRequest{})
+				return err
+			},
+			expectedCode:    codes.Unauthenticated,
+			expectedMessage: "unauthorized, missing bearer token or api key",
+		},
+		{
+			name: "invalid api key",
+			ctx:  func(t *testing.T) context.Context { return withAPIKey("unknown") },
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				_, err := client.GetEmployee(ctx, &employeespb.GetEmployeeRequest{EmpNo: 10002})
+				return err
+			},
+			expectedCode:    codes.Unauthenticated,
+			expectedMessage: "unauthorized, invalid api key",
+		},
+		{
+			name: "invalid bearer token on a stream",
+			ctx: func(t *testing.T) context.Context {
+				return metadata.AppendToOutgoingContext(context.Background(), AuthorizationMetadata, "Bearer abc")
+			},
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				stream, err := client.ListEmployees(ctx, &employeespb.ListEmployeesRequest{})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+			expectedCode:    codes.Unauthenticated,
+			expectedMessage: "unauthorized, invalid bearer token",
+		},
+		{
+			name: "manager transferring an employee",
+			ctx:  func(t *testing.T) context.Context { return withToken(t, auth.RoleManager) },
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				_, err := client.TransferEmployee(ctx, &employeespb.TransferEmployeeRequest{EmpNo: 10002, DeptNo: "d006", FromDate: "1996-08-04", ToDate: "1996-08-09"})
+				return err
+			},
+			expectedCode:    codes.PermissionDenied,
+			expectedMessage: "forbidden",
+		},
+		{
+			name: "auditor listing employees",
+			ctx:  func(t *testing.T) context.Context { return withToken(t, auth.RoleAuditor) },
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				stream, err := client.ListEmployees(ctx, &employeespb.ListEmployeesRequest{})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+			expectedCode:    codes.PermissionDenied,
+			expectedMessage: "forbidden",
+		},
+		{
+			name: "manager listing departments",
+			ctx:  func(t *testing.T) context.Context { return withToken(t, auth.RoleManager) },
+			call: func(ctx context.Context, client employeespb.EmployeeServiceClient) error {
+				_, err := client.ListDepartments(ctx, &employeespb.ListDepartmentsRequest{})
+				return err
+			},
+			expectedCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(tt.ctx(t), dial(t, &EmployeeManagerMock{}))
+
+			result := status.Convert(err)
+			assert.Equal(t, tt.expectedCode, result.Code())
+			assert.Equal(t, tt.expectedMessage, result.Message())
+		})
+	}
+}
+
+func TestInterceptors_Echo_request_id(t *testing.T) {
+	client := dial(t, &EmployeeManagerMock{departments: []models.Department{{DepartmentNumber: "d005", DepartmentName: "Development"}}})
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(withAPIKey(adminKey), RequestIDMetadata, "abc-123")
+	response, err := client.ListDepartments(ctx, &employeespb.ListDepartmentsRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"abc-123"}, header.Get(RequestIDMetadata))
+	require.Len(t, response.GetDepartments(), 1)
+	assert.Equal(t, "Development", response.GetDepartments()[0].GetDeptName())
+}
+
+func TestServe_Drains_in_flight_streams(t *testing.T) {
+	manager := &EmployeeManagerMock{employees: []models.Employee{mockEmployee(10001)}, started: make(chan struct{}), release: make(chan struct{})}
+	listener := bufconn.Listen(1 << 20)
+	server := New(authenticator(t), &EmployeeServer{EmployeeService: manager})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveResult := make(chan error, 1)
+	go func() { serveResult <- Serve(ctx, server, listener, 5*time.Second) }()
+
+	client := employeespb.NewEmployeeServiceClient(connect(t, listener))
+	received := make(chan error, 1)
+	go func() {
+		stream, err := client.ListEmployees(withAPIKey(adminKey), &employeespb.ListEmployeesRequest{})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		received <- err
+	}()
+
+	<-manager.started
+	cancel()
+
+	select {
+	case <-serveResult:
+		t.Fatal("server stopped before the in-flight stream finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(manager.release)
+
+	assert.NoError(t, <-received)
+	assert.NoError(t, <-serveResult)
+}
+
+func dial(t *testing.T, manager *EmployeeManagerMock) employeespb.EmployeeServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	masker := privacy.NewMasker(config.PIIConfig{Profile: "standard"})
+	server := New(authenticator(t), &EmployeeServer{EmployeeService: manager, Masker: masker})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return employeespb.NewEmployeeServiceClient(connect(t, listener))
+}
+
+func connect(t *testing.T, listener *bufconn.Listener) *grpc.ClientConn {
+	connection, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { connection.Close() })
+	return connection
+}
+
+func authenticator(t *testing.T) *auth.Authenticator {
+	document, err := json.Marshal(map[string][]map[string]string{"keys": {{
+		"kid": "hmac",
+		"kty": "oct",
+		"alg": "HS256",
+		"k":   base64.RawURLEncoding.EncodeToString(hmacSecret),
+	}}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, document, 0o600))
+
+	verifier, err := auth.NewTokenVerifier(config.JWTConfig{Issuer: testIssuer, Audience: testAudience, JWKSFile: path})
+	require.NoError(t, err)
+
+	authenticator := auth.New(KeyAuthenticatorMock{})
+	authenticator.Tokens = verifier
+	return authenticator
+}
+
+func withAPIKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), APIKeyMetadata, key)
+}
+
+func withToken(t *testing.T, roles ...string) context.Context {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "alice",
+		"roles": roles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "hmac"
+	signed, err := token.SignedString(hmacSecret)
+	require.NoError(t, err)
+
+	return metadata.AppendToOutgoingContext(context.Background(), AuthorizationMetadata, "Bearer "+signed)
+}
+
+func mockEmployee(employeeNumber int) models.Employee {
+	return models.Employee{
+		EmployeeNumber: employeeNumber,
+		FirstName:      "Lucas",
+		LastName:       "Lissandrello",
+		Gender:         "M",
+		BirthDate:      time.Date(1994, 11, 8, 7, 30, 00, 0, time.UTC),
+		HireDate:       time.Date(2022, 06, 20, 15, 00, 00, 0, time.UTC),
+		Department:     "Development",
+	}
+}
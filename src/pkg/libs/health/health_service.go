@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"employee_exercise/src/pkg/models"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	tablesQuery = "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()"
+)
+
+var RequiredTables = []string{"api_keys", "audit_log", "departments", "dept_emp", "dept_manager", "employees", "salaries", "titles"}
+
+type HealthService struct {
+	HealthManager  *sql.DB
+	RequiredTables []string
+	PingTimeout    time.Duration
+}
+
+func (h *HealthService) Liveness(ctx context.Context) models.HealthReport {
+	return models.HealthReport{Status: StatusOK}
+}
+
+// Readiness reports whether the database answers, holds the expected schema and has free connections in the pool.
+func (h *HealthService) Readiness(ctx context.Context) models.HealthReport {
+	checks := map[string]models.HealthCheck{
+		"pool":     PoolCheck(h.HealthManager.Stats()),
+		"database": h.checkDatabase(ctx),
+	}
+
+	if checks["database"].Status == StatusOK {
+		checks["migrations"] = h.checkMigrations(ctx)
+	} else {
+		checks["migrations"] = models.HealthCheck{Status: StatusFail, Message: "database unreachable"}
+	}
+
+	report := models.HealthReport{Status: StatusOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func (h *HealthService) checkDatabase(ctx context.Context) models.HealthCheck {
+	if h.PingTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.PingTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := h.HealthManager.PingContext(ctx)
+	latency := time.Since(start)
+	if err != nil {
+		slog.ErrorContext(ctx, "readiness: error pinging database", "error", err)
+		return models.HealthCheck{Status: StatusFail, Message: err.Error()}
+	}
+
+	return models.HealthCheck{
+		Status:  StatusOK,
+		Details: map[string]interface{}{"latency_ms": latency.Milliseconds()},
+	}
+}
+
+func (h *HealthService) checkMigrations(ctx context.Context) models.HealthCheck {
+	rows, err := h.HealthManager.QueryContext(ctx, tablesQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "readiness: error listing tables", "error", err)
+		return models.HealthCheck{Status: StatusFail, Message: err.Error()}
+	}
+
+	defer rows.Close()
+
+	present := make(map[string]bool)
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			slog.ErrorContext(ctx, "readiness: error scanning tables", "error", err)
+			return models.HealthCheck{Status: StatusFail, Message: err.Error()}
+		}
+		present[strings.ToLower(table)] = true
+	}
+
+	var missing []string
+	for _, table := range h.RequiredTables {
+		if !present[table] {
+			missing = append(missing, table)
+		}
+	}
+
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return models.HealthCheck{
+			Status:  StatusFail,
+			Message: fmt.Sprintf("missing tables: %s", strings.Join(missing, ", ")),
+			Details: map[string]interface{}{"missing_tables": missing},
+		}
+	}
+
+	return models.HealthCheck{
+		Status:  StatusOK,
+		Details: map[string]interface{}{"tables": len(h.RequiredTables)},
+	}
+}
+
+// PoolCheck fails when every connection the pool may open is in use, as new queries would have to wait.
+func PoolCheck(stats sql.DBStats) models.HealthCheck {
+	check := models.HealthCheck{
+		Status: StatusOK,
+		Details: map[string]interface{}{
+			"open_connections": stats.OpenConnections,
+			"in_use":           stats.InUse,
+			"idle":             stats.Idle,
+			"max_open":         stats.MaxOpenConnections,
+			"wait_count":       stats.WaitCount,
+			"wait_duration_ms": stats.WaitDuration.Milliseconds(),
+		},
+	}
+
+	if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+		check.Status = StatusFail
+		check.Message = "connection pool saturated"
+	}
+
+	return check
+}
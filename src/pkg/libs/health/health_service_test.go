@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestHealthService_Readiness_Succeeds(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectPing()
+	mock.
+		ExpectQuery(regexp.QuoteMeta(tablesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).
+			AddRow("employees").
+			AddRow("DEPARTMENTS").
+			AddRow("dept_emp"))
+
+	service := HealthService{HealthManager: db, RequiredTables: []string{"departments", "dept_emp", "employees"}, PingTimeout: time.Second}
+	report := service.Readiness(context.Background())
+
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, models.HealthCheck{Status: StatusOK, Details: map[string]interface{}{"tables": 3}}, report.Checks["migrations"])
+	assert.Equal(t, StatusOK, report.Checks["pool"].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealthService_Readiness_Reports_missing_tables(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectPing()
+	mock.
+		ExpectQuery(regexp.QuoteMeta(tablesQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("employees"))
+
+	service := HealthService{HealthManager: db, RequiredTables: []string{"titles", "employees", "salaries"}}
+	report := service.Readiness(context.Background())
+
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, models.HealthCheck{
+		Status:  StatusFail,
+		Message: "missing tables: salaries, titles",
+		Details: map[string]interface{}{"missing_tables": []string{"salaries", "titles"}},
+	}, report.Checks["migrations"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealthService_Readiness_Fails_when_database_is_down(t *testing.T) {
+	db, mock := newMock(t)
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+	service := HealthService{HealthManager: db, RequiredTables: RequiredTables}
+	report := service.Readiness(context.Background())
+
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, models.HealthCheck{Status: StatusFail, Message: "connection refused"}, report.Checks["database"])
+	assert.Equal(t, models.HealthCheck{Status: StatusFail, Message: "database unreachable"}, report.Checks["migrations"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPoolCheck(t *testing.T) {
+	tests := []struct {
+		name            string
+		stats           sql.DBStats
+		expectedStatus  string
+		expectedMessage string
+	}{
+		{
+			name:           "pool with free connections",
+			stats:          sql.DBStats{MaxOpenConnections: 10, OpenConnections: 4, InUse: 3, Idle: 1},
+			expectedStatus: StatusOK,
+		},
+		{
+			name:           "unlimited pool",
+			stats:          sql.DBStats{OpenConnections: 40, InUse: 40},
+			expectedStatus: StatusOK,
+		},
+		{
+			name:            "saturated pool",
+			stats:           sql.DBStats{MaxOpenConnections: 10, OpenConnections: 10, InUse: 10, WaitCount: 7, WaitDuration: 1500 * time.Millisecond},
+			expectedStatus:  StatusFail,
+			expectedMessage: "connection pool saturated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := PoolCheck(tt.stats)
+
+			assert.Equal(t, tt.expectedStatus, check.Status)
+			assert.Equal(t, tt.expectedMessage, check.Message)
+			assert.Equal(t, tt.stats.InUse, check.Details["in_use"])
+			assert.Equal(t, tt.stats.WaitDuration.Milliseconds(), check.Details["wait_duration_ms"])
+		})
+	}
+}
+
+func newMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db, mock
+}
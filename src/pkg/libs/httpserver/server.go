@@ -0,0 +1,62 @@
+package httpserver
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+func New(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         cfg.Address,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+}
+
+func Run(ctx context.Context, server *http.Server, shutdownTimeout time.Duration) error {
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+
+	return Serve(ctx, server, listener, shutdownTimeout)
+}
+
+// Serve handles requests on listener until ctx is done, then stops accepting connections and waits up to
+// shutdownTimeout for in-flight requests to finish before closing the remaining ones.
+func Serve(ctx context.Context, server *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	serveErrors := make(chan error, 1)
+	go func() {
+		slog.InfoContext(ctx, "listening", "address", listener.Addr())
+		serveErrors <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErrors:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.InfoContext(ctx, "shutting down, draining in-flight requests", "timeout", shutdownTimeout)
+	shutdownContext, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	shutdownError := server.Shutdown(shutdownContext)
+	if shutdownError != nil {
+		slog.ErrorContext(ctx, "could not drain in-flight requests", "error", shutdownError)
+		server.Close()
+	}
+
+	if err := <-serveErrors; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return shutdownError
+}
@@ -0,0 +1,98 @@
+package httpserver
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNew_Applies_timeouts(t *testing.T) {
+	handler := http.NewServeMux()
+	server := New(config.ServerConfig{
+		Address:      ":8080",
+		ReadTimeout:  time.Second,
+		WriteTimeout: 2 * time.Second,
+		IdleTimeout:  3 * time.Second,
+	}, handler)
+
+	assert.Equal(t, ":8080", server.Addr)
+	assert.Equal(t, handler, server.Handler)
+	assert.Equal(t, time.Second, server.ReadTimeout)
+	assert.Equal(t, 2*time.Second, server.WriteTimeout)
+	assert.Equal(t, 3*time.Second, server.IdleTimeout)
+}
+
+func TestServe_Drains_in_flight_requests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a listener", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveResult := make(chan error, 1)
+	go func() { serveResult <- Serve(ctx, server, listener, 5*time.Second) }()
+
+	responseBody := make(chan string, 1)
+	go func() {
+		response, requestError := http.Get("http://" + listener.Addr().String())
+		if requestError != nil {
+			responseBody <- requestError.Error()
+			return
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		responseBody <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case <-serveResult:
+		t.Fatal("server stopped before the in-flight request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	assert.Equal(t, "done", <-responseBody)
+	assert.NoError(t, <-serveResult)
+}
+
+func TestServe_Gives_up_after_shutdown_timeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a listener", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveResult := make(chan error, 1)
+	go func() { serveResult <- Serve(ctx, server, listener, 10*time.Millisecond) }()
+	go http.Get("http://" + listener.Addr().String())
+
+	<-started
+	cancel()
+
+	assert.ErrorIs(t, <-serveResult, context.DeadlineExceeded)
+}
@@ -0,0 +1,26 @@
+package httpserver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatusRecorder_Unwraps_to_the_connection(t *testing.T) {
+	deadlineErrors := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := NewStatusRecorder(w)
+		deadlineErrors <- http.NewResponseController(recorder).SetWriteDeadline(time.Time{})
+		recorder.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	response.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+	assert.NoError(t, <-deadlineErrors)
+}
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name              string
+		incomingRequestID string
+		expectGenerated   bool
+	}{
+		{
+			name:              "echoes the caller's request id",
+			incomingRequestID: "checkout-7f3a.1",
+		},
+		{
+			name:            "generates a request id when missing",
+			expectGenerated: true,
+		},
+		{
+			name:              "replaces an invalid request id",
+			incomingRequestID: "bad id\nwith newline",
+			expectGenerated:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			useLogger(t, &out)
+
+			var handlerRequestID string
+			router := mux.NewRouter()
+			router.Use(Middleware)
+			router.HandleFunc("/employees/{emp_no}/timeline", func(w http.ResponseWriter, r *http.Request) {
+				handlerRequestID = RequestID(r.Context())
+				slog.WarnContext(r.Context(), "employee not found", "emp_no", 10001)
+				w.WriteHeader(http.StatusNotFound)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/employees/10001/timeline", nil)
+			if tt.incomingRequestID != "" {
+				request.Header.Set(RequestIDHeader, tt.incomingRequestID)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			responseRequestID := recorder.Header().Get(RequestIDHeader)
+			assert.Equal(t, handlerRequestID, responseRequestID)
+			if tt.expectGenerated {
+				assert.Len(t, responseRequestID, 32)
+			} else {
+				assert.Equal(t, tt.incomingRequestID, responseRequestID)
+			}
+
+			records := decode(t, &out)
+			assert.Len(t, records, 2)
+			assert.Equal(t, "employee not found", records[0]["msg"])
+			assert.Equal(t, responseRequestID, records[0]["request_id"])
+			assert.Equal(t, "request completed", records[1]["msg"])
+			assert.Equal(t, responseRequestID, records[1]["request_id"])
+			assert.Equal(t, "/employees/{emp_no}/timeline", records[1]["route"])
+			assert.Equal(t, float64(http.StatusNotFound), records[1]["status"])
+		})
+	}
+}
+
+func TestNew_Adds_trace_context_and_filters_by_level(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, config.LogConfig{Level: "warn", Format: FormatJSON})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	logger.InfoContext(ctx, "filtered out")
+	logger.With("component", "reports").ErrorContext(ctx, "error preparing sql headcount query")
+
+	records := decode(t, &out)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "ERROR", records[0]["level"])
+	assert.Equal(t, "reports", records[0]["component"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", records[0]["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", records[0]["span_id"])
+	assert.NotContains(t, records[0], "request_id")
+}
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, ParseLevel("debug"))
+	assert.Equal(t, slog.LevelWarn, ParseLevel("WARN"))
+	assert.Equal(t, slog.LevelInfo, ParseLevel("verbose"))
+}
+
+func useLogger(t *testing.T, out *bytes.Buffer) {
+	previous := slog.Default()
+	slog.SetDefault(New(out, config.LogConfig{Level: "debug", Format: FormatJSON}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+}
+
+func decode(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("an error '%s' was not expected when decoding the log line %q", err, line)
+		}
+		records = append(records, record)
+	}
+	return records
+}
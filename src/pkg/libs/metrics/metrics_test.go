@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics_Middleware_Records_route_templates_and_status_codes(t *testing.T) {
+	m := New(nil)
+
+	router := mux.NewRouter()
+	router.Use(m.Middleware)
+	router.HandleFunc("/employees/{emp_no}/timeline", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["emp_no"] == "0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("{}"))
+	}).Methods("GET")
+
+	for _, url := range []string{"/employees/10001/timeline", "/employees/10002/timeline", "/employees/0/timeline"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+	}
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `employee_exercise_http_requests_total{code="200",method="GET",route="/employees/{emp_no}/timeline"} 2`)
+	assert.Contains(t, body, `employee_exercise_http_requests_total{code="404",method="GET",route="/employees/{emp_no}/timeline"} 1`)
+	assert.Contains(t, body, `employee_exercise_http_request_duration_seconds_count{method="GET",route="/employees/{emp_no}/timeline"} 3`)
+}
+
+func TestMetrics_RateLimited_Counts_decisions_by_route(t *testing.T) {
+	m := New(nil)
+
+	m.RateLimited("/employees", "allowed")
+	m.RateLimited("/employees", "allowed")
+	m.RateLimited("/employees", "rejected")
+	m.RateLimited("/reports/headcount", "store_error")
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `employee_exercise_rate_limiter_decisions_total{decision="allowed",route="/employees"} 2`)
+	assert.Contains(t, body, `employee_exercise_rate_limiter_decisions_total{decision="rejected",route="/employees"} 1`)
+	assert.Contains(t, body, `employee_exercise_rate_limiter_decisions_total{decision="store_error",route="/reports/headcount"} 1`)
+}
+
+func TestMetrics_Exposes_database_pool_stats(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+	db.SetMaxOpenConns(7)
+
+	body := scrape(t, New(db))
+
+	assert.Contains(t, body, `go_sql_max_open_connections{db_name="employees"} 7`)
+	assert.Contains(t, body, `go_sql_in_use_connections{db_name="employees"} 0`)
+	assert.Contains(t, body, `go_sql_wait_duration_seconds_total{db_name="employees"}`)
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	return strings.TrimSpace(recorder.Body.String())
+}
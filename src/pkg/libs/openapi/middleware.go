@@ -0,0 +1,284 @@
+package openapi
+
+import (
+	"bytes"
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/validation"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Validator enforces the document on the routes it describes.
+type Validator struct {
+	spec              *Spec
+	validateResponses bool
+}
+
+// NewValidator checks requests against spec and, when validateResponses is set, responses too. Response
+// validation buffers JSON responses and is meant for tests and staging.
+func NewValidator(spec *Spec, validateResponses bool) *Validator {
+	return &Validator{spec: spec, validateResponses: validateResponses}
+}
+
+// Middleware rejects requests whose path, query parameters or JSON body do not match the operation of their
+// route with a 400 listing every invalid field. Routes the document does not describe are passed through.
+// With response validation, a JSON response that does not match is logged and replaced by a 500. Responses of
+// another documented content type, as the ndjson export, are streamed through once their status and content
+// type are checked.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		operation, ok := v.spec.Operation(r.Method, template)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		violations := v.validateParameters(operation, r)
+		if operation.RequestBody != nil {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "bad request, wrong request body"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			bodyViolations, malformed := v.validateBody(operation.RequestBody, body)
+			if malformed {
+				problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidBody, "bad request, wrong request body"))
+				return
+			}
+			violations = append(violations, bodyViolations...)
+		}
+		if len(violations) != 0 {
+			problem.Write(w, r, problem.Validation(violations))
+			return
+		}
+
+		if !v.validateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buffer := &responseBuffer{ResponseWriter: w, status: http.StatusOK, validator: v, operation: operation}
+		next.ServeHTTP(buffer, r)
+		if buffer.streaming {
+			return
+		}
+
+		if mismatches := v.validateResponse(operation, buffer); len(mismatches) != 0 {
+			slog.ErrorContext(r.Context(), "response does not match the openapi document",
+				"method", r.Method,
+				"route", template,
+				"status", buffer.status,
+				"violations", mismatches,
+			)
+			w.Header().Del("Content-Disposition")
+			problem.Write(w, r, problem.Internal())
+			return
+		}
+		w.WriteHeader(buffer.status)
+		w.Write(buffer.body.Bytes())
+	})
+}
+
+// validateParameters checks the path and query parameters, in the order of the document. Query enums are
+// matched regardless of case, as the handlers do.
+func (v *Validator) validateParameters(operation *Operation, r *http.Request) []problem.FieldError {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	var violations []problem.FieldError
+	for _, parameter := range v.spec.Parameters(operation) {
+		var raw string
+		var present bool
+		switch parameter.In {
+		case "path":
+			raw, present = vars[parameter.Name]
+		case "query":
+			raw, present = query.Get(parameter.Name), query.Has(parameter.Name)
+		default:
+			continue
+		}
+
+		if !present {
+			if parameter.Required {
+				violations = append(violations, problem.FieldError{Field: parameter.Name, Code: validation.CodeRequired, Message: "is required"})
+			}
+			continue
+		}
+
+		schema := v.spec.Resolve(parameter.Schema)
+		value, violation := parameterValue(schema, raw)
+		if violation == nil {
+			if found := v.spec.Validate(schema, value); len(found) != 0 {
+				violation = &found[0]
+			}
+		}
+		if violation != nil {
+			violations = append(violations, problem.FieldError{Field: parameter.Name, Code: violation.Code, Message: violation.Message})
+		}
+	}
+	return violations
+}
+
+// parameterValue converts a raw parameter to the JSON value its schema expects.
+func parameterValue(schema *Schema, raw string) (interface{}, *Violation) {
+	switch {
+	case schema == nil:
+		return raw, nil
+	case schema.Type.Allows("string") || len(schema.Type) == 0:
+		for _, allowed := range schema.Enum {
+			if name, ok := allowed.(string); ok && strings.EqualFold(name, raw) {
+				return name, nil
+			}
+		}
+		return raw, nil
+	case schema.Type.Allows("integer") && !schema.Type.Allows("number"):
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, &Violation{Code: validation.CodeInvalid, Message: "must be an integer"}
+		}
+		return float64(parsed), nil
+	case schema.Type.Allows("number"):
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, &Violation{Code: validation.CodeInvalid, Message: "must be a number"}
+		}
+		return parsed, nil
+	case schema.Type.Allows("boolean"):
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, &Violation{Code: validation.CodeInvalid, Message: "must be a boolean"}
+		}
+		return parsed, nil
+	}
+	return raw, nil
+}
+
+// validateBody checks a JSON body. malformed is set when the body is not JSON at all.
+func (v *Validator) validateBody(requestBody *RequestBody, body []byte) (violations []problem.FieldError, malformed bool) {
+	media, ok := requestBody.Content["application/json"]
+	if !ok {
+		return nil, false
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		if requestBody.Required {
+			return nil, true
+		}
+		return nil, false
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, true
+	}
+
+	for _, violation := range v.spec.Validate(media.Schema, value) {
+		field := strings.TrimPrefix(violation.Path, "/")
+		if field == "" {
+			field = "body"
+		}
+		violations = append(violations, problem.FieldError{Field: field, Code: violation.Code, Message: violation.Message})
+	}
+	return violations, false
+}
+
+// validateResponse checks that the status and content type are documented and that a JSON body matches its
+// schema.
+func (v *Validator) validateResponse(operation *Operation, buffer *responseBuffer) []string {
+	response, ok := v.spec.Response(operation, buffer.status)
+	if !ok {
+		return []string{"status " + strconv.Itoa(buffer.status) + " is not documented"}
+	}
+
+	contentType, _, err := mime.ParseMediaType(buffer.Header().Get("Content-Type"))
+	if err != nil {
+		return []string{"content type is missing"}
+	}
+	media, ok := response.Content[contentType]
+	if !ok {
+		return []string{"content type " + contentType + " is not documented"}
+	}
+	if contentType != "application/json" && contentType != problem.ContentType {
+		return nil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(buffer.body.Bytes(), &body); err != nil {
+		return []string{"body is not JSON"}
+	}
+	var mismatches []string
+	for _, violation := range v.spec.Validate(media.Schema, body) {
+		mismatches = append(mismatches, violation.String())
+	}
+	return mismatches
+}
+
+// streams tells whether a response with this status and content type is documented and not JSON, so it has no
+// body to validate and can be sent as it is written.
+func (v *Validator) streams(operation *Operation, status int, contentType string) bool {
+	response, ok := v.spec.Response(operation, status)
+	if !ok {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/json" || mediaType == problem.ContentType {
+		return false
+	}
+	_, ok = response.Content[mediaType]
+	return ok
+}
+
+// responseBuffer holds the status and body of a response until it has been validated. Headers go straight to
+// the underlying writer, they are only sent with the status. A response that streams is passed through from its
+// status on.
+type responseBuffer struct {
+	http.ResponseWriter
+	validator   *Validator
+	operation   *Operation
+	status      int
+	wroteHeader bool
+	streaming   bool
+	body        bytes.Buffer
+}
+
+func (b *responseBuffer) WriteHeader(code int) {
+	if b.wroteHeader {
+		return
+	}
+	b.status = code
+	b.wroteHeader = true
+	if b.validator.streams(b.operation, code, b.Header().Get("Content-Type")) {
+		b.streaming = true
+		b.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (b *responseBuffer) Write(body []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	if b.streaming {
+		return b.ResponseWriter.Write(body)
+	}
+	return b.body.Write(body)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to lift the write deadline.
+func (b *responseBuffer) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
@@ -0,0 +1,210 @@
+package openapi
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidator_Middleware_Requests(t *testing.T) {
+	tests := []struct {
+		name                 string
+		method               string
+		url                  string
+		body                 string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "valid query",
+			method:               http.MethodGet,
+			url:                  "/employees?limit=5&page=1&orderBy=emp_no",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "handled",
+		},
+		{
+			name:                 "invalid query",
+			method:               http.MethodGet,
+			url:                  "/employees?limit=abc&page=0",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees","code":"validation_failed","errors":[{"field":"limit","code":"invalid","message":"must be an integer"},{"field":"page","code":"min","message":"must be at least 1"}]}`,
+		},
+		{
+			name:                 "unknown order column",
+			method:               http.MethodGet,
+			url:                  "/employees?orderBy=salary&order=DESC",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees","code":"validation_failed","errors":[{"field":"orderBy","code":"invalid","message":"must be one of emp_no, birth_date, first_name, last_name, gender, hire_date"}]}`,
+		},
+		{
+			name:                 "enums ignore case",
+			method:               http.MethodGet,
+			url:                  "/reports/headcount?from=1990-01-01&to=1991-01-01&interval=YEAR&groupBy=Gender",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "handled",
+		},
+		{
+			name:                 "missing and invalid query parameters",
+			method:               http.MethodGet,
+			url:                  "/reports/headcount?to=1991-13-01&interval=week",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/reports/headcount","code":"validation_failed","errors":[{"field":"from","code":"required","message":"is required"},{"field":"to","code":"date","message":"must be a date formatted as YYYY-MM-DD"},{"field":"interval","code":"invalid","message":"must be one of month, quarter, year"}]}`,
+		},
+		{
+			name:                 "invalid path parameter",
+			method:               http.MethodDelete,
+			url:                  "/api-keys/abc",
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/api-keys/abc","code":"validation_failed","errors":[{"field":"id","code":"invalid","message":"must be an integer"}]}`,
+		},
+		{
+			name:                 "valid body",
+			method:               http.MethodPost,
+			url:                  "/employees_department",
+			body:                 `{"emp_no":10002,"dept_no":"d006","from_date":"1996-08-04","to_date":"1996-08-09"}`,
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "handled",
+		},
+		{
+			name:                 "malformed body",
+			method:               http.MethodPost,
+			url:                  "/employees_department",
+			body:                 `{"emp_no":`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong request body","instance":"/employees_department","code":"invalid_body"}`,
+		},
+		{
+			name:                 "invalid body",
+			method:               http.MethodPost,
+			url:                  "/employees_department",
+			body:                 `{"emp_no":"10002","dept_no":"d0006","from_date":"1996-08-04","salary":1}`,
+			expectedResponseCode: http.StatusBadRequest,
+			expectedResponseBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"the request has invalid fields","instance":"/employees_department","code":"validation_failed","errors":[{"field":"to_date","code":"required","message":"is required"},{"field":"dept_no","code":"pattern","message":"must match ^d\\d{3}$"},{"field":"emp_no","code":"invalid","message":"must be integer, got string"},{"field":"salary","code":"unknown","message":"is not a known property"}]}`,
+		},
+		{
+			name:                 "undocumented route",
+			method:               http.MethodGet,
+			url:                  "/undocumented?limit=abc",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "handled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled := func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("handled"))
+			}
+			router := validatedRouter(t, false)
+			router.HandleFunc("/employees", handled).Methods(http.MethodGet)
+			router.HandleFunc("/reports/headcount", handled).Methods(http.MethodGet)
+			router.HandleFunc("/api-keys/{id}", handled).Methods(http.MethodDelete)
+			router.HandleFunc("/employees_department", handled).Methods(http.MethodPost)
+			router.HandleFunc("/undocumented", handled).Methods(http.MethodGet)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			assert.Equal(t, tt.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestValidator_Middleware_Responses(t *testing.T) {
+	tests := []struct {
+		name                 string
+		status               int
+		contentType          string
+		body                 string
+		expectedResponseCode int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "documented response",
+			status:               http.StatusOK,
+			contentType:          "application/json",
+			body:                 `{"message":"api key revoked"}`,
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"message":"api key revoked"}`,
+		},
+		{
+			name:                 "documented problem",
+			status:               http.StatusNotFound,
+			contentType:          "application/problem+json",
+			body:                 `{"type":"about:blank","title":"Not Found","status":404,"detail":"api key not found","instance":"/api-keys/1","code":"api_key_not_found"}`,
+			expectedResponseCode: http.StatusNotFound,
+			expectedResponseBody: `{"type":"about:blank","title":"Not Found","status":404,"detail":"api key not found","instance":"/api-keys/1","code":"api_key_not_found"}`,
+		},
+		{
+			name:                 "body drifted from the document",
+			status:               http.StatusOK,
+			contentType:          "application/json",
+			body:                 `{"message":1}`,
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/api-keys/1","code":"internal_error"}`,
+		},
+		{
+			name:                 "undocumented status",
+			status:               http.StatusConflict,
+			contentType:          "application/json",
+			body:                 `{}`,
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/api-keys/1","code":"internal_error"}`,
+		},
+		{
+			name:                 "undocumented content type",
+			status:               http.StatusOK,
+			contentType:          "text/plain",
+			body:                 `revoked`,
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedResponseBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/api-keys/1","code":"internal_error"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := validatedRouter(t, true)
+			router.HandleFunc("/api-keys/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}).Methods(http.MethodDelete)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/api-keys/1", nil))
+
+			assert.Equal(t, tt.expectedResponseCode, rr.Code)
+			assert.Equal(t, tt.expectedResponseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestValidator_Middleware_Streams_non_JSON_responses(t *testing.T) {
+	router := validatedRouter(t, true)
+	rr := httptest.NewRecorder()
+	router.HandleFunc("/exports/anonymized", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"table":"departments","dept_no":"d005","dept_name":"Development"}` + "\n"))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEmpty(t, rr.Body.String(), "the first line is sent before the handler returns")
+		w.Write([]byte(`{"table":"titles","emp_no":1,"title":"Engineer","from_date":"1986-06-26","to_date":"9999-01-01"}` + "\n"))
+	}).Methods(http.MethodGet)
+
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/exports/anonymized", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Equal(t, 2, strings.Count(rr.Body.String(), "\n"))
+}
+
+func validatedRouter(t *testing.T, validateResponses bool) *mux.Router {
+	spec, err := Load()
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Use(NewValidator(spec, validateResponses).Middleware)
+	return router
+}
@@ -0,0 +1,272 @@
+package openapi
+
+import (
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/libs/validation"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocument_References_resolve(t *testing.T) {
+	var document interface{}
+	require.NoError(t, json.Unmarshal(Document, &document))
+	spec, err := Load()
+	require.NoError(t, err)
+
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch typed := node.(type) {
+		case map[string]interface{}:
+			if ref, ok := typed["$ref"].(string); ok {
+				parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+				require.Len(t, parts, 2, ref)
+				var found bool
+				switch parts[0] {
+				case "schemas":
+					_, found = spec.Components.Schemas[parts[1]]
+				case "parameters":
+					_, found = spec.Components.Parameters[parts[1]]
+				case "responses":
+					_, found = spec.Components.Responses[parts[1]]
+				}
+				assert.True(t, found, "unresolved reference %s", ref)
+			}
+			for _, child := range typed {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range typed {
+				walk(child)
+			}
+		}
+	}
+	walk(document)
+}
+
+// TestDocument_Matches_models fails when a model gains, loses or retypes a field without the document following.
+func TestDocument_Matches_models(t *testing.T) {
+	spec, err := Load()
+	require.NoError(t, err)
+
+	schemaModels := map[string]interface{}{
+		"Problem":                   problem.Problem{},
+		"FieldError":                problem.FieldError{},
+		"Employee":                  models.Employee{},
+		"EmployeeResponse":          models.EmployeeResponse{},
+		"TimelineEvent":             models.TimelineEvent{},
+		"EmployeeTimeline":          models.EmployeeTimeline{},
+		"EmployeeDepartmentRequest": models.EmployeeDepartmentRequest{},
+		"HeadcountGroup":            models.HeadcountGroup{},
+		"HeadcountPeriod":           models.HeadcountPeriod{},
+		"HeadcountReport":           models.HeadcountReport{},
+		"SalaryStatistics":          models.SalaryStatistics{},
+		"SalaryReport":              models.SalaryReport{},
+		"GenderSalary":              models.GenderSalary{},
+		"SalaryGenderGap":           models.SalaryGenderGap{},
+		"SalaryGenderGapReport":     models.SalaryGenderGapReport{},
+		"DepartmentAttrition":       models.DepartmentAttrition{},
+		"AttritionPeriod":           models.AttritionPeriod{},
+		"AttritionReport":           models.AttritionReport{},
+		"TenureBucket":              models.TenureBucket{},
+		"DepartmentTenure":          models.DepartmentTenure{},
+		"TenureReport":              models.TenureReport{},
+		"OrgChartEmployee":          models.OrgChartEmployee{},
+		"OrgChartDepartment":        models.OrgChartDepartment{},
+		"OrgChart":                  models.OrgChart{},
+		"APIKey":                    models.APIKey{},
+		"IssuedAPIKey":              models.IssuedAPIKey{},
+		"APIKeyRequest":             models.APIKeyRequest{},
+		"AuditEntry":                models.AuditEntry{},
+		"AuditResponse":             models.AuditResponse{},
+		"HealthCheck":               models.HealthCheck{},
+		"HealthReport":              models.HealthReport{},
+	}
+
+	for name, model := range schemaModels {
+		t.Run(name, func(t *testing.T) {
+			schema := spec.Resolve(&Schema{Ref: "#/components/schemas/" + name})
+			require.NotNil(t, schema, "missing schema")
+			if len(schema.AllOf) != 0 {
+				schema = spec.merge(schema.AllOf)
+			}
+
+			fields := jsonFields(reflect.TypeOf(model))
+			assert.Equal(t, sortedNames(fields), sortedNames(schema.Properties), "properties")
+
+			for _, required := range schema.Required {
+				if field, ok := fields[required]; ok {
+					assert.False(t, field.omitempty, "%s is required but omitted when empty", required)
+				}
+			}
+			for fieldName, field := range fields {
+				property := spec.Resolve(schema.Properties[fieldName])
+				if property == nil {
+					continue
+				}
+				for _, kind := range field.kinds {
+					assert.True(t, schemaAllows(spec, property, kind), "%s can be %s", fieldName, kind)
+				}
+			}
+		})
+	}
+}
+
+func TestSpec_Validate(t *testing.T) {
+	spec, err := Load()
+	require.NoError(t, err)
+	schema := &Schema{Ref: "#/components/schemas/EmployeeResponse"}
+
+	tests := []struct {
+		name               string
+		body               string
+		expectedViolations []Violation
+	}{
+		{
+			name: "valid",
+			body: `{"total":1,"page":1,"employees":[{"emp_no":1,"birth_date":"1994","first_name":"Lucas","last_name":"Lissandrello","hire_date":"2022-06-20T15:00:00Z","department":"Development"}]}`,
+		},
+		{
+			name: "invalid",
+			body: `{"total":"1","employees":[{"emp_no":1.5,"first_name":"Lucas","last_name":"Lissandrello","hire_date":"2022-06-20","department":"Development","salary":1}]}`,
+			expectedViolations: []Violation{
+				{"/page", validation.CodeRequired, "is required"},
+				{"/employees/0/emp_no", validation.CodeInvalid, "must be integer, got number"},
+				{"/employees/0/hire_date", validation.CodeInvalid, "must be an RFC 3339 date-time"},
+				{"/employees/0/salary", validation.CodeUnknown, "is not a known property"},
+				{"/total", validation.CodeInvalid, "must be integer, got string"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &body))
+
+			assert.Equal(t, tt.expectedViolations, spec.Validate(schema, body))
+		})
+	}
+}
+
+type jsonField struct {
+	omitempty bool
+	kinds     []string
+}
+
+// jsonFields lists the JSON properties of a struct and the JSON types each can take.
+func jsonFields(structType reflect.Type) map[string]jsonField {
+	fields := make(map[string]jsonField)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Anonymous {
+			for name, embedded := range jsonFields(field.Type) {
+				fields[name] = embedded
+			}
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		omitempty := strings.Contains(options, "omitempty")
+		fields[name] = jsonField{omitempty: omitempty, kinds: jsonKinds(field.Type, omitempty)}
+	}
+	return fields
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+func jsonKinds(fieldType reflect.Type, omitempty bool) []string {
+	switch {
+	case fieldType == reflect.TypeOf(time.Time{}):
+		return []string{"string"}
+	case fieldType == rawMessageType:
+		return nil
+	}
+
+	switch fieldType.Kind() {
+	case reflect.Pointer:
+		kinds := jsonKinds(fieldType.Elem(), omitempty)
+		if !omitempty {
+			kinds = append(kinds, "null")
+		}
+		return kinds
+	case reflect.String:
+		return []string{"string"}
+	case reflect.Bool:
+		return []string{"boolean"}
+	case reflect.Int, reflect.Int64:
+		return []string{"integer"}
+	case reflect.Float64:
+		return []string{"number"}
+	case reflect.Slice:
+		return []string{"array"}
+	default:
+		return []string{"object"}
+	}
+}
+
+func schemaAllows(spec *Spec, schema *Schema, kind string) bool {
+	if len(schema.OneOf) != 0 {
+		for _, option := range schema.OneOf {
+			if schemaAllows(spec, spec.Resolve(option), kind) {
+				return true
+			}
+		}
+		return false
+	}
+	return schema.Type.Allows(kind)
+}
+
+func sortedNames[T any](fields map[string]T) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDocsAssetHandler(t *testing.T) {
+	router := validatedRouter(t, true)
+	router.Handle("/docs/{asset}", DocsAssetHandler()).Methods(http.MethodGet)
+
+	tests := []struct {
+		asset               string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{"swagger-ui-bundle.js", http.StatusOK, "text/javascript; charset=utf-8"},
+		{"swagger-ui.css", http.StatusOK, "text/css; charset=utf-8"},
+		{"index.html", http.StatusNotFound, problem.ContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.asset, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs/"+tt.asset, nil))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestDocsHandler_Loads_vendored_assets_only(t *testing.T) {
+	page := string(docsPage)
+
+	assert.Contains(t, page, `src="/docs/swagger-ui-bundle.js"`)
+	assert.Contains(t, page, `href="/docs/swagger-ui.css"`)
+	assert.NotContains(t, page, "https://")
+}
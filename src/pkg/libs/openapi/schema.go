@@ -0,0 +1,265 @@
+package openapi
+
+import (
+	"employee_exercise/src/pkg/libs/validation"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schema is the subset of JSON Schema the document uses.
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 Types              `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []interface{}      `json:"enum"`
+	Pattern              string             `json:"pattern"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *Schema            `json:"items"`
+	AdditionalProperties *Schema            `json:"additionalProperties"`
+	AllOf                []*Schema          `json:"allOf"`
+	OneOf                []*Schema          `json:"oneOf"`
+}
+
+// Types is the type keyword, a single type or a list of them.
+type Types []string
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+func (t Types) Allows(name string) bool {
+	if len(t) == 0 {
+		return true
+	}
+	for _, allowed := range t {
+		if allowed == name || allowed == "number" && name == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// Violation is a value that does not match its schema. Path points at it, like /employees/0/emp_no, and Code
+// is one of the validation field codes.
+type Violation struct {
+	Path    string
+	Code    string
+	Message string
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// Resolve follows a schema reference.
+func (s *Spec) Resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = s.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// Validate checks a decoded JSON value against a schema. Objects are closed: a property the schema does not
+// list is a violation, so responses cannot grow fields the document does not know about.
+func (s *Spec) Validate(schema *Schema, value interface{}) []Violation {
+	return s.validate(schema, value, "")
+}
+
+func (s *Spec) validate(schema *Schema, value interface{}, path string) []Violation {
+	schema = s.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+
+	if len(schema.AllOf) != 0 {
+		return s.validate(s.merge(schema.AllOf), value, path)
+	}
+	if len(schema.OneOf) != 0 {
+		matches := 0
+		for _, option := range schema.OneOf {
+			if len(s.validate(option, value, path)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return []Violation{{path, validation.CodeInvalid, fmt.Sprintf("must match exactly one schema, matches %d", matches)}}
+		}
+		return nil
+	}
+
+	kind := jsonType(value)
+	if !schema.Type.Allows(kind) {
+		return []Violation{{path, validation.CodeInvalid, fmt.Sprintf("must be %s, got %s", strings.Join(schema.Type, " or "), kind)}}
+	}
+	if len(schema.Enum) != 0 && !contains(schema.Enum, value) {
+		return []Violation{{path, validation.CodeInvalid, fmt.Sprintf("must be one of %s", enumList(schema.Enum))}}
+	}
+
+	switch typed := value.(type) {
+	case string:
+		return s.validateString(schema, typed, path)
+	case float64:
+		return s.validateNumber(schema, typed, path)
+	case []interface{}:
+		var violations []Violation
+		for i, item := range typed {
+			violations = append(violations, s.validate(schema.Items, item, fmt.Sprintf("%s/%d", path, i))...)
+		}
+		return violations
+	case map[string]interface{}:
+		return s.validateObject(schema, typed, path)
+	}
+	return nil
+}
+
+func (s *Spec) validateString(schema *Schema, value, path string) []Violation {
+	if schema.MinLength != nil && len(value) < *schema.MinLength {
+		return []Violation{{path, validation.CodeMin, fmt.Sprintf("must be at least %d characters", *schema.MinLength)}}
+	}
+	if schema.MaxLength != nil && len(value) > *schema.MaxLength {
+		return []Violation{{path, validation.CodeMax, fmt.Sprintf("must be at most %d characters", *schema.MaxLength)}}
+	}
+	if schema.Pattern != "" && !compile(schema.Pattern).MatchString(value) {
+		return []Violation{{path, validation.CodePattern, "must match " + schema.Pattern}}
+	}
+
+	switch schema.Format {
+	case "date":
+		if _, err := time.Parse(validation.DateLayout, value); err != nil {
+			return []Violation{{path, validation.CodeDate, "must be a date formatted as YYYY-MM-DD"}}
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return []Violation{{path, validation.CodeInvalid, "must be an RFC 3339 date-time"}}
+		}
+	}
+	return nil
+}
+
+func (s *Spec) validateNumber(schema *Schema, value float64, path string) []Violation {
+	if schema.Minimum != nil && value < *schema.Minimum {
+		return []Violation{{path, validation.CodeMin, fmt.Sprintf("must be at least %v", *schema.Minimum)}}
+	}
+	if schema.Maximum != nil && value > *schema.Maximum {
+		return []Violation{{path, validation.CodeMax, fmt.Sprintf("must be at most %v", *schema.Maximum)}}
+	}
+	return nil
+}
+
+func (s *Spec) validateObject(schema *Schema, value map[string]interface{}, path string) []Violation {
+	var violations []Violation
+	for _, name := range schema.Required {
+		if _, ok := value[name]; !ok {
+			violations = append(violations, Violation{path + "/" + name, validation.CodeRequired, "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		if !ok {
+			property = schema.AdditionalProperties
+		}
+		if property == nil {
+			if schema.Properties != nil {
+				violations = append(violations, Violation{path + "/" + name, validation.CodeUnknown, "is not a known property"})
+			}
+			continue
+		}
+		violations = append(violations, s.validate(property, value[name], path+"/"+name)...)
+	}
+	return violations
+}
+
+// merge combines the object schemas of an allOf into one, so that each part does not reject the
+// properties of the others.
+func (s *Spec) merge(schemas []*Schema) *Schema {
+	merged := &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}}
+	for _, part := range schemas {
+		part = s.Resolve(part)
+		for name, property := range part.Properties {
+			merged.Properties[name] = property
+		}
+		merged.Required = append(merged.Required, part.Required...)
+	}
+	return merged
+}
+
+var patterns sync.Map
+
+// compile parses a schema pattern once, the document is trusted so a bad pattern panics.
+func compile(pattern string) *regexp.Regexp {
+	if compiled, ok := patterns.Load(pattern); ok {
+		return compiled.(*regexp.Regexp)
+	}
+	compiled := regexp.MustCompile(pattern)
+	patterns.Store(pattern, compiled)
+	return compiled
+}
+
+func jsonType(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if typed == math.Trunc(typed) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(values []interface{}) string {
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		names = append(names, fmt.Sprint(value))
+	}
+	return strings.Join(names, ", ")
+}
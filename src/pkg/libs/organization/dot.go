@@ -0,0 +1,61 @@
+package organization
+
+import (
+	"bufio"
+	"employee_exercise/src/pkg/models"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT renders the chart as a Graphviz digraph: organization -> department -> manager -> members.
+func WriteDOT(w io.Writer, chart *models.OrgChart) error {
+	writer := bufio.NewWriter(w)
+
+	fmt.Fprintln(writer, "digraph org_chart {")
+	fmt.Fprintln(writer, "\trankdir=TB;")
+	fmt.Fprintln(writer, "\tnode [shape=box];")
+	fmt.Fprintf(writer, "\torganization [label=%s];\n", quote("Organization\nas of "+chart.AsOf.Format(dateLayout)))
+
+	for _, department := range chart.Departments {
+		departmentNode := quote(department.DepartmentNumber)
+		fmt.Fprintf(writer, "\t%s [label=%s, shape=folder];\n", departmentNode,
+			quote(department.DepartmentNumber+"\n"+department.DepartmentName))
+		fmt.Fprintf(writer, "\torganization -> %s;\n", departmentNode)
+
+		parent := departmentNode
+		if department.Manager != nil {
+			parent = employeeNode(*department.Manager)
+			fmt.Fprintf(writer, "\t%s [label=%s, style=bold];\n", parent, employeeLabel(*department.Manager))
+			fmt.Fprintf(writer, "\t%s -> %s;\n", departmentNode, parent)
+		}
+
+		for _, member := range department.Members {
+			memberNode := employeeNode(member)
+			fmt.Fprintf(writer, "\t%s [label=%s];\n", memberNode, employeeLabel(member))
+			fmt.Fprintf(writer, "\t%s -> %s;\n", parent, memberNode)
+		}
+	}
+
+	fmt.Fprintln(writer, "}")
+
+	return writer.Flush()
+}
+
+func employeeNode(employee models.OrgChartEmployee) string {
+	return quote(fmt.Sprintf("e%d", employee.EmployeeNumber))
+}
+
+func employeeLabel(employee models.OrgChartEmployee) string {
+	label := fmt.Sprintf("%s %s\n#%d", employee.FirstName, employee.LastName, employee.EmployeeNumber)
+	if employee.Title != "" {
+		label += "\n" + employee.Title
+	}
+
+	return quote(label)
+}
+
+func quote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,190 @@
+package organization
+
+import (
+	"context"
+	"database/sql"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+const (
+	departmentsQuery = "SELECT dept_no, dept_name FROM departments"
+	managersQuery    = "SELECT dm.dept_no, e.emp_no, e.first_name, e.last_name, COALESCE(t.title, '') FROM dept_manager dm " +
+		"JOIN employees e ON dm.emp_no = e.emp_no " +
+		"LEFT JOIN titles t ON t.emp_no = e.emp_no AND t.from_date <= ? AND t.to_date > ? " +
+		"WHERE dm.from_date <= ? AND dm.to_date > ?"
+	membersQuery = "SELECT de.dept_no, e.emp_no, e.first_name, e.last_name, COALESCE(t.title, '') FROM dept_emp de " +
+		"JOIN employees e ON de.emp_no = e.emp_no " +
+		"LEFT JOIN titles t ON t.emp_no = e.emp_no AND t.from_date <= ? AND t.to_date > ? " +
+		"WHERE de.from_date <= ? AND de.to_date > ?"
+
+	dateLayout = "2006-01-02"
+)
+
+var ErrDepartmentNotFound = errors.New("department not found")
+
+// MemberScope limits the employees a caller may see to the members of the departments they manage.
+type MemberScope interface {
+	VisibleCondition(ctx context.Context, column string, asOf time.Time) (condition string, arguments []interface{}, visible bool, err error)
+}
+
+type OrganizationService struct {
+	OrganizationManager *sql.DB
+	// Scope hides the members the caller may not see, every member is shown when it is nil.
+	Scope MemberScope
+}
+
+// GetOrgChart returns every department, or only departmentID when it is not empty, with the manager and members at asOf.
+// Managers of a department are always shown, members only when the caller's scope allows it.
+func (o *OrganizationService) GetOrgChart(ctx context.Context, asOf time.Time, departmentID string) (*models.OrgChart, error) {
+	departments, err := o.getDepartments(ctx, departmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if departmentID != "" && len(departments) == 0 {
+		slog.InfoContext(ctx, "department not found", "dept_no", departmentID)
+		return nil, ErrDepartmentNotFound
+	}
+
+	date := asOf.Format(dateLayout)
+	managers, err := o.getDepartmentEmployees(ctx, managersQuery, "dm", date, departmentID, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	condition, arguments, visible := "", []interface{}(nil), true
+	if o.Scope != nil {
+		condition, arguments, visible, err = o.Scope.VisibleCondition(ctx, "e.emp_no", asOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	members := map[string][]models.OrgChartEmployee{}
+	if visible {
+		members, err = o.getDepartmentEmployees(ctx, membersQuery, "de", date, departmentID, condition, arguments)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	chart := models.OrgChart{
+		AsOf:        asOf,
+		Departments: make([]models.OrgChartDepartment, 0, len(departments)),
+	}
+
+	for _, department := range departments {
+		orgChartDepartment := models.OrgChartDepartment{
+			DepartmentNumber: department.DepartmentNumber,
+			DepartmentName:   department.DepartmentName,
+			Members:          []models.OrgChartEmployee{},
+		}
+
+		var managerNumber int
+		if departmentManagers := managers[department.DepartmentNumber]; len(departmentManagers) > 0 {
+			manager := departmentManagers[0]
+			managerNumber = manager.EmployeeNumber
+			orgChartDepartment.Manager = &manager
+		}
+
+		for _, member := range members[department.DepartmentNumber] {
+			if member.EmployeeNumber != managerNumber {
+				orgChartDepartment.Members = append(orgChartDepartment.Members, member)
+			}
+		}
+
+		chart.Departments = append(chart.Departments, orgChartDepartment)
+	}
+
+	return &chart, nil
+}
+
+func (o *OrganizationService) getDepartments(ctx context.Context, departmentID string) ([]models.Department, error) {
+	query := departmentsQuery
+	var args []interface{}
+	if departmentID != "" {
+		query += " WHERE dept_no = ?"
+		args = append(args, departmentID)
+	}
+	query += " ORDER BY dept_no"
+
+	stmt, err := o.OrganizationManager.PrepareContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql select query for departments", "error", err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql select query for departments", "error", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var departments []models.Department
+	for rows.Next() {
+		department := models.Department{}
+		err = rows.Scan(&department.DepartmentNumber, &department.DepartmentName)
+		if err != nil {
+			slog.ErrorContext(ctx, "error scanning sql select query for departments", "error", err)
+			return nil, err
+		}
+
+		departments = append(departments, department)
+	}
+
+	return departments, rows.Err()
+}
+
+// getDepartmentEmployees returns the employees of query by department, keeping only those matching condition.
+func (o *OrganizationService) getDepartmentEmployees(ctx context.Context, query, alias, date, departmentID, condition string, arguments []interface{}) (map[string][]models.OrgChartEmployee, error) {
+	args := append([]interface{}{date, date, date, date}, arguments...)
+	query += condition
+	if departmentID != "" {
+		query += " AND " + alias + ".dept_no = ?"
+		args = append(args, departmentID)
+	}
+	query += " ORDER BY " + alias + ".dept_no, e.emp_no"
+
+	stmt, err := o.OrganizationManager.PrepareContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql select query for department employees", "error", err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql select query for department employees", "error", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	employees := make(map[string][]models.OrgChartEmployee)
+	for rows.Next() {
+		var departmentNumber string
+		employee := models.OrgChartEmployee{}
+		err = rows.Scan(&departmentNumber, &employee.EmployeeNumber, &employee.FirstName, &employee.LastName, &employee.Title)
+		if err != nil {
+			slog.ErrorContext(ctx, "error scanning sql select query for department employees", "error", err)
+			return nil, err
+		}
+
+		departmentEmployees := employees[departmentNumber]
+		if n := len(departmentEmployees); n > 0 && departmentEmployees[n-1].EmployeeNumber == employee.EmployeeNumber {
+			continue
+		}
+
+		employees[departmentNumber] = append(departmentEmployees, employee)
+	}
+
+	return employees, rows.Err()
+}
@@ -0,0 +1,276 @@
+package organization
+
+import (
+	"bytes"
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/employee"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestOrganizationService_GetOrgChart_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(departmentsQuery + " ORDER BY dept_no")).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name"}).
+			AddRow("d001", "Marketing").
+			AddRow("d002", "Finance"))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(managersQuery+" ORDER BY dm.dept_no, e.emp_no")).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01").
+		WillReturnRows(orgChartEmployeeRows().
+			AddRow("d001", 110022, "Margareta", "Markovitch", "Manager"))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(membersQuery+" ORDER BY de.dept_no, e.emp_no")).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01").
+		WillReturnRows(orgChartEmployeeRows().
+			AddRow("d001", 10017, "Cristinel", "Bouloucos", "Senior Staff").
+			AddRow("d001", 110022, "Margareta", "Markovitch", "Manager").
+			AddRow("d002", 10042, "Magy", "Stamatiou", "Senior Engineer").
+			AddRow("d002", 10042, "Magy", "Stamatiou", "Engineer"))
+
+	organizationService := &OrganizationService{OrganizationManager: db}
+
+	chart, err := organizationService.GetOrgChart(context.Background(), mockAsOf(), "")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, mockOrgChart(), chart)
+}
+
+func TestOrganizationService_GetOrgChart_Filters_by_department(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(departmentsQuery + " WHERE dept_no = ? ORDER BY dept_no")).
+		ExpectQuery().
+		WithArgs("d002").
+		WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name"}).AddRow("d002", "Finance"))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(managersQuery+" AND dm.dept_no = ? ORDER BY dm.dept_no, e.emp_no")).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01", "d002").
+		WillReturnRows(orgChartEmployeeRows())
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(membersQuery+" AND de.dept_no = ? ORDER BY de.dept_no, e.emp_no")).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01", "d002").
+		WillReturnRows(orgChartEmployeeRows().AddRow("d002", 10042, "Magy", "Stamatiou", "Senior Engineer"))
+
+	organizationService := &OrganizationService{OrganizationManager: db}
+
+	chart, err := organizationService.GetOrgChart(context.Background(), mockAsOf(), "d002")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, &models.OrgChart{
+		AsOf:        mockAsOf(),
+		Departments: []models.OrgChartDepartment{mockOrgChart().Departments[1]},
+	}, chart)
+}
+
+func TestOrganizationService_GetOrgChart_Hides_members_of_other_departments_from_managers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(departmentsQuery + " ORDER BY dept_no")).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name"}).
+			AddRow("d001", "Marketing").
+			AddRow("d002", "Finance"))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(managersQuery+" ORDER BY dm.dept_no, e.emp_no")).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01").
+		WillReturnRows(orgChartEmployeeRows().
+			AddRow("d001", 110022, "Margareta", "Markovitch", "Manager"))
+
+	expectManagedDepartments(mock, "d001")
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(membersQuery+
+			" AND e.emp_no IN (SELECT de.emp_no FROM dept_emp de WHERE de.dept_no IN (?) AND de.from_date <= ? AND de.to_date > ?)"+
+			" ORDER BY de.dept_no, e.emp_no")).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01", "d001", "2000-01-01", "2000-01-01").
+		WillReturnRows(orgChartEmployeeRows().
+			AddRow("d001", 10017, "Cristinel", "Bouloucos", "Senior Staff").
+			AddRow("d001", 110022, "Margareta", "Markovitch", "Manager"))
+
+	organizationService := &OrganizationService{OrganizationManager: db, Scope: &employee.EmployeeService{EmployeeManager: db}}
+
+	chart, err := organizationService.GetOrgChart(withManager(), mockAsOf(), "")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	expected := mockOrgChart()
+	expected.Departments[1].Members = []models.OrgChartEmployee{}
+	assert.Equal(t, expected, chart)
+}
+
+func TestOrganizationService_GetOrgChart_Hides_every_member_from_former_managers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(departmentsQuery + " WHERE dept_no = ? ORDER BY dept_no")).
+		ExpectQuery().
+		WithArgs("d002").
+		WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name"}).AddRow("d002", "Finance"))
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(managersQuery+" AND dm.dept_no = ? ORDER BY dm.dept_no, e.emp_no")).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01", "d002").
+		WillReturnRows(orgChartEmployeeRows())
+
+	expectManagedDepartments(mock)
+
+	organizationService := &OrganizationService{OrganizationManager: db, Scope: &employee.EmployeeService{EmployeeManager: db}}
+
+	chart, err := organizationService.GetOrgChart(withManager(), mockAsOf(), "d002")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, &models.OrgChart{
+		AsOf:        mockAsOf(),
+		Departments: []models.OrgChartDepartment{{DepartmentNumber: "d002", DepartmentName: "Finance", Members: []models.OrgChartEmployee{}}},
+	}, chart)
+}
+
+func TestOrganizationService_GetOrgChart_Fails_when_department_not_exists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(departmentsQuery + " WHERE dept_no = ? ORDER BY dept_no")).
+		ExpectQuery().
+		WithArgs("d999").
+		WillReturnRows(sqlmock.NewRows([]string{"dept_no", "dept_name"}))
+
+	organizationService := &OrganizationService{OrganizationManager: db}
+
+	chart, err := organizationService.GetOrgChart(context.Background(), mockAsOf(), "d999")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, chart)
+	assert.Equal(t, ErrDepartmentNotFound, err)
+}
+
+func TestOrganizationService_GetOrgChart_Fails_preparing_departments_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(departmentsQuery + " ORDER BY dept_no")).
+		WillReturnError(errors.New("error preparing query in database"))
+
+	organizationService := &OrganizationService{OrganizationManager: db}
+
+	chart, err := organizationService.GetOrgChart(context.Background(), mockAsOf(), "")
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, chart)
+	assert.Equal(t, errors.New("error preparing query in database"), err)
+}
+
+func TestWriteDOT(t *testing.T) {
+	chart := mockOrgChart()
+	chart.Departments[1].Members[0].FirstName = `Magy "The Mag"`
+
+	var buffer bytes.Buffer
+	err := WriteDOT(&buffer, chart)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph org_chart {
+	rankdir=TB;
+	node [shape=box];
+	organization [label="Organization\nas of 2000-01-01"];
+	"d001" [label="d001\nMarketing", shape=folder];
+	organization -> "d001";
+	"e110022" [label="Margareta Markovitch\n#110022\nManager", style=bold];
+	"d001" -> "e110022";
+	"e10017" [label="Cristinel Bouloucos\n#10017\nSenior Staff"];
+	"e110022" -> "e10017";
+	"d002" [label="d002\nFinance", shape=folder];
+	organization -> "d002";
+	"e10042" [label="Magy \"The Mag\" Stamatiou\n#10042\nSenior Engineer"];
+	"d002" -> "e10042";
+}
+`, buffer.String())
+}
+
+func orgChartEmployeeRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"dept_no", "emp_no", "first_name", "last_name", "title"})
+}
+
+func expectManagedDepartments(mock sqlmock.Sqlmock, departments ...string) {
+	rows := sqlmock.NewRows([]string{"dept_no"})
+	for _, department := range departments {
+		rows.AddRow(department)
+	}
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta("SELECT dept_no FROM dept_manager WHERE emp_no = ?")).
+		ExpectQuery().
+		WithArgs(110022, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(rows)
+}
+
+func withManager() context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "jwt:margareta", Roles: []string{auth.RoleManager}, EmployeeNumber: 110022})
+}
+
+func mockAsOf() time.Time {
+	return time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func mockOrgChart() *models.OrgChart {
+	return &models.OrgChart{
+		AsOf: mockAsOf(),
+		Departments: []models.OrgChartDepartment{
+			{
+				DepartmentNumber: "d001",
+				DepartmentName:   "Marketing",
+				Manager:          &models.OrgChartEmployee{EmployeeNumber: 110022, FirstName: "Margareta", LastName: "Markovitch", Title: "Manager"},
+				Members:          []models.OrgChartEmployee{{EmployeeNumber: 10017, FirstName: "Cristinel", LastName: "Bouloucos", Title: "Senior Staff"}},
+			},
+			{
+				DepartmentNumber: "d002",
+				DepartmentName:   "Finance",
+				Members:          []models.OrgChartEmployee{{EmployeeNumber: 10042, FirstName: "Magy", LastName: "Stamatiou", Title: "Senior Engineer"}},
+			},
+		},
+	}
+}
@@ -0,0 +1,181 @@
+package privacy
+
+import (
+	"bytes"
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
+	"encoding/json"
+	"math"
+	"time"
+)
+
+type Action string
+
+const (
+	Keep       Action = "keep"
+	Omit       Action = "omit"
+	Redact     Action = "redact"
+	Generalize Action = "generalize"
+
+	// Redacted replaces the value of redacted fields.
+	Redacted = "****"
+
+	// salaryBand is the width generalized numbers are rounded down to.
+	salaryBand = 10000
+)
+
+// Policy maps JSON field names to the action applied to them, at any depth of a response.
+type Policy map[string]Action
+
+// Profiles are the policies PII_PROFILE selects from.
+var Profiles = map[string]Policy{
+	"none": {},
+	"standard": {
+		"birth_date": Generalize, "gender": Redact, "salary": Generalize, "previous_salary": Generalize,
+	},
+	"strict": {
+		"birth_date": Omit, "gender": Omit, "salary": Omit, "previous_salary": Omit,
+	},
+}
+
+// NewPolicy returns the configured profile with the per field actions applied over it.
+func NewPolicy(cfg config.PIIConfig) Policy {
+	policy := Policy{}
+	for field, action := range Profiles[cfg.Profile] {
+		policy[field] = action
+	}
+	for field, action := range cfg.Fields {
+		policy[field] = Action(action)
+	}
+
+	return policy
+}
+
+type Masker struct {
+	Policy Policy
+}
+
+func NewMasker(cfg config.PIIConfig) *Masker {
+	return &Masker{Policy: NewPolicy(cfg)}
+}
+
+// Mask returns the JSON encoding of v with the policy applied. Calls without a principal, as in tests
+// and commands, and principals with the read:pii permission get v unchanged.
+func (m *Masker) Mask(ctx context.Context, v interface{}) (interface{}, error) {
+	if m == nil || len(m.Policy) == 0 {
+		return v, nil
+	}
+
+	principal := auth.PrincipalFromContext(ctx)
+	if principal == nil || principal.Can(auth.PermissionReadPII) {
+		return v, nil
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Policy.Apply(encoded)
+}
+
+// Apply masks the fields of a JSON document, keeping the order of the remaining ones.
+func (p Policy) Apply(document json.RawMessage) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	switch token {
+	case json.Delim('{'):
+		buffer.WriteByte('{')
+		for decoder.More() {
+			key, keyError := decoder.Token()
+			if keyError != nil {
+				return nil, keyError
+			}
+
+			var value json.RawMessage
+			if err = decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+
+			field, _ := key.(string)
+			switch p[field] {
+			case Omit:
+				continue
+			case Redact:
+				value = json.RawMessage(`"` + Redacted + `"`)
+			case Generalize:
+				value = generalize(value)
+			default:
+				if value, err = p.Apply(value); err != nil {
+					return nil, err
+				}
+			}
+
+			if buffer.Len() > 1 {
+				buffer.WriteByte(',')
+			}
+			name, _ := json.Marshal(field)
+			buffer.Write(name)
+			buffer.WriteByte(':')
+			buffer.Write(value)
+		}
+		buffer.WriteByte('}')
+
+	case json.Delim('['):
+		buffer.WriteByte('[')
+		for decoder.More() {
+			var value json.RawMessage
+			if err = decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+			if value, err = p.Apply(value); err != nil {
+				return nil, err
+			}
+
+			if buffer.Len() > 1 {
+				buffer.WriteByte(',')
+			}
+			buffer.Write(value)
+		}
+		buffer.WriteByte(']')
+
+	default:
+		return document, nil
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// generalize keeps the year of dates and rounds numbers down to a multiple of salaryBand, other values
+// but null are redacted.
+func generalize(value json.RawMessage) json.RawMessage {
+	if string(value) == "null" {
+		return value
+	}
+
+	var text string
+	if json.Unmarshal(value, &text) == nil {
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if parsed, err := time.Parse(layout, text); err == nil {
+				year, _ := json.Marshal(parsed.Format("2006"))
+				return year
+			}
+		}
+	}
+
+	var number float64
+	if json.Unmarshal(value, &number) == nil {
+		banded, _ := json.Marshal(math.Floor(number/salaryBand) * salaryBand)
+		return banded
+	}
+
+	return json.RawMessage(`"` + Redacted + `"`)
+}
@@ -0,0 +1,120 @@
+package privacy
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
+	"employee_exercise/src/pkg/models"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewPolicy(t *testing.T) {
+	policy := NewPolicy(config.PIIConfig{Profile: "standard", Fields: map[string]string{"gender": "keep", "hire_date": "generalize"}})
+
+	assert.Equal(t, Policy{
+		"birth_date": Generalize, "gender": Keep, "salary": Generalize, "previous_salary": Generalize, "hire_date": Generalize,
+	}, policy)
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		document string
+		expected string
+	}{
+		{
+			name:     "nested fields keep their order",
+			policy:   Policy{"birth_date": Generalize, "gender": Redact, "salary": Omit},
+			document: `{"total":1,"employees":[{"emp_no":10001,"birth_date":"1953-09-02T00:00:00Z","gender":"M","salary":60117,"first_name":"Georgi"}]}`,
+			expected: `{"total":1,"employees":[{"emp_no":10001,"birth_date":"1953","gender":"****","first_name":"Georgi"}]}`,
+		},
+		{
+			name:     "numbers are rounded down to a band",
+			policy:   Policy{"salary": Generalize, "previous_salary": Generalize},
+			document: `[{"salary":69999,"previous_salary":60000},{"type":"hire"}]`,
+			expected: `[{"salary":60000,"previous_salary":60000},{"type":"hire"}]`,
+		},
+		{
+			name:     "plain dates, null and other values",
+			policy:   Policy{"hire_date": Generalize, "manager": Generalize, "first_name": Generalize},
+			document: `{"hire_date":"1986-06-26","manager":null,"first_name":"Georgi"}`,
+			expected: `{"hire_date":"1986","manager":null,"first_name":"****"}`,
+		},
+		{
+			name:     "scalar documents are unchanged",
+			policy:   Policy{"gender": Omit},
+			document: `"gender"`,
+			expected: `"gender"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked, err := tt.policy.Apply(json.RawMessage(tt.document))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(masked))
+		})
+	}
+}
+
+func TestMasker_Mask(t *testing.T) {
+	employee := models.Employee{
+		EmployeeNumber: 10001, BirthDate: time.Date(1953, 9, 2, 0, 0, 0, 0, time.UTC), FirstName: "Georgi",
+		LastName: "Facello", Gender: "M", HireDate: time.Date(1986, 6, 26, 0, 0, 0, 0, time.UTC), Department: "d005",
+	}
+
+	tests := []struct {
+		name      string
+		masker    *Masker
+		principal *auth.Principal
+		expected  string
+	}{
+		{
+			name:      "caller without read:pii",
+			masker:    NewMasker(config.PIIConfig{Profile: "standard"}),
+			principal: &auth.Principal{Roles: []string{auth.RoleHR}},
+			expected:  `{"emp_no":10001,"birth_date":"1953","first_name":"Georgi","last_name":"Facello","gender":"****","hire_date":"1986-06-26T00:00:00Z","department":"d005"}`,
+		},
+		{
+			name:      "strict profile",
+			masker:    NewMasker(config.PIIConfig{Profile: "strict"}),
+			principal: &auth.Principal{Roles: []string{auth.RoleManager}},
+			expected:  `{"emp_no":10001,"first_name":"Georgi","last_name":"Facello","hire_date":"1986-06-26T00:00:00Z","department":"d005"}`,
+		},
+		{
+			name:      "caller with read:pii",
+			masker:    NewMasker(config.PIIConfig{Profile: "strict"}),
+			principal: &auth.Principal{Roles: []string{auth.RoleHRAdmin}},
+			expected:  `{"emp_no":10001,"birth_date":"1953-09-02T00:00:00Z","first_name":"Georgi","last_name":"Facello","gender":"M","hire_date":"1986-06-26T00:00:00Z","department":"d005"}`,
+		},
+		{
+			name:     "no principal",
+			masker:   NewMasker(config.PIIConfig{Profile: "strict"}),
+			expected: `{"emp_no":10001,"birth_date":"1953-09-02T00:00:00Z","first_name":"Georgi","last_name":"Facello","gender":"M","hire_date":"1986-06-26T00:00:00Z","department":"d005"}`,
+		},
+		{
+			name:      "no masker",
+			principal: &auth.Principal{Roles: []string{auth.RoleHR}},
+			expected:  `{"emp_no":10001,"birth_date":"1953-09-02T00:00:00Z","first_name":"Georgi","last_name":"Facello","gender":"M","hire_date":"1986-06-26T00:00:00Z","department":"d005"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+
+			masked, err := tt.masker.Mask(ctx, employee)
+			assert.NoError(t, err)
+
+			encoded, err := json.Marshal(masked)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(encoded))
+		})
+	}
+}
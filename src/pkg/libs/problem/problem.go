@@ -0,0 +1,86 @@
+package problem
+
+import (
+	"employee_exercise/src/pkg/libs/logging"
+	"encoding/json"
+	"net/http"
+)
+
+const ContentType = "application/problem+json"
+
+// Codes are stable, clients may switch on them. Details are for humans and may change.
+const (
+	CodeInvalidParameter = "invalid_parameter"
+	CodeInvalidBody      = "invalid_body"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+)
+
+// FieldError tells which field of the request is wrong and why.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem details object, extended with a stable code, the invalid fields and
+// the request ID to quote when reporting the error.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+func New(status int, code, detail string) *Problem {
+	return &Problem{Status: status, Code: code, Detail: detail}
+}
+
+// InvalidParameter is the problem of a query or path parameter that cannot be used.
+func InvalidParameter(name string) *Problem {
+	problem := New(http.StatusBadRequest, CodeInvalidParameter, "bad request, wrong "+name+" parameter")
+	problem.Errors = []FieldError{{Field: name, Code: "invalid", Message: "wrong " + name + " parameter"}}
+	return problem
+}
+
+// Validation is the problem of a request with invalid fields, all of them listed.
+func Validation(errors []FieldError) *Problem {
+	problem := New(http.StatusBadRequest, CodeValidationFailed, "the request has invalid fields")
+	problem.Errors = errors
+	return problem
+}
+
+// Internal hides the cause of the error, which is only logged.
+func Internal() *Problem {
+	return New(http.StatusInternalServerError, CodeInternal, "internal server error")
+}
+
+// Write sends the problem as application/problem+json. Type defaults to about:blank and Title to the
+// status text, as RFC 7807 asks for problems without a type of their own.
+func Write(w http.ResponseWriter, r *http.Request, problem *Problem) {
+	response := *problem
+	if response.Type == "" {
+		response.Type = "about:blank"
+	}
+	if response.Title == "" {
+		response.Title = http.StatusText(response.Status)
+	}
+	if response.Instance == "" {
+		response.Instance = r.URL.Path
+	}
+	response.RequestID = logging.RequestID(r.Context())
+
+	body, _ := json.Marshal(response)
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(response.Status)
+	w.Write(body)
+}
@@ -0,0 +1,50 @@
+package problem
+
+import (
+	"employee_exercise/src/pkg/libs/logging"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name         string
+		problem      *Problem
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "invalid parameter",
+			problem:      InvalidParameter("limit"),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request, wrong limit parameter","instance":"/employees","code":"invalid_parameter","errors":[{"field":"limit","code":"invalid","message":"wrong limit parameter"}],"request_id":"abc"}`,
+		},
+		{
+			name:         "internal error",
+			problem:      Internal(),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/employees","code":"internal_error","request_id":"abc"}`,
+		},
+		{
+			name:         "own type and title",
+			problem:      &Problem{Type: "https://example.com/problems/out-of-stock", Title: "Out of stock", Status: http.StatusConflict, Code: CodeConflict},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"type":"https://example.com/problems/out-of-stock","title":"Out of stock","status":409,"instance":"/employees","code":"conflict","request_id":"abc"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/employees?limit=x", nil)
+			request = request.WithContext(logging.WithRequestID(request.Context(), "abc"))
+			recorder := httptest.NewRecorder()
+
+			Write(recorder, request, tt.problem)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, recorder.Body.String())
+		})
+	}
+}
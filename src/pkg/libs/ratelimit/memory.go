@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"math"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// MemoryStore keeps the buckets in process, so every replica enforces the quotas on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (m *MemoryStore) Take(ctx context.Context, key string, quota config.RateQuota, now time.Time) (Result, error) {
+	return m.take(key, quota, now, 1), nil
+}
+
+func (m *MemoryStore) Peek(ctx context.Context, key string, quota config.RateQuota, now time.Time) (Result, error) {
+	return m.take(key, quota, now, 0), nil
+}
+
+// take refills the bucket and, when it holds a token, takes cost tokens from it.
+func (m *MemoryStore) take(key string, quota config.RateQuota, now time.Time, cost float64) Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	capacity := float64(quota.Burst)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		m.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*float64(quota.Rate))
+		b.updated = now
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens -= cost
+	}
+
+	r := result(quota, b.tokens, allowed)
+	b.full = now.Add(r.Reset)
+
+	return r
+}
+
+// sweep forgets buckets that have refilled completely, they are equivalent to new ones.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+
+	m.lastSweep = now
+}
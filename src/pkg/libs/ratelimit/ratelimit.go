@@ -0,0 +1,184 @@
+package ratelimit
+
+import (
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
+	"employee_exercise/src/pkg/libs/problem"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Decisions of the limiter, reported to its observer. A store error lets the request through.
+const (
+	DecisionAllowed    = "allowed"
+	DecisionRejected   = "rejected"
+	DecisionStoreError = "store_error"
+)
+
+// Limiter keeps a token bucket per client and route in its store. Buckets refill at the quota rate up to the
+// burst size, and a request is rejected instead of queued when its bucket is empty.
+type Limiter struct {
+	store  Store
+	quota  func(route string) config.RateQuota
+	exempt map[string]bool
+	now    func() time.Time
+	// Observer, when set, is told the decision taken on every limited request.
+	Observer func(route, decision string)
+}
+
+func New(store Store, quota func(route string) config.RateQuota, exemptRoutes ...string) *Limiter {
+	exempt := make(map[string]bool, len(exemptRoutes))
+	for _, route := range exemptRoutes {
+		exempt[route] = true
+	}
+
+	return &Limiter{
+		store:  store,
+		quota:  quota,
+		exempt: exempt,
+		now:    time.Now,
+	}
+}
+
+// Middleware rate limits every matched route except the exempt ones, identifying clients by ClientKey.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeOf(r)
+		if l.exempt[route] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := l.store.Take(r.Context(), route+"|"+ClientKey(r), l.quota(route), l.now())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "rate limit store unavailable, letting the request through", "route", route, "error", err)
+			l.observe(route, DecisionStoreError)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			l.reject(w, r, route, result)
+			return
+		}
+
+		l.observe(route, DecisionAllowed)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Failures runs before authentication and charges every request it rejects with a 401 to the bucket of the
+// client's IP on the route, the bucket Middleware uses for unauthenticated requests. Once that bucket is empty,
+// requests from the IP are rejected before their credentials are checked, so keys and tokens cannot be guessed
+// faster than the quota.
+func (l *Limiter) Failures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeOf(r)
+		if l.exempt[route] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, quota := route+"|"+ipKey(r), l.quota(route)
+		result, err := l.store.Peek(r.Context(), key, quota, l.now())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "rate limit store unavailable, letting the request through", "route", route, "error", err)
+			l.observe(route, DecisionStoreError)
+		} else if !result.Allowed {
+			l.reject(w, r, route, result)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if recorder.status != http.StatusUnauthorized {
+			return
+		}
+
+		if _, err = l.store.Take(r.Context(), key, quota, l.now()); err != nil {
+			slog.ErrorContext(r.Context(), "rate limit store unavailable, failed authentication not charged", "route", route, "error", err)
+			l.observe(route, DecisionStoreError)
+		}
+	})
+}
+
+func (l *Limiter) reject(w http.ResponseWriter, r *http.Request, route string, result Result) {
+	l.observe(route, DecisionRejected)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	problem.Write(w, r, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited, "too many requests"))
+}
+
+func (l *Limiter) observe(route, decision string) {
+	if l.Observer != nil {
+		l.Observer(route, decision)
+	}
+}
+
+// ClientKey identifies the caller by the subject of the principal authentication resolved or, for requests
+// that were not authenticated, by its IP address. Credentials are never used as keys before they are verified,
+// so a client cannot get a fresh bucket by sending a new made up key on every request.
+func ClientKey(r *http.Request) string {
+	if principal := auth.PrincipalFromContext(r.Context()); principal != nil {
+		return "principal:" + principal.Subject
+	}
+	return ipKey(r)
+}
+
+func ipKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// routeOf is the path template of the matched route, or the path when there is none.
+func routeOf(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// statusRecorder remembers the status of the response it passes through.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(body []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(body)
+}
+
+// Unwrap lets http.ResponseController reach the connection, e.g. to lift the write deadline.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/apikey"
+	"employee_exercise/src/pkg/libs/auth"
+	"employee_exercise/src/pkg/libs/config"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Middleware(t *testing.T) {
+	quotas := map[string]config.RateQuota{
+		"/reports/headcount": {Rate: 1, Burst: 1},
+		"/employees":         {Rate: 10, Burst: 2},
+	}
+	limiter, _ := newLimiter(NewMemoryStore(), func(route string) config.RateQuota { return quotas[route] }, "/healthz")
+	decisions := make(map[string]int)
+	limiter.Observer = func(route, decision string) { decisions[route+" "+decision]++ }
+
+	router := mux.NewRouter()
+	router.Use(limiter.Middleware)
+	for _, route := range []string{"/employees", "/reports/headcount", "/healthz"} {
+		router.HandleFunc(route, func(w http.ResponseWriter, r *http.Request) {})
+	}
+
+	tests := []struct {
+		name                string
+		url                 string
+		subject             string
+		apiKey              string
+		remoteAddr          string
+		expectedCode        int
+		expectedRemaining   string
+		expectedRetryAfter  string
+		expectRateLimitInfo bool
+	}{
+		{name: "first request", url: "/reports/headcount", remoteAddr: "10.0.0.1:5000", expectedCode: http.StatusOK, expectedRemaining: "0", expectRateLimitInfo: true},
+		{name: "same ip on another port is limited", url: "/reports/headcount", remoteAddr: "10.0.0.1:5001", expectedCode: http.StatusTooManyRequests, expectedRemaining: "0", expectedRetryAfter: "1", expectRateLimitInfo: true},
+		{name: "another route has its own quota", url: "/employees", remoteAddr: "10.0.0.1:5000", expectedCode: http.StatusOK, expectedRemaining: "1", expectRateLimitInfo: true},
+		{name: "authenticated clients are tracked apart from their ip", url: "/reports/headcount", subject: "api_key:1", remoteAddr: "10.0.0.1:5000", expectedCode: http.StatusOK, expectedRemaining: "0", expectRateLimitInfo: true},
+		{name: "authenticated client is limited from any ip", url: "/reports/headcount", subject: "api_key:1", remoteAddr: "10.0.0.2:5000", expectedCode: http.StatusTooManyRequests, expectedRemaining: "0", expectedRetryAfter: "1", expectRateLimitInfo: true},
+		{name: "token subjects behind one ip get their own buckets", url: "/reports/headcount", subject: "jwt:alice", remoteAddr: "10.0.0.9:5000", expectedCode: http.StatusOK, expectedRemaining: "0", expectRateLimitInfo: true},
+		{name: "another token subject behind the same ip", url: "/reports/headcount", subject: "jwt:bob", remoteAddr: "10.0.0.9:5001", expectedCode: http.StatusOK, expectedRemaining: "0", expectRateLimitInfo: true},
+		{name: "unverified api key falls back to the ip", url: "/reports/headcount", apiKey: "made-up", remoteAddr: "10.0.0.1:5000", expectedCode: http.StatusTooManyRequests, expectedRemaining: "0", expectedRetryAfter: "1", expectRateLimitInfo: true},
+		{name: "exempt route", url: "/healthz", remoteAddr: "10.0.0.1:5000", expectedCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			request.RemoteAddr = tt.remoteAddr
+			if tt.apiKey != "" {
+				request.Header.Set(apikey.Header, tt.apiKey)
+			}
+			if tt.subject != "" {
+				request = request.WithContext(auth.WithPrincipal(request.Context(), &auth.Principal{Subject: tt.subject}))
+			}
+			recorder := httptest.NewRecorder()
+
+			router.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Equal(t, tt.expectedRemaining, recorder.Header().Get("X-RateLimit-Remaining"))
+			assert.Equal(t, tt.expectedRetryAfter, recorder.Header().Get("Retry-After"))
+			if tt.expectRateLimitInfo {
+				assert.NotEmpty(t, recorder.Header().Get("X-RateLimit-Limit"))
+				assert.NotEmpty(t, recorder.Header().Get("X-RateLimit-Reset"))
+			} else {
+				assert.Empty(t, recorder.Header().Get("X-RateLimit-Limit"))
+			}
+			if tt.expectedCode == http.StatusTooManyRequests {
+				assert.Equal(t, `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"too many requests","instance":"/reports/headcount","code":"rate_limited"}`, recorder.Body.String())
+			}
+		})
+	}
+
+	assert.Equal(t, map[string]int{"/reports/headcount allowed": 4, "/reports/headcount rejected": 3, "/employees allowed": 1}, decisions)
+}
+
+func TestLimiter_Middleware_Lets_requests_through_when_the_store_fails(t *testing.T) {
+	limiter, _ := newLimiter(failingStore{}, func(string) config.RateQuota { return config.RateQuota{Rate: 1, Burst: 1} })
+	var decisions []string
+	limiter.Observer = func(route, decision string) { decisions = append(decisions, route+" "+decision) }
+
+	router := mux.NewRouter()
+	router.Use(limiter.Middleware)
+	router.HandleFunc("/employees", func(w http.ResponseWriter, r *http.Request) {})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/employees", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, []string{"/employees store_error"}, decisions)
+}
+
+func TestLimiter_Failures(t *testing.T) {
+	limiter, clock := newLimiter(NewMemoryStore(), func(string) config.RateQuota { return config.RateQuota{Rate: 1, Burst: 2} })
+	var verified int
+	router := mux.NewRouter()
+	router.Use(limiter.Failures)
+	router.HandleFunc("/employees", func(w http.ResponseWriter, r *http.Request) {
+		verified++
+		if r.Header.Get(apikey.Header) != "emp_valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+
+	send := func(key, remoteAddr string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/employees", nil)
+		request.RemoteAddr = remoteAddr
+		request.Header.Set(apikey.Header, key)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, send("emp_valid", "10.0.0.1:5000").Code, "successful authentications are not charged")
+	}
+	assert.Equal(t, http.StatusUnauthorized, send("guess-1", "10.0.0.1:5000").Code)
+	assert.Equal(t, http.StatusUnauthorized, send("guess-2", "10.0.0.1:5000").Code)
+
+	rejected := send("guess-3", "10.0.0.1:5000")
+	assert.Equal(t, http.StatusTooManyRequests, rejected.Code)
+	assert.Equal(t, "1", rejected.Header().Get("Retry-After"))
+	assert.Equal(t, 7, verified, "the rejected guess never reached authentication")
+
+	assert.Equal(t, http.StatusUnauthorized, send("guess-4", "10.0.0.2:5000").Code, "other ips keep their bucket")
+
+	clock.Add(time.Second)
+	assert.Equal(t, http.StatusUnauthorized, send("guess-5", "10.0.0.1:5000").Code)
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, quota config.RateQuota, now time.Time) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func (failingStore) Peek(ctx context.Context, key string, quota config.RateQuota, now time.Time) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newLimiter(store Store, quota func(string) config.RateQuota, exemptRoutes ...string) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := New(store, quota, exemptRoutes...)
+	limiter.now = func() time.Time { return clock.now }
+	return limiter, clock
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// takeScript refills and takes cost tokens from a bucket stored as a hash in a single atomic step, a cost of 0
+// peeks. The hash expires once the
+// bucket would be full again, so idle clients cost nothing.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+	tokens = capacity
+	updated = now
+end
+
+if now > updated then
+	tokens = math.min(capacity, tokens + (now - updated) * rate / 1000)
+	updated = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - cost
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(updated))
+redis.call('PEXPIRE', KEYS[1], math.max(1, math.ceil((capacity - tokens) * 1000 / rate)))
+
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore keeps the buckets in Redis, or any server speaking its protocol, so quotas hold across replicas.
+type RedisStore struct {
+	Client redis.UniversalClient
+	Prefix string
+}
+
+func NewRedisStore(cfg config.RedisConfig) *RedisStore {
+	return &RedisStore{
+		Client: redis.NewClient(&redis.Options{
+			Addr:     cfg.Address,
+			Password: cfg.Password,
+			DB:       cfg.Database,
+		}),
+		Prefix: "ratelimit:",
+	}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, quota config.RateQuota, now time.Time) (Result, error) {
+	return s.take(ctx, key, quota, now, 1)
+}
+
+func (s *RedisStore) Peek(ctx context.Context, key string, quota config.RateQuota, now time.Time) (Result, error) {
+	return s.take(ctx, key, quota, now, 0)
+}
+
+func (s *RedisStore) take(ctx context.Context, key string, quota config.RateQuota, now time.Time, cost int) (Result, error) {
+	reply, err := takeScript.Run(ctx, s.Client, []string{s.Prefix + key},
+		quota.Rate, quota.Burst, now.UnixMilli(), cost).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("taking rate limit token: %w", err)
+	}
+
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("taking rate limit token: unexpected reply %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	tokensReply, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(tokensReply, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("taking rate limit token: unexpected token count %v", reply[1])
+	}
+
+	return result(quota, tokens, allowed == 1), nil
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"math"
+	"time"
+)
+
+// Store keeps the token buckets. Take refills the bucket identified by key up to now and takes a token from it.
+// Peek refills it the same way and tells whether Take would be allowed, without taking anything.
+type Store interface {
+	Take(ctx context.Context, key string, quota config.RateQuota, now time.Time) (Result, error)
+	Peek(ctx context.Context, key string, quota config.RateQuota, now time.Time) (Result, error)
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// result describes a bucket left with tokens after a request that was allowed or not.
+func result(quota config.RateQuota, tokens float64, allowed bool) Result {
+	rate := float64(quota.Rate)
+
+	r := Result{
+		Allowed:   allowed,
+		Limit:     quota.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((float64(quota.Burst) - tokens) / rate),
+	}
+
+	if !allowed {
+		r.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	return r
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStores_Take(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+		"redis":  func(t *testing.T) Store { return newRedisStore(t, miniredis.RunT(t)) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			ctx := context.Background()
+			start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			quota := config.RateQuota{Rate: 2, Burst: 3}
+
+			for i := 2; i >= 0; i-- {
+				result, err := store.Take(ctx, "client", quota, start)
+				assert.NoError(t, err)
+				assert.True(t, result.Allowed)
+				assert.Equal(t, i, result.Remaining)
+			}
+
+			denied, err := store.Take(ctx, "client", quota, start)
+			assert.NoError(t, err)
+			assert.Equal(t, Result{Limit: 3, RetryAfter: 500 * time.Millisecond, Reset: 1500 * time.Millisecond}, denied)
+
+			other, err := store.Take(ctx, "other client", quota, start)
+			assert.NoError(t, err)
+			assert.True(t, other.Allowed)
+
+			refilled, err := store.Take(ctx, "client", quota, start.Add(500*time.Millisecond))
+			assert.NoError(t, err)
+			assert.True(t, refilled.Allowed)
+
+			empty, err := store.Take(ctx, "client", quota, start.Add(500*time.Millisecond))
+			assert.NoError(t, err)
+			assert.False(t, empty.Allowed)
+
+			full, err := store.Take(ctx, "client", quota, start.Add(time.Hour))
+			assert.NoError(t, err)
+			assert.Equal(t, 2, full.Remaining)
+		})
+	}
+}
+
+func TestStores_Peek(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+		"redis":  func(t *testing.T) Store { return newRedisStore(t, miniredis.RunT(t)) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			ctx := context.Background()
+			start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+			quota := config.RateQuota{Rate: 1, Burst: 1}
+
+			peeked, err := store.Peek(ctx, "client", quota, start)
+			assert.NoError(t, err)
+			assert.True(t, peeked.Allowed)
+			assert.Equal(t, 1, peeked.Remaining, "peeking takes nothing")
+
+			_, err = store.Take(ctx, "client", quota, start)
+			assert.NoError(t, err)
+
+			empty, err := store.Peek(ctx, "client", quota, start)
+			assert.NoError(t, err)
+			assert.False(t, empty.Allowed)
+			assert.Equal(t, time.Second, empty.RetryAfter)
+
+			refilled, err := store.Peek(ctx, "client", quota, start.Add(time.Second))
+			assert.NoError(t, err)
+			assert.True(t, refilled.Allowed)
+		})
+	}
+}
+
+func TestRedisStore_Shares_buckets_between_replicas(t *testing.T) {
+	server := miniredis.RunT(t)
+	replicas := []Store{newRedisStore(t, server), newRedisStore(t, server)}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	quota := config.RateQuota{Rate: 1, Burst: 2}
+
+	var allowed int
+	for i := 0; i < 4; i++ {
+		result, err := replicas[i%2].Take(context.Background(), "client", quota, now)
+		assert.NoError(t, err)
+		if result.Allowed {
+			allowed++
+		}
+	}
+
+	assert.Equal(t, 2, allowed)
+}
+
+func TestRedisStore_Expires_refilled_buckets(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := newRedisStore(t, server)
+
+	_, err := store.Take(context.Background(), "client", config.RateQuota{Rate: 2, Burst: 4}, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, server.Exists("ratelimit:client"))
+	assert.Equal(t, 500*time.Millisecond, server.TTL("ratelimit:client"))
+
+	server.FastForward(time.Second)
+	assert.False(t, server.Exists("ratelimit:client"))
+}
+
+func TestRedisStore_Fails_when_server_is_down(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := newRedisStore(t, server)
+	server.Close()
+
+	_, err := store.Take(context.Background(), "client", config.RateQuota{Rate: 1, Burst: 1}, time.Now())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "taking rate limit token")
+}
+
+func TestMemoryStore_Forgets_refilled_buckets(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	quota := config.RateQuota{Rate: 1, Burst: 1}
+
+	_, _ = store.Take(context.Background(), "idle", quota, now)
+	_, _ = store.Take(context.Background(), "active", quota, now.Add(2*sweepInterval))
+
+	assert.NotContains(t, store.buckets, "idle")
+	assert.Contains(t, store.buckets, "active")
+}
+
+func newRedisStore(t *testing.T, server *miniredis.Miniredis) *RedisStore {
+	store := NewRedisStore(config.RedisConfig{Address: server.Addr()})
+	t.Cleanup(func() { _ = store.Client.Close() })
+	return store
+}
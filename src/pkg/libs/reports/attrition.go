@@ -0,0 +1,203 @@
+package reports
+
+import (
+	"context"
+	"employee_exercise/src/pkg/models"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	attritionStintsQuery = "SELECT de.emp_no, d.dept_name, de.from_date, de.to_date, e.hire_date FROM dept_emp de " +
+		"JOIN departments d ON de.dept_no = d.dept_no JOIN employees e ON de.emp_no = e.emp_no " +
+		"WHERE de.emp_no IN (SELECT emp_no FROM dept_emp WHERE from_date < ? AND to_date >= ?) " +
+		"ORDER BY de.emp_no, de.from_date"
+
+	openEndedYear = 9999
+)
+
+type Stint struct {
+	EmployeeNumber int
+	Department     string
+	FromDate       time.Time
+	ToDate         time.Time
+	HireDate       time.Time
+}
+
+func (r *ReportService) GetAttrition(ctx context.Context, parameters models.AttritionParameters) (*models.AttritionReport, error) {
+	cacheKey := fmt.Sprintf("attrition:%s:%s:%s", parameters.From.Format(dateLayout), parameters.To.Format(dateLayout), parameters.Interval)
+	if cached, ok := r.Cache.Get(cacheKey); ok {
+		return cached.(*models.AttritionReport), nil
+	}
+
+	periods, err := PeriodRanges(parameters.From, parameters.To, parameters.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	end := periods[len(periods)-1].End
+	stints, err := r.getStints(ctx, attritionStintsQuery, end.Format(dateLayout), parameters.From.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.AttritionReport{
+		From:     parameters.From,
+		To:       parameters.To,
+		Interval: parameters.Interval,
+		Periods:  ComputeAttrition(periods, stints),
+	}
+
+	r.Cache.Set(cacheKey, &report)
+
+	return &report, nil
+}
+
+func (r *ReportService) getStints(ctx context.Context, query string, args ...interface{}) ([]Stint, error) {
+	stmt, err := r.ReportManager.PrepareContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql department stints query", "error", err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql department stints query", "error", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var stints []Stint
+	for rows.Next() {
+		stint := Stint{}
+		err = rows.Scan(&stint.EmployeeNumber, &stint.Department, &stint.FromDate, &stint.ToDate, &stint.HireDate)
+		if err != nil {
+			slog.ErrorContext(ctx, "error scanning sql department stints query", "error", err)
+			return nil, err
+		}
+
+		stints = append(stints, stint)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "error iterating sql department stints query", "error", err)
+		return nil, err
+	}
+
+	return stints, nil
+}
+
+// ComputeAttrition classifies the start and end of every department stint as a hire, an internal transfer or a departure,
+// and counts them per period and department together with the headcount at the start of each period.
+func ComputeAttrition(periods []Period, stints []Stint) []models.AttritionPeriod {
+	departments := departmentNames(stints)
+	result := make([]models.AttritionPeriod, len(periods))
+	counters := make([]map[string]*models.DepartmentAttrition, len(periods))
+	for i, period := range periods {
+		result[i] = models.AttritionPeriod{Start: period.Start, End: period.End}
+		counters[i] = make(map[string]*models.DepartmentAttrition, len(departments))
+		for _, department := range departments {
+			counters[i][department] = &models.DepartmentAttrition{Department: department}
+		}
+	}
+
+	count := func(date time.Time, department string, increment func(*models.DepartmentAttrition)) {
+		for i, period := range periods {
+			if !date.Before(period.Start) && date.Before(period.End) {
+				increment(counters[i][department])
+				return
+			}
+		}
+	}
+
+	for _, employeeStints := range stintsByEmployee(stints) {
+		for k, stint := range employeeStints {
+			for i, period := range periods {
+				if !stint.FromDate.After(period.Start) && stint.ToDate.After(period.Start) {
+					counters[i][stint.Department].Headcount++
+				}
+			}
+
+			if k == 0 {
+				count(stint.HireDate, stint.Department, func(a *models.DepartmentAttrition) { a.Hires++ })
+			} else {
+				previous := employeeStints[k-1]
+				count(stint.FromDate, stint.Department, func(a *models.DepartmentAttrition) { a.TransfersIn++ })
+				count(stint.FromDate, previous.Department, func(a *models.DepartmentAttrition) { a.TransfersOut++ })
+			}
+
+			if k == len(employeeStints)-1 && stint.ToDate.Year() < openEndedYear {
+				count(stint.ToDate, stint.Department, func(a *models.DepartmentAttrition) { a.Departures++ })
+			}
+		}
+	}
+
+	for i := range result {
+		total := models.DepartmentAttrition{Department: "all"}
+		result[i].Departments = make([]models.DepartmentAttrition, 0, len(departments))
+		for _, department := range departments {
+			attrition := counters[i][department]
+			attrition.AttritionRate = rate(attrition.Departures, attrition.Headcount)
+			result[i].Departments = append(result[i].Departments, *attrition)
+
+			total.Headcount += attrition.Headcount
+			total.Hires += attrition.Hires
+			total.Departures += attrition.Departures
+			total.TransfersIn += attrition.TransfersIn
+			total.TransfersOut += attrition.TransfersOut
+		}
+
+		total.AttritionRate = rate(total.Departures, total.Headcount)
+		result[i].Total = total
+	}
+
+	return result
+}
+
+func stintsByEmployee(stints []Stint) [][]Stint {
+	sorted := append([]Stint(nil), stints...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].EmployeeNumber != sorted[j].EmployeeNumber {
+			return sorted[i].EmployeeNumber < sorted[j].EmployeeNumber
+		}
+		return sorted[i].FromDate.Before(sorted[j].FromDate)
+	})
+
+	var grouped [][]Stint
+	for i, stint := range sorted {
+		if i == 0 || stint.EmployeeNumber != sorted[i-1].EmployeeNumber {
+			grouped = append(grouped, nil)
+		}
+		grouped[len(grouped)-1] = append(grouped[len(grouped)-1], stint)
+	}
+
+	return grouped
+}
+
+func departmentNames(stints []Stint) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, stint := range stints {
+		if !seen[stint.Department] {
+			seen[stint.Department] = true
+			names = append(names, stint.Department)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func rate(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+
+	return math.Round(float64(part)/float64(whole)*10000) / 100
+}
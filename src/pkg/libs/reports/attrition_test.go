@@ -0,0 +1,194 @@
+package reports
+
+import (
+	"context"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestComputeAttrition(t *testing.T) {
+	periods := []Period{
+		{Start: date(1990, 1, 1), End: date(1991, 1, 1)},
+		{Start: date(1991, 1, 1), End: date(1992, 1, 1)},
+	}
+	stints := []Stint{
+		{EmployeeNumber: 1, Department: "Sales", FromDate: date(1985, 1, 1), ToDate: date(1990, 6, 1), HireDate: date(1985, 1, 1)},
+		{EmployeeNumber: 1, Department: "Development", FromDate: date(1990, 6, 1), ToDate: date(9999, 1, 1), HireDate: date(1985, 1, 1)},
+		{EmployeeNumber: 2, Department: "Sales", FromDate: date(1990, 3, 1), ToDate: date(1991, 2, 1), HireDate: date(1990, 3, 1)},
+		{EmployeeNumber: 3, Department: "Development", FromDate: date(1986, 1, 1), ToDate: date(9999, 1, 1), HireDate: date(1986, 1, 1)},
+	}
+
+	result := ComputeAttrition(periods, stints)
+
+	assert.Equal(t, []models.AttritionPeriod{
+		{
+			Start: date(1990, 1, 1),
+			End:   date(1991, 1, 1),
+			Total: models.DepartmentAttrition{Department: "all", Headcount: 2, Hires: 1, TransfersIn: 1, TransfersOut: 1},
+			Departments: []models.DepartmentAttrition{
+				{Department: "Development", Headcount: 1, TransfersIn: 1},
+				{Department: "Sales", Headcount: 1, Hires: 1, TransfersOut: 1},
+			},
+		},
+		{
+			Start: date(1991, 1, 1),
+			End:   date(1992, 1, 1),
+			Total: models.DepartmentAttrition{Department: "all", Headcount: 3, Departures: 1, AttritionRate: 33.33},
+			Departments: []models.DepartmentAttrition{
+				{Department: "Development", Headcount: 2},
+				{Department: "Sales", Headcount: 1, Departures: 1, AttritionRate: 100},
+			},
+		},
+	}, result)
+}
+
+func TestComputeAttrition_Without_stints(t *testing.T) {
+	result := ComputeAttrition([]Period{{Start: date(1990, 1, 1), End: date(1991, 1, 1)}}, nil)
+
+	assert.Equal(t, []models.AttritionPeriod{{
+		Start:       date(1990, 1, 1),
+		End:         date(1991, 1, 1),
+		Total:       models.DepartmentAttrition{Department: "all"},
+		Departments: []models.DepartmentAttrition{},
+	}}, result)
+}
+
+func TestReportService_GetAttrition_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(attritionStintsQuery)).
+		ExpectQuery().
+		WithArgs("1991-01-01", "1990-01-01").
+		WillReturnRows(sqlmock.NewRows([]string{"emp_no", "dept_name", "from_date", "to_date", "hire_date"}).
+			AddRow(2, "Sales", date(1990, 3, 1), date(1990, 9, 1), date(1990, 3, 1)))
+
+	reportService := &ReportService{ReportManager: db}
+
+	report, err := reportService.GetAttrition(context.Background(), models.AttritionParameters{
+		From:     date(1990, 1, 1),
+		To:       date(1990, 1, 1),
+		Interval: IntervalYear,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, &models.AttritionReport{
+		From:     date(1990, 1, 1),
+		To:       date(1990, 1, 1),
+		Interval: IntervalYear,
+		Periods: []models.AttritionPeriod{{
+			Start:       date(1990, 1, 1),
+			End:         date(1991, 1, 1),
+			Total:       models.DepartmentAttrition{Department: "all", Hires: 1, Departures: 1},
+			Departments: []models.DepartmentAttrition{{Department: "Sales", Hires: 1, Departures: 1}},
+		}},
+	}, report)
+}
+
+func TestReportService_GetAttrition_Fails_preparing_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(attritionStintsQuery)).
+		WillReturnError(errors.New("error preparing query in database"))
+
+	reportService := &ReportService{ReportManager: db}
+
+	report, err := reportService.GetAttrition(context.Background(), models.AttritionParameters{
+		From:     date(1990, 1, 1),
+		To:       date(1990, 1, 1),
+		Interval: IntervalYear,
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, report)
+	assert.Equal(t, errors.New("error preparing query in database"), err)
+}
+
+func TestComputeTenure(t *testing.T) {
+	asOf := date(2000, 1, 1)
+	stints := []Stint{
+		{EmployeeNumber: 1, Department: "Sales", FromDate: date(1999, 7, 2), ToDate: date(9999, 1, 1), HireDate: date(1999, 7, 2)},
+		{EmployeeNumber: 2, Department: "Sales", FromDate: date(1995, 1, 1), ToDate: date(9999, 1, 1), HireDate: date(1989, 12, 1)},
+		{EmployeeNumber: 3, Department: "Development", FromDate: date(1980, 1, 1), ToDate: date(9999, 1, 1), HireDate: date(1977, 1, 1)},
+		{EmployeeNumber: 4, Department: "Development", FromDate: date(1980, 1, 1), ToDate: date(1999, 1, 1), HireDate: date(1980, 1, 1)},
+	}
+
+	report := ComputeTenure(asOf, stints)
+
+	assert.Equal(t, asOf, report.AsOf)
+	assert.Equal(t, 3, report.Total.Employees)
+	assert.Equal(t, 11.19, report.Total.AverageYears)
+	assert.Equal(t, 10.08, report.Total.MedianYears)
+	assert.Equal(t, []models.TenureBucket{
+		{Label: "0-1", Employees: 1},
+		{Label: "1-2", Employees: 0},
+		{Label: "2-5", Employees: 0},
+		{Label: "5-10", Employees: 0},
+		{Label: "10-20", Employees: 1},
+		{Label: "20+", Employees: 1},
+	}, report.Total.Distribution)
+
+	assert.Len(t, report.Departments, 2)
+	assert.Equal(t, "Development", report.Departments[0].Department)
+	assert.Equal(t, 1, report.Departments[0].Employees)
+	assert.Equal(t, 23.0, report.Departments[0].AverageYears)
+	assert.Equal(t, "Sales", report.Departments[1].Department)
+	assert.Equal(t, 2, report.Departments[1].Employees)
+	assert.Equal(t, 5.29, report.Departments[1].MedianYears)
+}
+
+func TestComputeTenure_Merges_overlapping_stints(t *testing.T) {
+	asOf := date(2000, 1, 1)
+	stints := []Stint{
+		{EmployeeNumber: 1, Department: "Sales", FromDate: date(1995, 1, 1), ToDate: date(2001, 1, 1), HireDate: date(1995, 1, 1)},
+		{EmployeeNumber: 1, Department: "Sales", FromDate: date(1999, 1, 1), ToDate: date(9999, 1, 1), HireDate: date(1995, 1, 1)},
+		{EmployeeNumber: 1, Department: "Development", FromDate: date(1999, 6, 1), ToDate: date(9999, 1, 1), HireDate: date(1995, 1, 1)},
+		{EmployeeNumber: 2, Department: "Sales", FromDate: date(1990, 1, 1), ToDate: date(9999, 1, 1), HireDate: date(1990, 1, 1)},
+		{EmployeeNumber: 2, Department: "Sales", FromDate: date(1990, 1, 1), ToDate: date(9999, 1, 1), HireDate: date(1990, 1, 1)},
+	}
+
+	report := ComputeTenure(asOf, stints)
+
+	assert.Equal(t, 2, report.Total.Employees)
+	assert.Equal(t, 7.5, report.Total.AverageYears)
+	assert.Len(t, report.Departments, 2)
+	assert.Equal(t, "Development", report.Departments[0].Department)
+	assert.Equal(t, 1, report.Departments[0].Employees)
+	assert.Equal(t, "Sales", report.Departments[1].Department)
+	assert.Equal(t, 2, report.Departments[1].Employees)
+	assert.Equal(t, 7.5, report.Departments[1].AverageYears)
+}
+
+func TestReportService_GetTenure_Fails_executing_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(tenureStintsQuery)).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01").
+		WillReturnError(errors.New("error executing query in database"))
+
+	reportService := &ReportService{ReportManager: db}
+
+	report, err := reportService.GetTenure(context.Background(), time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, report)
+	assert.Equal(t, errors.New("error executing query in database"), err)
+}
@@ -0,0 +1,96 @@
+package reports
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCacheEntries bounds the cache, each distinct set of report parameters being an entry.
+const maxCacheEntries = 256
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+type Cache struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *Cache) TTL() time.Duration {
+	if c == nil {
+		return 0
+	}
+
+	return c.ttl
+}
+
+func (c *Cache) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+func (c *Cache) Set(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCacheEntries {
+		c.evict()
+	}
+
+	c.entries[key] = cacheEntry{
+		value:     value,
+		expiresAt: c.now().Add(c.ttl),
+	}
+}
+
+// evict drops the expired entries, or the oldest one when none has expired, to make room for a new entry.
+func (c *Cache) evict() {
+	now := c.now()
+	oldestKey := ""
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, entry.expiresAt
+		}
+	}
+
+	if len(c.entries) >= maxCacheEntries {
+		delete(c.entries, oldestKey)
+	}
+}
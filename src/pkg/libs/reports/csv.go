@@ -0,0 +1,28 @@
+package reports
+
+import (
+	"employee_exercise/src/pkg/models"
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+func WriteHeadcountCSV(w io.Writer, report *models.HeadcountReport) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"date", report.GroupBy, "headcount"})
+	if err != nil {
+		return err
+	}
+
+	for _, period := range report.Periods {
+		for _, group := range period.Groups {
+			err = writer.Write([]string{period.Date.Format(dateLayout), group.Group, strconv.Itoa(group.Headcount)})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,62 @@
+package reports
+
+import (
+	"fmt"
+	"time"
+)
+
+const MaxPeriods = 500
+
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+func PeriodDates(from, to time.Time, interval string) ([]time.Time, error) {
+	periods, err := PeriodRanges(from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make([]time.Time, 0, len(periods))
+	for _, period := range periods {
+		dates = append(dates, period.Start)
+	}
+
+	return dates, nil
+}
+
+// PeriodRanges splits [from, to] into consecutive half-open periods of the given interval, the first one starting at from.
+func PeriodRanges(from, to time.Time, interval string) ([]Period, error) {
+	months, err := intervalMonths(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	if to.Before(from) {
+		return nil, fmt.Errorf("to date %s is before from date %s", to.Format(dateLayout), from.Format(dateLayout))
+	}
+
+	var periods []Period
+	for start := from; !start.After(to); start = from.AddDate(0, months*len(periods), 0) {
+		if len(periods) == MaxPeriods {
+			return nil, fmt.Errorf("too many periods, the maximum is %d", MaxPeriods)
+		}
+		periods = append(periods, Period{Start: start, End: from.AddDate(0, months*(len(periods)+1), 0)})
+	}
+
+	return periods, nil
+}
+
+func intervalMonths(interval string) (int, error) {
+	switch interval {
+	case IntervalMonth:
+		return 1, nil
+	case IntervalQuarter:
+		return 3, nil
+	case IntervalYear:
+		return 12, nil
+	default:
+		return 0, fmt.Errorf("unsupported interval: %s", interval)
+	}
+}
@@ -0,0 +1,118 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"employee_exercise/src/pkg/models"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	IntervalMonth   = "month"
+	IntervalQuarter = "quarter"
+	IntervalYear    = "year"
+
+	GroupByDepartment = "department"
+	GroupByGender     = "gender"
+	GroupByTitle      = "title"
+
+	dateLayout = "2006-01-02"
+)
+
+var headcountQueries = map[string]string{
+	GroupByDepartment: "SELECT d.dept_name, COUNT(DISTINCT de.emp_no) FROM dept_emp de JOIN departments d ON de.dept_no = d.dept_no " +
+		"WHERE de.from_date <= ? AND de.to_date > ? GROUP BY d.dept_name ORDER BY d.dept_name",
+	GroupByGender: "SELECT e.gender, COUNT(DISTINCT de.emp_no) FROM dept_emp de JOIN employees e ON de.emp_no = e.emp_no " +
+		"WHERE de.from_date <= ? AND de.to_date > ? GROUP BY e.gender ORDER BY e.gender",
+	GroupByTitle: "SELECT t.title, COUNT(DISTINCT t.emp_no) FROM titles t " +
+		"WHERE t.from_date <= ? AND t.to_date > ? GROUP BY t.title ORDER BY t.title",
+}
+
+type ReportService struct {
+	ReportManager *sql.DB
+	Cache         *Cache
+}
+
+func (r *ReportService) GetHeadcount(ctx context.Context, parameters models.HeadcountParameters) (*models.HeadcountReport, error) {
+	cacheKey := fmt.Sprintf("headcount:%s:%s:%s:%s", parameters.From.Format(dateLayout), parameters.To.Format(dateLayout),
+		parameters.Interval, parameters.GroupBy)
+	if cached, ok := r.Cache.Get(cacheKey); ok {
+		return cached.(*models.HeadcountReport), nil
+	}
+
+	query, ok := headcountQueries[parameters.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported headcount group: %s", parameters.GroupBy)
+	}
+
+	dates, err := PeriodDates(parameters.From, parameters.To, parameters.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := r.ReportManager.PrepareContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql headcount query", "error", err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	report := models.HeadcountReport{
+		From:     parameters.From,
+		To:       parameters.To,
+		Interval: parameters.Interval,
+		GroupBy:  parameters.GroupBy,
+		Periods:  make([]models.HeadcountPeriod, 0, len(dates)),
+	}
+
+	for _, date := range dates {
+		period, periodError := r.getHeadcountAt(ctx, stmt, date)
+		if periodError != nil {
+			return nil, periodError
+		}
+
+		report.Periods = append(report.Periods, *period)
+	}
+
+	r.Cache.Set(cacheKey, &report)
+
+	return &report, nil
+}
+
+func (r *ReportService) getHeadcountAt(ctx context.Context, stmt *sql.Stmt, date time.Time) (*models.HeadcountPeriod, error) {
+	formattedDate := date.Format(dateLayout)
+	rows, err := stmt.QueryContext(ctx, formattedDate, formattedDate)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql headcount query", "date", formattedDate, "error", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	period := models.HeadcountPeriod{
+		Date:   date,
+		Groups: []models.HeadcountGroup{},
+	}
+
+	for rows.Next() {
+		group := models.HeadcountGroup{}
+		err = rows.Scan(&group.Group, &group.Headcount)
+		if err != nil {
+			slog.ErrorContext(ctx, "error scanning sql headcount query", "date", formattedDate, "error", err)
+			return nil, err
+		}
+
+		period.Total += group.Headcount
+		period.Groups = append(period.Groups, group)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "error iterating sql headcount query", "date", formattedDate, "error", err)
+		return nil, err
+	}
+
+	return &period, nil
+}
@@ -0,0 +1,269 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestReportService_GetHeadcount_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	prepare := mock.ExpectPrepare(regexp.QuoteMeta(headcountQueries[GroupByDepartment]))
+	prepare.ExpectQuery().
+		WithArgs("1990-01-01", "1990-01-01").
+		WillReturnRows(sqlmock.NewRows([]string{"dept_name", "headcount"}).
+			AddRow("Development", 10).
+			AddRow("Sales", 5))
+	prepare.ExpectQuery().
+		WithArgs("1991-01-01", "1991-01-01").
+		WillReturnRows(sqlmock.NewRows([]string{"dept_name", "headcount"}).
+			AddRow("Development", 12))
+
+	reportService := &ReportService{ReportManager: db}
+
+	report, err := reportService.GetHeadcount(context.Background(), mockHeadcountParameters())
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, expectedHeadcountReport(), report)
+}
+
+func TestReportService_GetHeadcount_Uses_cache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	prepare := mock.ExpectPrepare(regexp.QuoteMeta(headcountQueries[GroupByDepartment]))
+	prepare.ExpectQuery().
+		WithArgs("1990-01-01", "1990-01-01").
+		WillReturnRows(sqlmock.NewRows([]string{"dept_name", "headcount"}).
+			AddRow("Development", 10).
+			AddRow("Sales", 5))
+	prepare.ExpectQuery().
+		WithArgs("1991-01-01", "1991-01-01").
+		WillReturnRows(sqlmock.NewRows([]string{"dept_name", "headcount"}).
+			AddRow("Development", 12))
+
+	reportService := &ReportService{ReportManager: db, Cache: NewCache(time.Minute)}
+
+	first, err := reportService.GetHeadcount(context.Background(), mockHeadcountParameters())
+	assert.NoError(t, err)
+
+	second, err := reportService.GetHeadcount(context.Background(), mockHeadcountParameters())
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Same(t, first, second)
+}
+
+func TestReportService_GetHeadcount_Fails_preparing_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(headcountQueries[GroupByDepartment])).
+		WillReturnError(errors.New("error preparing query in database"))
+
+	reportService := &ReportService{ReportManager: db}
+
+	report, err := reportService.GetHeadcount(context.Background(), mockHeadcountParameters())
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, report)
+	assert.Equal(t, errors.New("error preparing query in database"), err)
+}
+
+func TestReportService_GetHeadcount_Fails_executing_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(headcountQueries[GroupByDepartment])).
+		ExpectQuery().
+		WithArgs("1990-01-01", "1990-01-01").
+		WillReturnError(errors.New("error executing query in database"))
+
+	reportService := &ReportService{ReportManager: db}
+
+	report, err := reportService.GetHeadcount(context.Background(), mockHeadcountParameters())
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, report)
+	assert.Equal(t, errors.New("error executing query in database"), err)
+}
+
+func TestPeriodDates(t *testing.T) {
+	tests := []struct {
+		name          string
+		from          time.Time
+		to            time.Time
+		interval      string
+		expectedDates []time.Time
+		expectedError bool
+	}{
+		{
+			name:     "monthly periods include both ends",
+			from:     date(2000, 1, 15),
+			to:       date(2000, 3, 15),
+			interval: IntervalMonth,
+			expectedDates: []time.Time{
+				date(2000, 1, 15),
+				date(2000, 2, 15),
+				date(2000, 3, 15),
+			},
+		},
+		{
+			name:     "quarterly periods stop before the end date",
+			from:     date(2000, 1, 1),
+			to:       date(2000, 8, 1),
+			interval: IntervalQuarter,
+			expectedDates: []time.Time{
+				date(2000, 1, 1),
+				date(2000, 4, 1),
+				date(2000, 7, 1),
+			},
+		},
+		{
+			name:          "yearly periods with a single date",
+			from:          date(2000, 1, 1),
+			to:            date(2000, 1, 1),
+			interval:      IntervalYear,
+			expectedDates: []time.Time{date(2000, 1, 1)},
+		},
+		{
+			name:          "unknown interval fails",
+			from:          date(2000, 1, 1),
+			to:            date(2001, 1, 1),
+			interval:      "week",
+			expectedError: true,
+		},
+		{
+			name:          "inverted range fails",
+			from:          date(2001, 1, 1),
+			to:            date(2000, 1, 1),
+			interval:      IntervalYear,
+			expectedError: true,
+		},
+		{
+			name:          "too many periods fails",
+			from:          date(1900, 1, 1),
+			to:            date(2000, 1, 1),
+			interval:      IntervalMonth,
+			expectedError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates, err := PeriodDates(tt.from, tt.to, tt.interval)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDates, dates)
+		})
+	}
+}
+
+func TestWriteHeadcountCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	err := WriteHeadcountCSV(&buffer, expectedHeadcountReport())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "date,department,headcount\n1990-01-01,Development,10\n1990-01-01,Sales,5\n1991-01-01,Development,12\n", buffer.String())
+}
+
+func TestCache_Expires_entries(t *testing.T) {
+	now := date(2000, 1, 1)
+	cache := NewCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("key", "value")
+	value, ok := cache.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get("key")
+	assert.False(t, ok)
+}
+
+func TestCache_Bounds_entries(t *testing.T) {
+	now := date(2000, 1, 1)
+	cache := NewCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < maxCacheEntries; i++ {
+		cache.Set(fmt.Sprintf("key-%d", i), i)
+		now = now.Add(time.Millisecond)
+	}
+	cache.Set("key-new", "value")
+
+	assert.Len(t, cache.entries, maxCacheEntries)
+	_, ok := cache.Get("key-0")
+	assert.False(t, ok)
+	_, ok = cache.Get("key-1")
+	assert.True(t, ok)
+	_, ok = cache.Get("key-new")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	cache.Set("key-late", "value")
+	assert.Len(t, cache.entries, 1)
+}
+
+func mockHeadcountParameters() models.HeadcountParameters {
+	return models.HeadcountParameters{
+		From:     date(1990, 1, 1),
+		To:       date(1991, 1, 1),
+		Interval: IntervalYear,
+		GroupBy:  GroupByDepartment,
+	}
+}
+
+func expectedHeadcountReport() *models.HeadcountReport {
+	return &models.HeadcountReport{
+		From:     date(1990, 1, 1),
+		To:       date(1991, 1, 1),
+		Interval: IntervalYear,
+		GroupBy:  GroupByDepartment,
+		Periods: []models.HeadcountPeriod{
+			{
+				Date:  date(1990, 1, 1),
+				Total: 15,
+				Groups: []models.HeadcountGroup{
+					{Group: "Development", Headcount: 10},
+					{Group: "Sales", Headcount: 5},
+				},
+			},
+			{
+				Date:  date(1991, 1, 1),
+				Total: 12,
+				Groups: []models.HeadcountGroup{
+					{Group: "Development", Headcount: 12},
+				},
+			},
+		},
+	}
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
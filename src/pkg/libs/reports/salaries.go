@@ -0,0 +1,235 @@
+package reports
+
+import (
+	"context"
+	"employee_exercise/src/pkg/models"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+)
+
+const salarySamplesQuery = "SELECT d.dept_name, t.title, e.gender, s.salary FROM salaries s " +
+	"JOIN employees e ON s.emp_no = e.emp_no " +
+	"JOIN dept_emp de ON s.emp_no = de.emp_no AND de.from_date <= ? AND de.to_date > ? " +
+	"JOIN departments d ON de.dept_no = d.dept_no " +
+	"JOIN titles t ON s.emp_no = t.emp_no AND t.from_date <= ? AND t.to_date > ? " +
+	"WHERE s.from_date <= ? AND s.to_date > ?"
+
+type salarySample struct {
+	department string
+	title      string
+	gender     string
+	salary     int
+}
+
+func (r *ReportService) GetSalaryStatistics(ctx context.Context, parameters models.SalaryParameters) (*models.SalaryReport, error) {
+	cacheKey := fmt.Sprintf("salaries:%s:%s", parameters.AsOf.Format(dateLayout), parameters.GroupBy)
+	if cached, ok := r.Cache.Get(cacheKey); ok {
+		return cached.(*models.SalaryReport), nil
+	}
+
+	groups, err := r.getSalaryGroups(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.SalaryReport{
+		AsOf:    parameters.AsOf,
+		GroupBy: parameters.GroupBy,
+		Groups:  []models.SalaryStatistics{},
+	}
+
+	for _, group := range sortedGroupNames(groups) {
+		salaries := make([]int, 0, len(groups[group]))
+		for _, sample := range groups[group] {
+			salaries = append(salaries, sample.salary)
+		}
+
+		report.Groups = append(report.Groups, SalaryStatisticsFor(group, salaries))
+	}
+
+	r.Cache.Set(cacheKey, &report)
+
+	return &report, nil
+}
+
+func (r *ReportService) GetSalaryGenderGap(ctx context.Context, parameters models.SalaryParameters) (*models.SalaryGenderGapReport, error) {
+	cacheKey := fmt.Sprintf("salaries-gender-gap:%s:%s", parameters.AsOf.Format(dateLayout), parameters.GroupBy)
+	if cached, ok := r.Cache.Get(cacheKey); ok {
+		return cached.(*models.SalaryGenderGapReport), nil
+	}
+
+	groups, err := r.getSalaryGroups(ctx, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.SalaryGenderGapReport{
+		AsOf:    parameters.AsOf,
+		GroupBy: parameters.GroupBy,
+		Groups:  []models.SalaryGenderGap{},
+	}
+
+	for _, group := range sortedGroupNames(groups) {
+		var male, female []int
+		for _, sample := range groups[group] {
+			switch sample.gender {
+			case "M":
+				male = append(male, sample.salary)
+			case "F":
+				female = append(female, sample.salary)
+			}
+		}
+
+		report.Groups = append(report.Groups, SalaryGenderGapFor(group, male, female))
+	}
+
+	r.Cache.Set(cacheKey, &report)
+
+	return &report, nil
+}
+
+func (r *ReportService) getSalaryGroups(ctx context.Context, parameters models.SalaryParameters) (map[string][]salarySample, error) {
+	if parameters.GroupBy != GroupByDepartment && parameters.GroupBy != GroupByTitle {
+		return nil, fmt.Errorf("unsupported salary group: %s", parameters.GroupBy)
+	}
+
+	stmt, err := r.ReportManager.PrepareContext(ctx, salarySamplesQuery)
+	if err != nil {
+		slog.ErrorContext(ctx, "error preparing sql salaries query", "error", err)
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	asOf := parameters.AsOf.Format(dateLayout)
+	rows, err := stmt.QueryContext(ctx, asOf, asOf, asOf, asOf, asOf, asOf)
+	if err != nil {
+		slog.ErrorContext(ctx, "error executing sql salaries query", "as_of", asOf, "error", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	groups := make(map[string][]salarySample)
+	for rows.Next() {
+		sample := salarySample{}
+		err = rows.Scan(&sample.department, &sample.title, &sample.gender, &sample.salary)
+		if err != nil {
+			slog.ErrorContext(ctx, "error scanning sql salaries query", "as_of", asOf, "error", err)
+			return nil, err
+		}
+
+		group := sample.department
+		if parameters.GroupBy == GroupByTitle {
+			group = sample.title
+		}
+
+		groups[group] = append(groups[group], sample)
+	}
+
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "error iterating sql salaries query", "as_of", asOf, "error", err)
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func SalaryStatisticsFor(group string, salaries []int) models.SalaryStatistics {
+	statistics := models.SalaryStatistics{
+		Group:      group,
+		SampleSize: len(salaries),
+	}
+
+	if len(salaries) == 0 {
+		return statistics
+	}
+
+	sorted := append([]int(nil), salaries...)
+	sort.Ints(sorted)
+
+	statistics.Min = sorted[0]
+	statistics.Max = sorted[len(sorted)-1]
+	statistics.Average = average(sorted)
+	statistics.Median = Percentile(sorted, 50)
+	statistics.P10 = Percentile(sorted, 10)
+	statistics.P25 = Percentile(sorted, 25)
+	statistics.P75 = Percentile(sorted, 75)
+	statistics.P90 = Percentile(sorted, 90)
+
+	return statistics
+}
+
+func SalaryGenderGapFor(group string, male, female []int) models.SalaryGenderGap {
+	maleSalary := genderSalary(male)
+	femaleSalary := genderSalary(female)
+
+	return models.SalaryGenderGap{
+		Group:      group,
+		SampleSize: maleSalary.SampleSize + femaleSalary.SampleSize,
+		Male:       maleSalary,
+		Female:     femaleSalary,
+		AverageGap: gapPercent(maleSalary.Average, femaleSalary.Average),
+		MedianGap:  gapPercent(maleSalary.Median, femaleSalary.Median),
+	}
+}
+
+// Percentile uses linear interpolation between closest ranks over an ascending slice.
+func Percentile(sorted []int, percentile float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := percentile / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return float64(sorted[lower])
+	}
+
+	return float64(sorted[lower]) + (rank-float64(lower))*float64(sorted[upper]-sorted[lower])
+}
+
+func genderSalary(salaries []int) models.GenderSalary {
+	sorted := append([]int(nil), salaries...)
+	sort.Ints(sorted)
+
+	return models.GenderSalary{
+		SampleSize: len(sorted),
+		Average:    average(sorted),
+		Median:     Percentile(sorted, 50),
+	}
+}
+
+func gapPercent(male, female float64) float64 {
+	if male == 0 || female == 0 {
+		return 0
+	}
+
+	return math.Round((male-female)/male*10000) / 100
+}
+
+func average(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+
+	return math.Round(float64(total)/float64(len(values))*100) / 100
+}
+
+func sortedGroupNames(groups map[string][]salarySample) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,147 @@
+package reports
+
+import (
+	"context"
+	"employee_exercise/src/pkg/models"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+)
+
+func TestReportService_GetSalaryStatistics_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(salarySamplesQuery)).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01").
+		WillReturnRows(salarySampleRows())
+
+	reportService := &ReportService{ReportManager: db}
+
+	report, err := reportService.GetSalaryStatistics(context.Background(), models.SalaryParameters{
+		AsOf:    date(2000, 1, 1),
+		GroupBy: GroupByDepartment,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, &models.SalaryReport{
+		AsOf:    date(2000, 1, 1),
+		GroupBy: GroupByDepartment,
+		Groups: []models.SalaryStatistics{
+			{Group: "Development", SampleSize: 3, Min: 40000, Max: 60000, Average: 50000, Median: 50000, P10: 42000, P25: 45000, P75: 55000, P90: 58000},
+			{Group: "Sales", SampleSize: 1, Min: 70000, Max: 70000, Average: 70000, Median: 70000, P10: 70000, P25: 70000, P75: 70000, P90: 70000},
+		},
+	}, report)
+}
+
+func TestReportService_GetSalaryGenderGap_Succeeds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(salarySamplesQuery)).
+		ExpectQuery().
+		WithArgs("2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01", "2000-01-01").
+		WillReturnRows(salarySampleRows())
+
+	reportService := &ReportService{ReportManager: db}
+
+	report, err := reportService.GetSalaryGenderGap(context.Background(), models.SalaryParameters{
+		AsOf:    date(2000, 1, 1),
+		GroupBy: GroupByTitle,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, &models.SalaryGenderGapReport{
+		AsOf:    date(2000, 1, 1),
+		GroupBy: GroupByTitle,
+		Groups: []models.SalaryGenderGap{
+			{
+				Group:      "Engineer",
+				SampleSize: 3,
+				Male:       models.GenderSalary{SampleSize: 2, Average: 55000, Median: 55000},
+				Female:     models.GenderSalary{SampleSize: 1, Average: 40000, Median: 40000},
+				AverageGap: 27.27,
+				MedianGap:  27.27,
+			},
+			{
+				Group:      "Staff",
+				SampleSize: 1,
+				Female:     models.GenderSalary{SampleSize: 1, Average: 70000, Median: 70000},
+			},
+		},
+	}, report)
+}
+
+func TestReportService_GetSalaryStatistics_Fails_executing_query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	mock.
+		ExpectPrepare(regexp.QuoteMeta(salarySamplesQuery)).
+		ExpectQuery().
+		WillReturnError(errors.New("error executing query in database"))
+
+	reportService := &ReportService{ReportManager: db}
+
+	report, err := reportService.GetSalaryStatistics(context.Background(), models.SalaryParameters{
+		AsOf:    date(2000, 1, 1),
+		GroupBy: GroupByDepartment,
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, report)
+	assert.Equal(t, errors.New("error executing query in database"), err)
+}
+
+func TestReportService_GetSalaryStatistics_Fails_with_unsupported_group(t *testing.T) {
+	reportService := &ReportService{}
+
+	report, err := reportService.GetSalaryStatistics(context.Background(), models.SalaryParameters{
+		AsOf:    date(2000, 1, 1),
+		GroupBy: GroupByGender,
+	})
+	assert.Nil(t, report)
+	assert.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []int
+		percentile float64
+		expected   float64
+	}{
+		{name: "empty slice", values: nil, percentile: 50, expected: 0},
+		{name: "single value", values: []int{10}, percentile: 90, expected: 10},
+		{name: "odd median", values: []int{1, 2, 3}, percentile: 50, expected: 2},
+		{name: "even median interpolates", values: []int{1, 2, 3, 4}, percentile: 50, expected: 2.5},
+		{name: "lowest rank", values: []int{1, 2, 3, 4}, percentile: 0, expected: 1},
+		{name: "highest rank", values: []int{1, 2, 3, 4}, percentile: 100, expected: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Percentile(tt.values, tt.percentile))
+		})
+	}
+}
+
+func salarySampleRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"dept_name", "title", "gender", "salary"}).
+		AddRow("Development", "Engineer", "M", 60000).
+		AddRow("Development", "Engineer", "F", 40000).
+		AddRow("Development", "Engineer", "M", 50000).
+		AddRow("Sales", "Staff", "F", 70000)
+}
@@ -0,0 +1,151 @@
+package reports
+
+import (
+	"context"
+	"employee_exercise/src/pkg/models"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const tenureStintsQuery = "SELECT de.emp_no, d.dept_name, de.from_date, de.to_date, e.hire_date FROM dept_emp de " +
+	"JOIN departments d ON de.dept_no = d.dept_no JOIN employees e ON de.emp_no = e.emp_no " +
+	"WHERE de.from_date <= ? AND de.to_date > ?"
+
+type tenureBucket struct {
+	label    string
+	maxYears float64
+}
+
+var tenureBuckets = []tenureBucket{
+	{label: "0-1", maxYears: 1},
+	{label: "1-2", maxYears: 2},
+	{label: "2-5", maxYears: 5},
+	{label: "5-10", maxYears: 10},
+	{label: "10-20", maxYears: 20},
+	{label: "20+", maxYears: math.Inf(1)},
+}
+
+func (r *ReportService) GetTenure(ctx context.Context, asOf time.Time) (*models.TenureReport, error) {
+	cacheKey := fmt.Sprintf("tenure:%s", asOf.Format(dateLayout))
+	if cached, ok := r.Cache.Get(cacheKey); ok {
+		return cached.(*models.TenureReport), nil
+	}
+
+	formattedDate := asOf.Format(dateLayout)
+	stints, err := r.getStints(ctx, tenureStintsQuery, formattedDate, formattedDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := ComputeTenure(asOf, stints)
+
+	r.Cache.Set(cacheKey, report)
+
+	return report, nil
+}
+
+// ComputeTenure measures company tenure from hire date to asOf for every employee with a department stint covering asOf.
+// Overlapping stints are merged first, so an employee counts once in the total and once in each of their departments.
+func ComputeTenure(asOf time.Time, stints []Stint) *models.TenureReport {
+	byDepartment := make(map[string][]float64)
+	counted := make(map[int]bool)
+	var all []float64
+	for _, stint := range mergeStints(stints) {
+		if stint.FromDate.After(asOf) || !stint.ToDate.After(asOf) {
+			continue
+		}
+
+		years := TenureYears(stint.HireDate, asOf)
+		byDepartment[stint.Department] = append(byDepartment[stint.Department], years)
+		if !counted[stint.EmployeeNumber] {
+			counted[stint.EmployeeNumber] = true
+			all = append(all, years)
+		}
+	}
+
+	report := &models.TenureReport{
+		AsOf:        asOf,
+		Total:       departmentTenure("all", all),
+		Departments: []models.DepartmentTenure{},
+	}
+
+	for _, department := range departmentNames(stints) {
+		if years, ok := byDepartment[department]; ok {
+			report.Departments = append(report.Departments, departmentTenure(department, years))
+		}
+	}
+
+	return report
+}
+
+// mergeStints returns the stints of every employee with the overlapping or adjacent stints in the same department
+// merged into one, as duplicated dept_emp rows would otherwise count the employee several times.
+func mergeStints(stints []Stint) []Stint {
+	var merged []Stint
+	for _, employeeStints := range stintsByEmployee(stints) {
+		latest := make(map[string]int)
+		for _, stint := range employeeStints {
+			if i, ok := latest[stint.Department]; ok && !stint.FromDate.After(merged[i].ToDate) {
+				if stint.ToDate.After(merged[i].ToDate) {
+					merged[i].ToDate = stint.ToDate
+				}
+				continue
+			}
+
+			latest[stint.Department] = len(merged)
+			merged = append(merged, stint)
+		}
+	}
+
+	return merged
+}
+
+func TenureYears(hireDate, asOf time.Time) float64 {
+	return asOf.Sub(hireDate).Hours() / 24 / 365.25
+}
+
+func departmentTenure(department string, years []float64) models.DepartmentTenure {
+	sorted := append([]float64(nil), years...)
+	sort.Float64s(sorted)
+
+	tenure := models.DepartmentTenure{
+		Department:   department,
+		Employees:    len(sorted),
+		Distribution: make([]models.TenureBucket, len(tenureBuckets)),
+	}
+
+	for i, bucket := range tenureBuckets {
+		tenure.Distribution[i].Label = bucket.label
+	}
+
+	total := 0.0
+	for _, value := range sorted {
+		total += value
+		for i, bucket := range tenureBuckets {
+			if value < bucket.maxYears {
+				tenure.Distribution[i].Employees++
+				break
+			}
+		}
+	}
+
+	if len(sorted) == 0 {
+		return tenure
+	}
+
+	tenure.AverageYears = roundYears(total / float64(len(sorted)))
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		tenure.MedianYears = roundYears((sorted[middle-1] + sorted[middle]) / 2)
+	} else {
+		tenure.MedianYears = roundYears(sorted[middle])
+	}
+
+	return tenure
+}
+
+func roundYears(years float64) float64 {
+	return math.Round(years*100) / 100
+}
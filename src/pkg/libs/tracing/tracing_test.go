@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"employee_exercise/src/pkg/libs/config"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware_Continues_incoming_trace(t *testing.T) {
+	recorder := useRecorder(t)
+
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.HandleFunc("/employees/{emp_no}/timeline", func(w http.ResponseWriter, r *http.Request) {
+		_, span := StartSQL(r.Context(), "getEmployeeByID", "SELECT * FROM employees WHERE emp_no=10001")
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/employees/10001/timeline", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	sqlSpan, serverSpan := spans[0], spans[1]
+	assert.Equal(t, "GET /employees/{emp_no}/timeline", serverSpan.Name())
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, serverSpan.Status().Code)
+	assert.Contains(t, serverSpan.Attributes(), attribute.Int("http.response.status_code", 500))
+
+	assert.Equal(t, "sql getEmployeeByID", sqlSpan.Name())
+	assert.Equal(t, serverSpan.SpanContext().SpanID(), sqlSpan.Parent().SpanID())
+	assert.Contains(t, sqlSpan.Attributes(), attribute.String("db.statement", "SELECT * FROM employees WHERE emp_no=10001"))
+}
+
+func TestRecordError(t *testing.T) {
+	recorder := useRecorder(t)
+
+	_, span := StartSQL(context.Background(), "getTotalEmployees", "SELECT COUNT(*) FROM employees")
+	RecordError(span, errors.New("connection reset"))
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "connection reset", spans[0].Status().Description)
+	assert.Len(t, spans[0].Events(), 1)
+}
+
+func TestSetup(t *testing.T) {
+	var out bytes.Buffer
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: ExporterStdout}, &out)
+	assert.NoError(t, err)
+
+	_, span := Tracer().Start(context.Background(), "EmployeeService.GetEmployees")
+	span.End()
+
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, out.String(), `"Name":"EmployeeService.GetEmployees"`)
+
+	_, err = Setup(context.Background(), config.TracingConfig{Exporter: "zipkin"}, &out)
+	assert.EqualError(t, err, "unknown tracing exporter: zipkin")
+}
+
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagator())
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
@@ -0,0 +1,273 @@
+package validation
+
+import (
+	"employee_exercise/src/pkg/libs/problem"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DateLayout is the format of every date the API accepts.
+const DateLayout = "2006-01-02"
+
+// Codes of the field errors, stable like the problem codes.
+const (
+	CodeRequired  = "required"
+	CodeInvalid   = "invalid"
+	CodeMin       = "min"
+	CodeMax       = "max"
+	CodePattern   = "pattern"
+	CodeDate      = "date"
+	CodeNotBefore = "not_before"
+	CodeUnknown   = "unknown"
+)
+
+// Struct checks the fields of v, a struct or a pointer to one, against their `validate` tags and returns every
+// violation, in field order, at most one per field. Fields are named after their json or query tag.
+//
+// Rules are separated by commas:
+//
+//	required       the field is not its zero value, or not nil for a pointer
+//	min=N, max=N   numbers are within N, strings have at least or at most N characters
+//	pattern=RE     strings match the regular expression, which cannot contain a comma
+//	date           strings are dates formatted as YYYY-MM-DD
+//	notbefore=F    a date that is not before the date of field F
+//	oneof=A B      strings are one of the space separated values, regardless of case
+//
+// Apart from required, rules are skipped for zero strings and nil pointers, so optional fields can be left out.
+func Struct(v interface{}) []problem.FieldError {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	fields := fieldsOf(value.Type())
+
+	values := make(map[string]reflect.Value, len(fields))
+	for _, field := range fields {
+		values[field.name] = value.FieldByIndex(field.index)
+	}
+
+	var violations []problem.FieldError
+	for _, field := range fields {
+		for _, rule := range field.rules {
+			if violation := rule.check(values[field.name], values); violation != nil {
+				violation.Field = field.name
+				violations = append(violations, *violation)
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// Query sets the fields of v, a pointer to a struct, that have a `query` tag from the query parameters and
+// validates v. A parameter that does not parse is reported and its field is left unset.
+func Query(query url.Values, v interface{}) []problem.FieldError {
+	value := reflect.ValueOf(v).Elem()
+
+	var violations []problem.FieldError
+	unparsed := make(map[string]bool)
+	for _, field := range fieldsOf(value.Type()) {
+		if !field.query || !query.Has(field.name) {
+			continue
+		}
+		if err := set(value.FieldByIndex(field.index), query.Get(field.name)); err != nil {
+			violations = append(violations, problem.FieldError{Field: field.name, Code: CodeInvalid, Message: err.Error()})
+			unparsed[field.name] = true
+		}
+	}
+
+	for _, violation := range Struct(v) {
+		if !unparsed[violation.Field] {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+func set(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(parsed)
+	default:
+		panic(fmt.Sprintf("validation: unsupported query field kind %s", field.Kind()))
+	}
+	return nil
+}
+
+type field struct {
+	name  string
+	index []int
+	query bool
+	rules []rule
+}
+
+type rule struct {
+	name     string
+	argument string
+	number   int64
+	pattern  *regexp.Regexp
+	values   []string
+}
+
+var cache sync.Map
+
+// fieldsOf parses the tags of a struct type once. Embedded structs contribute their own fields. Malformed tags
+// are programming errors and panic.
+func fieldsOf(structType reflect.Type) []field {
+	if cached, ok := cache.Load(structType); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	for i := 0; i < structType.NumField(); i++ {
+		structField := structType.Field(i)
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			for _, embedded := range fieldsOf(structField.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+		if !structField.IsExported() {
+			continue
+		}
+
+		field := field{name: structField.Name, index: []int{i}}
+		if name, ok := tagName(structField.Tag, "query"); ok {
+			field.name, field.query = name, true
+		} else if name, ok := tagName(structField.Tag, "json"); ok {
+			field.name = name
+		}
+		if tag := structField.Tag.Get("validate"); tag != "" {
+			for _, definition := range strings.Split(tag, ",") {
+				field.rules = append(field.rules, parseRule(structType, structField.Name, definition))
+			}
+		}
+		fields = append(fields, field)
+	}
+
+	cache.Store(structType, fields)
+	return fields
+}
+
+func tagName(tag reflect.StructTag, key string) (string, bool) {
+	name, _, _ := strings.Cut(tag.Get(key), ",")
+	return name, name != "" && name != "-"
+}
+
+func parseRule(structType reflect.Type, fieldName, definition string) rule {
+	name, argument, _ := strings.Cut(definition, "=")
+	parsed := rule{name: name, argument: argument}
+
+	var err error
+	switch name {
+	case "required", "date":
+	case "min", "max":
+		parsed.number, err = strconv.ParseInt(argument, 10, 64)
+	case "pattern":
+		parsed.pattern, err = regexp.Compile(argument)
+	case "notbefore":
+		if argument == "" {
+			err = errors.New("missing field")
+		}
+	case "oneof":
+		if parsed.values = strings.Fields(argument); len(parsed.values) == 0 {
+			err = errors.New("missing values")
+		}
+	default:
+		err = errors.New("unknown rule")
+	}
+	if err != nil {
+		panic(fmt.Sprintf("validation: %s.%s: rule %q: %v", structType, fieldName, definition, err))
+	}
+	return parsed
+}
+
+func (r rule) check(value reflect.Value, values map[string]reflect.Value) *problem.FieldError {
+	if r.name == "required" {
+		if value.IsZero() {
+			return &problem.FieldError{Code: CodeRequired, Message: "is required"}
+		}
+		return nil
+	}
+
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.String && value.String() == "" {
+		return nil
+	}
+
+	switch r.name {
+	case "min":
+		if size(value) < r.number {
+			return &problem.FieldError{Code: CodeMin, Message: fmt.Sprintf("must be at least %d%s", r.number, unit(value))}
+		}
+	case "max":
+		if size(value) > r.number {
+			return &problem.FieldError{Code: CodeMax, Message: fmt.Sprintf("must be at most %d%s", r.number, unit(value))}
+		}
+	case "pattern":
+		if !r.pattern.MatchString(value.String()) {
+			return &problem.FieldError{Code: CodePattern, Message: "must match " + r.pattern.String()}
+		}
+	case "date":
+		if _, err := time.Parse(DateLayout, value.String()); err != nil {
+			return &problem.FieldError{Code: CodeDate, Message: "must be a date formatted as YYYY-MM-DD"}
+		}
+	case "notbefore":
+		date, err := time.Parse(DateLayout, value.String())
+		if err != nil {
+			return nil
+		}
+		other, ok := values[r.argument]
+		if !ok || other.Kind() != reflect.String {
+			return nil
+		}
+		start, err := time.Parse(DateLayout, other.String())
+		if err == nil && date.Before(start) {
+			return &problem.FieldError{Code: CodeNotBefore, Message: "must not be before " + r.argument}
+		}
+	case "oneof":
+		for _, allowed := range r.values {
+			if strings.EqualFold(allowed, value.String()) {
+				return nil
+			}
+		}
+		return &problem.FieldError{Code: CodeInvalid, Message: "must be one of " + strings.Join(r.values, ", ")}
+	}
+	return nil
+}
+
+// size is the number of a numeric field and the length of a string field.
+func size(value reflect.Value) int64 {
+	if value.Kind() == reflect.String {
+		return int64(len(value.String()))
+	}
+	return value.Int()
+}
+
+func unit(value reflect.Value) string {
+	if value.Kind() == reflect.String {
+		return " characters"
+	}
+	return ""
+}
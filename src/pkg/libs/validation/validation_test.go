@@ -0,0 +1,141 @@
+package validation
+
+import (
+	"employee_exercise/src/pkg/libs/problem"
+	"employee_exercise/src/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+)
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name               string
+		request            models.EmployeeDepartmentRequest
+		expectedViolations []problem.FieldError
+	}{
+		{
+			name:    "valid request",
+			request: models.EmployeeDepartmentRequest{EmployeeNumber: 10002, Department: "d006", FromDate: "1996-08-03", ToDate: "1996-08-03"},
+		},
+		{
+			name: "every field missing",
+			expectedViolations: []problem.FieldError{
+				{Field: "emp_no", Code: CodeRequired, Message: "is required"},
+				{Field: "dept_no", Code: CodeRequired, Message: "is required"},
+				{Field: "from_date", Code: CodeRequired, Message: "is required"},
+				{Field: "to_date", Code: CodeRequired, Message: "is required"},
+			},
+		},
+		{
+			name:    "every field invalid",
+			request: models.EmployeeDepartmentRequest{EmployeeNumber: -1, Department: "d0006", FromDate: "03/08/1996", ToDate: "1996-02-30"},
+			expectedViolations: []problem.FieldError{
+				{Field: "emp_no", Code: CodeMin, Message: "must be at least 1"},
+				{Field: "dept_no", Code: CodePattern, Message: `must match ^d\d{3}$`},
+				{Field: "from_date", Code: CodeDate, Message: "must be a date formatted as YYYY-MM-DD"},
+				{Field: "to_date", Code: CodeDate, Message: "must be a date formatted as YYYY-MM-DD"},
+			},
+		},
+		{
+			name:    "dates out of order",
+			request: models.EmployeeDepartmentRequest{EmployeeNumber: 10002, Department: "d006", FromDate: "1996-08-04", ToDate: "1996-08-03"},
+			expectedViolations: []problem.FieldError{
+				{Field: "to_date", Code: CodeNotBefore, Message: "must not be before from_date"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedViolations, Struct(&tt.request))
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	one, hundred := 1, 100
+
+	tests := []struct {
+		name               string
+		query              string
+		expectedQuery      models.AuditQuery
+		expectedViolations []problem.FieldError
+	}{
+		{
+			name: "no parameters",
+		},
+		{
+			name:          "every parameter",
+			query:         "emp_no=1&actor=jwt:alice&from=2024-03-01&to=2024-03-02&limit=100&page=1",
+			expectedQuery: models.AuditQuery{EmployeeNumber: &one, Actor: "jwt:alice", From: "2024-03-01", To: "2024-03-02", PageRequest: models.PageRequest{Limit: &hundred, Page: &one}},
+		},
+		{
+			name:          "every parameter invalid",
+			query:         "emp_no=abc&from=2024-03-02&to=2024-03-01&limit=1001&page=0",
+			expectedQuery: models.AuditQuery{From: "2024-03-02", To: "2024-03-01"},
+			expectedViolations: []problem.FieldError{
+				{Field: "emp_no", Code: CodeInvalid, Message: "must be an integer"},
+				{Field: "to", Code: CodeNotBefore, Message: "must not be before from"},
+				{Field: "limit", Code: CodeMax, Message: "must be at most 1000"},
+				{Field: "page", Code: CodeMin, Message: "must be at least 1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			var query models.AuditQuery
+
+			violations := Query(values, &query)
+
+			assert.Equal(t, tt.expectedViolations, violations)
+			if tt.expectedViolations == nil {
+				assert.Equal(t, tt.expectedQuery, query)
+			}
+		})
+	}
+}
+
+func TestQuery_One_of(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		expectedQuery      models.EmployeeQuery
+		expectedViolations []problem.FieldError
+	}{
+		{
+			name:          "allowed values regardless of case",
+			query:         "orderBy=hire_date&order=DESC",
+			expectedQuery: models.EmployeeQuery{OrderBy: "hire_date", Order: "DESC"},
+		},
+		{
+			name:  "values out of the list",
+			query: "orderBy=emp_no%3BDROP%20TABLE%20employees&order=up",
+			expectedViolations: []problem.FieldError{
+				{Field: "orderBy", Code: CodeInvalid, Message: "must be one of emp_no, birth_date, first_name, last_name, gender, hire_date"},
+				{Field: "order", Code: CodeInvalid, Message: "must be one of asc, desc"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			var query models.EmployeeQuery
+
+			violations := Query(values, &query)
+
+			assert.Equal(t, tt.expectedViolations, violations)
+			if tt.expectedViolations == nil {
+				assert.Equal(t, tt.expectedQuery, query)
+			}
+		})
+	}
+}
+
+func TestStruct_Panics_on_malformed_tags(t *testing.T) {
+	var request struct {
+		Name string `validate:"size=3"`
+	}
+
+	assert.Panics(t, func() { Struct(request) })
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Owner      string     `json:"owner"`
+	Prefix     string     `json:"prefix"`
+	Admin      bool       `json:"admin"`
+	Roles      []string   `json:"roles"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyRequest struct {
+	Owner string   `json:"owner" validate:"required,max=100"`
+	Admin bool     `json:"admin"`
+	Roles []string `json:"roles"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEntry struct {
+	ID             int64           `json:"id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Actor          string          `json:"actor"`
+	RequestID      string          `json:"request_id,omitempty"`
+	Action         string          `json:"action"`
+	EmployeeNumber *int            `json:"emp_no,omitempty"`
+	Before         json.RawMessage `json:"before"`
+	After          json.RawMessage `json:"after"`
+}
+
+type AuditResponse struct {
+	Page    int          `json:"page"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+// AuditQuery is the query parameters of the audit log. from and to are inclusive days.
+type AuditQuery struct {
+	EmployeeNumber *int   `query:"emp_no" validate:"min=1"`
+	Actor          string `query:"actor"`
+	From           string `query:"from" validate:"date"`
+	To             string `query:"to" validate:"date,notbefore=from"`
+	PageRequest
+}
+
+// AuditFilter selects audit entries, zero values match everything. To is exclusive.
+type AuditFilter struct {
+	EmployeeNumber int
+	Actor          string
+	From           time.Time
+	To             time.Time
+	Limit          int
+	Offset         int
+}
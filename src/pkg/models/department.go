@@ -8,10 +8,10 @@ type Department struct {
 }
 
 type EmployeeDepartmentRequest struct {
-	EmployeeNumber int    `json:"emp_no"`
-	Department     string `json:"dept_no"`
-	FromDate       string `json:"from_date"`
-	ToDate         string `json:"to_date"`
+	EmployeeNumber int    `json:"emp_no" validate:"required,min=1"`
+	Department     string `json:"dept_no" validate:"required,pattern=^d\\d{3}$"`
+	FromDate       string `json:"from_date" validate:"required,date"`
+	ToDate         string `json:"to_date" validate:"required,date,notbefore=from_date"`
 }
 
 type EmployeeDepartment struct {
@@ -12,8 +12,52 @@ type Employee struct {
 	Department     string    `json:"department"`
 }
 
+// PageRequest is the limit and page query parameters of a listing, nil when absent.
+type PageRequest struct {
+	Limit *int `query:"limit" validate:"min=1,max=1000"`
+	Page  *int `query:"page" validate:"min=1"`
+}
+
+// EmployeeQuery is the query parameters of the employees listing. The columns are the ones the gRPC and GraphQL
+// listings order by too.
+type EmployeeQuery struct {
+	OrderBy string `query:"orderBy" validate:"oneof=emp_no birth_date first_name last_name gender hire_date"`
+	Order   string `query:"order" validate:"oneof=asc desc"`
+	PageRequest
+}
+
 type EmployeeResponse struct {
 	Total     int        `json:"total"`
 	Page      int        `json:"page"`
 	Employees []Employee `json:"employees"`
 }
+
+type TimelineEvent struct {
+	Date           time.Time `json:"date"`
+	Type           string    `json:"type"`
+	Department     string    `json:"dept_no,omitempty"`
+	DepartmentName string    `json:"dept_name,omitempty"`
+	Title          string    `json:"title,omitempty"`
+	PreviousTitle  string    `json:"previous_title,omitempty"`
+	Salary         int       `json:"salary,omitempty"`
+	PreviousSalary int       `json:"previous_salary,omitempty"`
+}
+
+type EmployeeTimeline struct {
+	EmployeeNumber int             `json:"emp_no"`
+	FirstName      string          `json:"first_name"`
+	LastName       string          `json:"last_name"`
+	Events         []TimelineEvent `json:"events"`
+}
+
+type Title struct {
+	Title    string    `json:"title"`
+	FromDate time.Time `json:"from_date"`
+	ToDate   time.Time `json:"to_date"`
+}
+
+type Salary struct {
+	Salary   int       `json:"salary"`
+	FromDate time.Time `json:"from_date"`
+	ToDate   time.Time `json:"to_date"`
+}
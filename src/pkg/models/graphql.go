@@ -0,0 +1,8 @@
+package models
+
+// GraphQLRequest is the body of a POST to /graphql.
+type GraphQLRequest struct {
+	Query         string                 `json:"query" validate:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
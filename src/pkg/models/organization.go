@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type OrgChartEmployee struct {
+	EmployeeNumber int    `json:"emp_no"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	Title          string `json:"title"`
+}
+
+type OrgChartDepartment struct {
+	DepartmentNumber string             `json:"dept_no"`
+	DepartmentName   string             `json:"dept_name"`
+	Manager          *OrgChartEmployee  `json:"manager"`
+	Members          []OrgChartEmployee `json:"members"`
+}
+
+type OrgChart struct {
+	AsOf        time.Time            `json:"as_of"`
+	Departments []OrgChartDepartment `json:"departments"`
+}
@@ -0,0 +1,123 @@
+package models
+
+import "time"
+
+type HeadcountParameters struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+	GroupBy  string
+}
+
+type HeadcountGroup struct {
+	Group     string `json:"group"`
+	Headcount int    `json:"headcount"`
+}
+
+type HeadcountPeriod struct {
+	Date   time.Time        `json:"date"`
+	Total  int              `json:"total"`
+	Groups []HeadcountGroup `json:"groups"`
+}
+
+type HeadcountReport struct {
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Interval string            `json:"interval"`
+	GroupBy  string            `json:"group_by"`
+	Periods  []HeadcountPeriod `json:"periods"`
+}
+
+type SalaryParameters struct {
+	AsOf    time.Time
+	GroupBy string
+}
+
+type SalaryStatistics struct {
+	Group      string  `json:"group"`
+	SampleSize int     `json:"sample_size"`
+	Min        int     `json:"min"`
+	Max        int     `json:"max"`
+	Average    float64 `json:"average"`
+	Median     float64 `json:"median"`
+	P10        float64 `json:"p10"`
+	P25        float64 `json:"p25"`
+	P75        float64 `json:"p75"`
+	P90        float64 `json:"p90"`
+}
+
+type SalaryReport struct {
+	AsOf    time.Time          `json:"as_of"`
+	GroupBy string             `json:"group_by"`
+	Groups  []SalaryStatistics `json:"groups"`
+}
+
+type GenderSalary struct {
+	SampleSize int     `json:"sample_size"`
+	Average    float64 `json:"average"`
+	Median     float64 `json:"median"`
+}
+
+type SalaryGenderGap struct {
+	Group      string       `json:"group"`
+	SampleSize int          `json:"sample_size"`
+	Male       GenderSalary `json:"male"`
+	Female     GenderSalary `json:"female"`
+	AverageGap float64      `json:"average_gap_percent"`
+	MedianGap  float64      `json:"median_gap_percent"`
+}
+
+type SalaryGenderGapReport struct {
+	AsOf    time.Time         `json:"as_of"`
+	GroupBy string            `json:"group_by"`
+	Groups  []SalaryGenderGap `json:"groups"`
+}
+
+type AttritionParameters struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+}
+
+type DepartmentAttrition struct {
+	Department    string  `json:"department"`
+	Headcount     int     `json:"headcount"`
+	Hires         int     `json:"hires"`
+	Departures    int     `json:"departures"`
+	TransfersIn   int     `json:"transfers_in"`
+	TransfersOut  int     `json:"transfers_out"`
+	AttritionRate float64 `json:"attrition_rate_percent"`
+}
+
+type AttritionPeriod struct {
+	Start       time.Time             `json:"start"`
+	End         time.Time             `json:"end"`
+	Total       DepartmentAttrition   `json:"total"`
+	Departments []DepartmentAttrition `json:"departments"`
+}
+
+type AttritionReport struct {
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Interval string            `json:"interval"`
+	Periods  []AttritionPeriod `json:"periods"`
+}
+
+type TenureBucket struct {
+	Label     string `json:"label"`
+	Employees int    `json:"employees"`
+}
+
+type DepartmentTenure struct {
+	Department   string         `json:"department"`
+	Employees    int            `json:"employees"`
+	AverageYears float64        `json:"average_years"`
+	MedianYears  float64        `json:"median_years"`
+	Distribution []TenureBucket `json:"distribution"`
+}
+
+type TenureReport struct {
+	AsOf        time.Time          `json:"as_of"`
+	Total       DepartmentTenure   `json:"total"`
+	Departments []DepartmentTenure `json:"departments"`
+}